@@ -0,0 +1,373 @@
+package archive
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// 奇数宽度，覆盖逐行差分的行边界
+const testW, testH = 13, 7
+
+func testHeader(c Compression) Header {
+	return Header{
+		Width: testW, Height: testH, DepthScale: 0.00025, Compression: c,
+		Intrinsics: &depth.Intrinsics{Width: testW, Height: testH, PPX: 6.5, PPY: 3.5, FX: 10, FY: 10},
+		Created:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Metadata:   map[string]string{"serial_number": "123"},
+	}
+}
+
+// testData 生成第 n 帧：带无效点和大跳变的深度
+func testData(n int) []uint16 {
+	data := make([]uint16, testW*testH)
+	for i := range data {
+		switch {
+		case i%5 == 0:
+		case i%11 == 0:
+			data[i] = 65535
+		default:
+			data[i] = uint16(500 + 17*i + 100*n)
+		}
+	}
+	return data
+}
+
+// writeArchive 写入 n 帧，帧号从 100 开始，时间戳间隔 33ms；close 为假时只刷新不写索引
+func writeArchive(t *testing.T, c Compression, n int, close bool) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.rsda")
+	w, err := Create(path, testHeader(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := w.WriteFrame(testData(i), uint64(100+i), float64(1000+33*i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if close {
+		err = w.Close()
+	} else {
+		// 模拟进程退出：数据已刷新到文件，但没有索引和文件尾
+		err = w.Flush()
+		w.f.Close()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func openArchive(t *testing.T, path string) *Reader {
+	t.Helper()
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []Compression{CompressionDeflate, CompressionNone} {
+		t.Run(string(c), func(t *testing.T) {
+			const n = 10
+			r := openArchive(t, writeArchive(t, c, n, true))
+			if r.Recovered() || r.Len() != n {
+				t.Fatalf("recovered %v, len %d", r.Recovered(), r.Len())
+			}
+			want := testHeader(c)
+			got := r.Header()
+			if got.Width != want.Width || got.Height != want.Height || got.DepthScale != want.DepthScale ||
+				got.Compression != c || !got.Created.Equal(want.Created) || *got.Intrinsics != *want.Intrinsics ||
+				got.Metadata["serial_number"] != "123" {
+				t.Errorf("header %+v", got)
+			}
+
+			// 倒序随机访问
+			for i := n - 1; i >= 0; i-- {
+				f, err := r.Frame(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f.Index != i || f.FrameNumber != uint64(100+i) || f.Timestamp != float64(1000+33*i) {
+					t.Errorf("frame %d: %+v", i, f)
+				}
+				if !slices.Equal(f.Data, testData(i)) {
+					t.Errorf("frame %d data differs", i)
+				}
+			}
+
+			// 顺序读取
+			for i := 0; ; i++ {
+				f, err := r.Next()
+				if err == io.EOF {
+					if i != n {
+						t.Errorf("EOF after %d frames", i)
+					}
+					break
+				}
+				if err != nil || f.Index != i {
+					t.Fatalf("Next %d: %+v %v", i, f.Index, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSeekAndFind(t *testing.T) {
+	r := openArchive(t, writeArchive(t, CompressionDeflate, 5, true))
+
+	if err := r.Seek(3); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := r.Next(); err != nil || f.Index != 3 {
+		t.Errorf("after Seek(3): %d %v", f.Index, err)
+	}
+	if err := r.Seek(5); err != nil { // 末尾是合法位置
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next at end: %v", err)
+	}
+	for _, i := range []int{-1, 6} {
+		if err := r.Seek(i); err == nil {
+			t.Errorf("Seek(%d) succeeded", i)
+		}
+	}
+	for _, i := range []int{-1, 5} {
+		if _, err := r.Frame(i); err == nil {
+			t.Errorf("Frame(%d) succeeded", i)
+		}
+	}
+
+	// 时间戳为 1000, 1033, 1066, 1099, 1132
+	tests := []struct {
+		ts   float64
+		want int
+	}{
+		{0, 0},
+		{1000, 0},
+		{1000.5, 1},
+		{1066, 2},
+		{1132, 4},
+		{1133, 5},
+	}
+	for _, tt := range tests {
+		if got := r.FindTimestamp(tt.ts); got != tt.want {
+			t.Errorf("FindTimestamp(%g) = %d, want %d", tt.ts, got, tt.want)
+		}
+	}
+	if i, ok := r.FindFrameNumber(102); !ok || i != 2 {
+		t.Errorf("FindFrameNumber(102) = %d %v", i, ok)
+	}
+	if _, ok := r.FindFrameNumber(99); ok {
+		t.Error("FindFrameNumber(99) found a frame")
+	}
+
+	f, _ := r.Frame(1)
+	rf, err := r.ROIFrame(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.Scale != 0.00025 || rf.FrameNumber != 101 || rf.Intrinsics == nil || rf.Depth.Width != testW {
+		t.Errorf("roi frame %+v", rf)
+	}
+}
+
+func TestRecoverUnclosedWriter(t *testing.T) {
+	for _, c := range []Compression{CompressionDeflate, CompressionNone} {
+		t.Run(string(c), func(t *testing.T) {
+			path := writeArchive(t, c, 6, false)
+			r := openArchive(t, path)
+			if !r.Recovered() || r.Len() != 6 {
+				t.Fatalf("recovered %v, len %d", r.Recovered(), r.Len())
+			}
+			for i := 0; i < 6; i++ {
+				if f, err := r.Frame(i); err != nil || !slices.Equal(f.Data, testData(i)) {
+					t.Fatalf("frame %d: %v", i, err)
+				}
+			}
+
+			// 最后一帧只写入了一部分：忽略该帧
+			fi, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, cut := range []int64{1, 10, frameHeaderSize + 1} {
+				if err := os.Truncate(path, fi.Size()-cut); err != nil {
+					t.Fatal(err)
+				}
+				r := openArchive(t, path)
+				if !r.Recovered() || r.Len() != 5 {
+					t.Errorf("cut %d: recovered %v, len %d", cut, r.Recovered(), r.Len())
+				}
+				if f, err := r.Frame(4); err != nil || !slices.Equal(f.Data, testData(4)) {
+					t.Errorf("cut %d: frame 4: %v", cut, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverDamagedTrailer(t *testing.T) {
+	path := writeArchive(t, CompressionDeflate, 4, true)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 索引偏移指向错误位置时退回扫描
+	binary.LittleEndian.PutUint64(b[len(b)-trailerSize:], 1)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := openArchive(t, path)
+	if !r.Recovered() || r.Len() != 4 {
+		t.Errorf("recovered %v, len %d", r.Recovered(), r.Len())
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	path := writeArchive(t, CompressionNone, 3, true)
+	r := openArchive(t, path)
+	offset := r.index[1].offset + frameHeaderSize + 7
+	r.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[offset] ^= 0x01
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r = openArchive(t, path)
+	if _, err := r.Frame(1); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("frame 1: %v", err)
+	}
+	// 其它帧不受影响
+	for _, i := range []int{0, 2} {
+		if _, err := r.Frame(i); err != nil {
+			t.Errorf("frame %d: %v", i, err)
+		}
+	}
+}
+
+func TestCreateValidatesHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Header)
+		err    string
+	}{
+		{"zero size", func(h *Header) { h.Width = 0 }, "invalid frame size"},
+		{"zero scale", func(h *Header) { h.DepthScale = 0 }, "invalid depth scale"},
+		{"compression", func(h *Header) { h.Compression = "zstd" }, "unknown compression"},
+		{"intrinsics size", func(h *Header) { h.Intrinsics = &depth.Intrinsics{Width: 640, Height: 480} },
+			"intrinsics 640x480 do not match frame size 13x7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHeader(CompressionDeflate)
+			tt.modify(&h)
+			_, err := Create(filepath.Join(t.TempDir(), "x.rsda"), h)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsInvalidHeader(t *testing.T) {
+	// writeRaw 写出只有文件头的归档
+	writeRaw := func(t *testing.T, magic string, version uint16, meta []byte) string {
+		path := filepath.Join(t.TempDir(), "x.rsda")
+		var head [fileHeaderSize]byte
+		copy(head[:], magic)
+		binary.LittleEndian.PutUint16(head[4:], version)
+		binary.LittleEndian.PutUint32(head[8:], uint32(len(meta)))
+		if err := os.WriteFile(path, append(head[:], meta...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	h := testHeader(CompressionDeflate)
+	h.Intrinsics.Width = 640
+	mismatched, _ := json.Marshal(h)
+	valid, _ := json.Marshal(testHeader(CompressionDeflate))
+
+	tests := []struct {
+		name    string
+		magic   string
+		version uint16
+		meta    []byte
+		err     string
+	}{
+		{"magic", "RIFF", Version, valid, "not a depth archive"},
+		{"version", fileMagic, Version + 1, valid, "unsupported version 2"},
+		{"json", fileMagic, Version, []byte("{"), "invalid header"},
+		{"intrinsics size", fileMagic, Version, mismatched, "intrinsics 640x7 do not match frame size 13x7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(writeRaw(t, tt.magic, tt.version, tt.meta))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	// 文件头声明的长度超过文件大小
+	path := writeRaw(t, fileMagic, Version, valid)
+	b, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, b[:len(b)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "truncated header") {
+		t.Errorf("truncated header: %v", err)
+	}
+
+	// 有效文件头、没有帧
+	r := openArchive(t, writeRaw(t, fileMagic, Version, valid))
+	if r.Len() != 0 || !r.Recovered() {
+		t.Errorf("empty archive: len %d recovered %v", r.Len(), r.Recovered())
+	}
+}
+
+func TestWriteFrameErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.rsda")
+	h := Header{Width: 512, Height: 512, DepthScale: 0.001, Compression: CompressionNone}
+	w, err := Create(path, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFrame(make([]uint16, 10), 0, 0); err == nil {
+		t.Error("short frame accepted")
+	}
+
+	// 文件被关闭后，缓冲写满时 WriteFrame 返回写入错误，而不是静默丢帧
+	w.f.Close()
+	data := make([]uint16, 512*512)
+	for i := 0; i < 4 && err == nil; i++ {
+		err = w.WriteFrame(data, uint64(i), 0)
+	}
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("error %v, want os.ErrClosed", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close after a write error succeeded")
+	}
+	if err := w.WriteFrame(data, 9, 0); err == nil || !strings.Contains(err.Error(), "writer closed") {
+		t.Errorf("write after Close: %v", err)
+	}
+}
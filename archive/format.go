@@ -0,0 +1,87 @@
+// Package archive 读写无损 Z16 深度归档文件 (.rsda)
+// 每帧保存原始深度值、帧号和时间戳，文件头保存分辨率、深度比例和内参，
+// 文件尾的索引支持按帧号位置随机访问，可用于回放 ROI 等逻辑做回归测试
+//
+// 文件布局（小端序）：
+//
+//	文件头  "RSDA" | version uint16 | reserved uint16 | len uint32 | Header (JSON)
+//	帧记录  "FRME" | frame_number uint64 | timestamp float64 | crc32 uint32 | len uint32 | data
+//	索引    "RSDI" | count uint32 | count × (offset uint64, frame_number uint64, timestamp float64)
+//	文件尾  index_offset uint64 | "RSDE"
+//
+// data 为逐行差分后的 uint16 (小端) 经 deflate 压缩的结果，crc32 针对压缩前的数据；
+// 写入未正常关闭（缺少索引）时，读取端会顺序扫描帧记录重建索引
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+const (
+	fileMagic    = "RSDA"
+	frameMagic   = "FRME"
+	indexMagic   = "RSDI"
+	trailerMagic = "RSDE"
+
+	// Version 当前文件格式版本
+	Version = 1
+
+	fileHeaderSize  = 12
+	frameHeaderSize = 4 + 8 + 8 + 4 + 4
+	indexEntrySize  = 8 + 8 + 8
+	trailerSize     = 8 + 4
+)
+
+// Compression 帧数据压缩方式
+type Compression string
+
+const (
+	CompressionDeflate Compression = "deflate" // 逐行差分 + deflate，默认
+	CompressionNone    Compression = "none"    // 原始 uint16 小端，写入最快
+)
+
+// Header 是归档的元数据
+type Header struct {
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	DepthScale  float32           `json:"depth_scale"`          // 米/单位
+	Intrinsics  *depth.Intrinsics `json:"intrinsics,omitempty"` // 深度流内参，未知时为空
+	Compression Compression       `json:"compression"`
+	Created     time.Time         `json:"created"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // 设备序列号、预设等自定义信息
+}
+
+// validate 校验元数据
+func (h Header) validate() error {
+	if h.Width <= 0 || h.Height <= 0 {
+		return fmt.Errorf("archive: invalid frame size %dx%d", h.Width, h.Height)
+	}
+	if h.DepthScale <= 0 {
+		return fmt.Errorf("archive: invalid depth scale %g", h.DepthScale)
+	}
+	if h.Compression != CompressionDeflate && h.Compression != CompressionNone {
+		return fmt.Errorf("archive: unknown compression %q", h.Compression)
+	}
+	if in := h.Intrinsics; in != nil && (in.Width != h.Width || in.Height != h.Height) {
+		return fmt.Errorf("archive: intrinsics %dx%d do not match frame size %dx%d", in.Width, in.Height, h.Width, h.Height)
+	}
+	return nil
+}
+
+// Frame 是归档中的一帧
+type Frame struct {
+	Index       int // 在归档中的序号，从 0 开始
+	FrameNumber uint64
+	Timestamp   float64 // 毫秒
+	Data        []uint16
+}
+
+// indexEntry 是一帧在文件中的位置
+type indexEntry struct {
+	offset      int64
+	frameNumber uint64
+	timestamp   float64
+}
@@ -0,0 +1,286 @@
+package archive
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/internal/z16"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+)
+
+// Reader 按序号随机读取深度归档
+type Reader struct {
+	f         *os.File
+	hdr       Header
+	index     []indexEntry
+	recovered bool
+	next      int
+
+	raw  []byte
+	comp []byte
+}
+
+// Open 打开归档文件；缺少索引时扫描帧记录重建索引，截断的最后一帧会被忽略
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{f: f}
+	if err := r.init(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// init 读取文件头和索引
+func (r *Reader) init() error {
+	fi, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+	size := fi.Size()
+
+	var head [fileHeaderSize]byte
+	if _, err := r.f.ReadAt(head[:], 0); err != nil {
+		return fmt.Errorf("archive: failed to read file header: %w", err)
+	}
+	if string(head[:4]) != fileMagic {
+		return fmt.Errorf("archive: not a depth archive")
+	}
+	if v := binary.LittleEndian.Uint16(head[4:]); v != Version {
+		return fmt.Errorf("archive: unsupported version %d", v)
+	}
+	metaLen := int64(binary.LittleEndian.Uint32(head[8:]))
+	if fileHeaderSize+metaLen > size {
+		return fmt.Errorf("archive: truncated header")
+	}
+	meta := make([]byte, metaLen)
+	if _, err := r.f.ReadAt(meta, fileHeaderSize); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(meta, &r.hdr); err != nil {
+		return fmt.Errorf("archive: invalid header: %w", err)
+	}
+	if err := r.hdr.validate(); err != nil {
+		return err
+	}
+
+	dataStart := fileHeaderSize + metaLen
+	if r.readIndex(dataStart, size) {
+		return nil
+	}
+	r.recovered = true
+	return r.scan(dataStart, size)
+}
+
+// readIndex 读取文件尾的索引，文件尾缺失或损坏时返回 false
+func (r *Reader) readIndex(dataStart, size int64) bool {
+	if size < dataStart+trailerSize {
+		return false
+	}
+	var tail [trailerSize]byte
+	if _, err := r.f.ReadAt(tail[:], size-trailerSize); err != nil || string(tail[8:]) != trailerMagic {
+		return false
+	}
+	indexOffset := int64(binary.LittleEndian.Uint64(tail[:8]))
+	if indexOffset < dataStart || indexOffset+8 > size-trailerSize {
+		return false
+	}
+
+	var ih [8]byte
+	if _, err := r.f.ReadAt(ih[:], indexOffset); err != nil || string(ih[:4]) != indexMagic {
+		return false
+	}
+	count := int64(binary.LittleEndian.Uint32(ih[4:]))
+	if indexOffset+8+count*indexEntrySize != size-trailerSize {
+		return false
+	}
+	buf := make([]byte, count*indexEntrySize)
+	if _, err := r.f.ReadAt(buf, indexOffset+8); err != nil {
+		return false
+	}
+	r.index = make([]indexEntry, count)
+	for i := range r.index {
+		b := buf[i*indexEntrySize:]
+		r.index[i] = indexEntry{
+			offset:      int64(binary.LittleEndian.Uint64(b)),
+			frameNumber: binary.LittleEndian.Uint64(b[8:]),
+			timestamp:   math.Float64frombits(binary.LittleEndian.Uint64(b[16:])),
+		}
+	}
+	return true
+}
+
+// scan 顺序扫描帧记录重建索引，遇到不完整的记录时停止
+func (r *Reader) scan(offset, size int64) error {
+	var h [frameHeaderSize]byte
+	for offset+frameHeaderSize <= size {
+		if _, err := r.f.ReadAt(h[:], offset); err != nil {
+			return err
+		}
+		if string(h[:4]) != frameMagic {
+			break
+		}
+		n := int64(binary.LittleEndian.Uint32(h[24:]))
+		if offset+frameHeaderSize+n > size {
+			break
+		}
+		r.index = append(r.index, indexEntry{
+			offset:      offset,
+			frameNumber: binary.LittleEndian.Uint64(h[4:]),
+			timestamp:   math.Float64frombits(binary.LittleEndian.Uint64(h[12:])),
+		})
+		offset += frameHeaderSize + n
+	}
+	return nil
+}
+
+// Header 返回归档元数据
+func (r *Reader) Header() Header {
+	return r.hdr
+}
+
+// Len 返回帧数
+func (r *Reader) Len() int {
+	return len(r.index)
+}
+
+// Recovered 返回索引是否由扫描重建（写入端未正常关闭）
+func (r *Reader) Recovered() bool {
+	return r.recovered
+}
+
+// Frame 读取第 i 帧，返回的 Data 为新分配的切片
+func (r *Reader) Frame(i int) (Frame, error) {
+	if i < 0 || i >= len(r.index) {
+		return Frame{}, fmt.Errorf("archive: frame index %d out of range [0, %d)", i, len(r.index))
+	}
+	e := r.index[i]
+
+	var h [frameHeaderSize]byte
+	if _, err := r.f.ReadAt(h[:], e.offset); err != nil {
+		return Frame{}, err
+	}
+	if string(h[:4]) != frameMagic {
+		return Frame{}, fmt.Errorf("archive: corrupt frame record %d", i)
+	}
+	crc := binary.LittleEndian.Uint32(h[20:])
+	n := int(binary.LittleEndian.Uint32(h[24:]))
+	if cap(r.comp) < n {
+		r.comp = make([]byte, n)
+	}
+	comp := r.comp[:n]
+	if _, err := r.f.ReadAt(comp, e.offset+frameHeaderSize); err != nil {
+		return Frame{}, err
+	}
+
+	pixels := r.hdr.Width * r.hdr.Height
+	raw := comp
+	if r.hdr.Compression == CompressionDeflate {
+		if cap(r.raw) < pixels*2 {
+			r.raw = make([]byte, pixels*2)
+		}
+		raw = r.raw[:pixels*2]
+		zr := flate.NewReader(bytes.NewReader(comp))
+		_, err := io.ReadFull(zr, raw)
+		zr.Close()
+		if err != nil {
+			return Frame{}, fmt.Errorf("archive: frame %d: %w", i, err)
+		}
+	}
+	if len(raw) != pixels*2 {
+		return Frame{}, fmt.Errorf("archive: frame %d has %d bytes, want %d", i, len(raw), pixels*2)
+	}
+	if crc32.ChecksumIEEE(raw) != crc {
+		return Frame{}, fmt.Errorf("archive: frame %d checksum mismatch", i)
+	}
+
+	data := make([]uint16, pixels)
+	if r.hdr.Compression == CompressionDeflate {
+		z16.DeltaDecode(data, raw, r.hdr.Width)
+	} else {
+		for j := range data {
+			data[j] = binary.LittleEndian.Uint16(raw[j*2:])
+		}
+	}
+	return Frame{Index: i, FrameNumber: e.frameNumber, Timestamp: e.timestamp, Data: data}, nil
+}
+
+// Next 顺序读取下一帧，读完时返回 io.EOF
+func (r *Reader) Next() (Frame, error) {
+	if r.next >= len(r.index) {
+		return Frame{}, io.EOF
+	}
+	f, err := r.Frame(r.next)
+	if err != nil {
+		return Frame{}, err
+	}
+	r.next++
+	return f, nil
+}
+
+// Seek 设置 Next 读取的下一帧序号
+func (r *Reader) Seek(i int) error {
+	if i < 0 || i > len(r.index) {
+		return fmt.Errorf("archive: frame index %d out of range [0, %d]", i, len(r.index))
+	}
+	r.next = i
+	return nil
+}
+
+// FindTimestamp 返回第一个时间戳不小于 ts 的帧序号，没有时返回 Len()
+// 要求时间戳单调递增（同一设备同一时间戳域录制时成立）
+func (r *Reader) FindTimestamp(ts float64) int {
+	return sort.Search(len(r.index), func(i int) bool { return r.index[i].timestamp >= ts })
+}
+
+// FindFrameNumber 返回帧号为 n 的帧序号
+func (r *Reader) FindFrameNumber(n uint64) (int, bool) {
+	for i, e := range r.index {
+		if e.frameNumber == n {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Image 将帧数据包装为深度图（不拷贝）
+func (r *Reader) Image(f Frame) (*depth.Image, error) {
+	return depth.FromBuffer(f.Data, r.hdr.Width, r.hdr.Height)
+}
+
+// ROIFrame 将帧转换为 ROI 引擎的输入，附带归档中的深度比例和内参
+func (r *Reader) ROIFrame(f Frame) (roi.Frame, error) {
+	img, err := r.Image(f)
+	if err != nil {
+		return roi.Frame{}, err
+	}
+	return roi.Frame{
+		Depth:       img,
+		Scale:       r.hdr.DepthScale,
+		Timestamp:   f.Timestamp,
+		FrameNumber: f.FrameNumber,
+		Intrinsics:  r.hdr.Intrinsics,
+	}, nil
+}
+
+// Close 关闭文件
+func (r *Reader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
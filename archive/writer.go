@@ -0,0 +1,169 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/z16"
+)
+
+// Writer 顺序写入深度归档
+type Writer struct {
+	hdr Header
+
+	f      *os.File
+	w      *bufio.Writer
+	offset int64
+	index  []indexEntry
+
+	raw  []byte
+	buf  bytes.Buffer
+	zw   *flate.Writer
+	head [frameHeaderSize]byte
+}
+
+// Create 创建归档文件；hdr.Compression 为空时使用 deflate，Created 为零值时取当前时间
+func Create(path string, hdr Header) (*Writer, error) {
+	if hdr.Compression == "" {
+		hdr.Compression = CompressionDeflate
+	}
+	if hdr.Created.IsZero() {
+		hdr.Created = time.Now()
+	}
+	if err := hdr.validate(); err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &Writer{hdr: hdr, f: f, w: bufio.NewWriterSize(f, 1<<20)}
+	if hdr.Compression == CompressionDeflate {
+		// 深度数据差分后以小值为主，BestSpeed 已有较好的压缩率
+		a.zw, _ = flate.NewWriter(&a.buf, flate.BestSpeed)
+	}
+
+	var head [fileHeaderSize]byte
+	copy(head[:], fileMagic)
+	binary.LittleEndian.PutUint16(head[4:], Version)
+	binary.LittleEndian.PutUint32(head[8:], uint32(len(meta)))
+	a.w.Write(head[:])
+	a.w.Write(meta)
+	a.offset = int64(len(head) + len(meta))
+	return a, nil
+}
+
+// Header 返回归档元数据
+func (a *Writer) Header() Header {
+	return a.hdr
+}
+
+// Len 返回已写入的帧数
+func (a *Writer) Len() int {
+	return len(a.index)
+}
+
+// WriteFrame 写入一帧，data 长度必须为 Width*Height
+func (a *Writer) WriteFrame(data []uint16, frameNumber uint64, timestamp float64) error {
+	if a.f == nil {
+		return fmt.Errorf("archive: writer closed")
+	}
+	if len(data) != a.hdr.Width*a.hdr.Height {
+		return fmt.Errorf("archive: frame has %d pixels, want %d", len(data), a.hdr.Width*a.hdr.Height)
+	}
+
+	if cap(a.raw) < len(data)*2 {
+		a.raw = make([]byte, len(data)*2)
+	}
+	raw := a.raw[:len(data)*2]
+	if a.zw != nil {
+		z16.DeltaEncode(raw, data, a.hdr.Width)
+	} else {
+		for i, v := range data {
+			binary.LittleEndian.PutUint16(raw[i*2:], v)
+		}
+	}
+	crc := crc32.ChecksumIEEE(raw)
+
+	payload := raw
+	if a.zw != nil {
+		a.buf.Reset()
+		a.zw.Reset(&a.buf)
+		if _, err := a.zw.Write(raw); err != nil {
+			return err
+		}
+		if err := a.zw.Close(); err != nil {
+			return err
+		}
+		payload = a.buf.Bytes()
+	}
+
+	h := a.head[:]
+	copy(h, frameMagic)
+	binary.LittleEndian.PutUint64(h[4:], frameNumber)
+	binary.LittleEndian.PutUint64(h[12:], math.Float64bits(timestamp))
+	binary.LittleEndian.PutUint32(h[20:], crc)
+	binary.LittleEndian.PutUint32(h[24:], uint32(len(payload)))
+	// bufio.Writer 的错误会保留，写入失败后之后的帧和 Close 都会返回错误
+	if _, err := a.w.Write(h); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(payload); err != nil {
+		return err
+	}
+
+	a.index = append(a.index, indexEntry{offset: a.offset, frameNumber: frameNumber, timestamp: timestamp})
+	a.offset += int64(len(h) + len(payload))
+	return nil
+}
+
+// Flush 将缓冲数据写入文件，进程意外退出时已刷新的帧仍可读取
+func (a *Writer) Flush() error {
+	if a.f == nil {
+		return nil
+	}
+	return a.w.Flush()
+}
+
+// Close 写入索引和文件尾并关闭文件
+func (a *Writer) Close() error {
+	f := a.f
+	if f == nil {
+		return nil
+	}
+	a.f = nil
+
+	indexOffset := a.offset
+	var b [indexEntrySize]byte
+	a.w.WriteString(indexMagic)
+	binary.LittleEndian.PutUint32(b[:4], uint32(len(a.index)))
+	a.w.Write(b[:4])
+	for _, e := range a.index {
+		binary.LittleEndian.PutUint64(b[0:], uint64(e.offset))
+		binary.LittleEndian.PutUint64(b[8:], e.frameNumber)
+		binary.LittleEndian.PutUint64(b[16:], math.Float64bits(e.timestamp))
+		a.w.Write(b[:])
+	}
+	binary.LittleEndian.PutUint64(b[:8], uint64(indexOffset))
+	a.w.Write(b[:8])
+	a.w.WriteString(trailerMagic)
+
+	if err := a.w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,154 @@
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+// 本文件不含 //export，可以在前导中定义 C 函数和线程局部变量
+
+// jm_last_error 是调用线程最近一次失败的错误信息
+// 导出函数在调用方的线程上执行，因此每个宿主线程看到的是自己的错误
+static __thread char jm_last_error[512];
+
+static void jm_set_last_error(const char* msg) {
+	strncpy(jm_last_error, msg, sizeof(jm_last_error) - 1);
+	jm_last_error[sizeof(jm_last_error) - 1] = '\0';
+}
+
+// jm_copy_last_error 拷贝错误信息（必要时截断），返回完整长度
+static int jm_copy_last_error(char* buf, int len) {
+	int n = (int)strlen(jm_last_error);
+	if (buf != NULL && len > 0) {
+		int c = n < len - 1 ? n : len - 1;
+		memcpy(buf, jm_last_error, c);
+		buf[c] = '\0';
+	}
+	return n;
+}
+
+static void jm_clear_last_error(void) {
+	jm_last_error[0] = '\0';
+}
+
+typedef void (*jm_log_fn)(int level, const char* source, const char* message, void* user);
+
+static void jm_call_log(void* cb, int level, const char* source, const char* message, void* user) {
+	((jm_log_fn)cb)(level, source, message, user);
+}
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// errorNames 与 JM_ErrorCode 一一对应
+var errorNames = map[int]string{
+	errOK:             "JM_OK",
+	errInvalidHandle:  "JM_ERR_INVALID_HANDLE",
+	errRealSense:      "JM_ERR_REALSENSE",
+	errState:          "JM_ERR_STATE",
+	errInvalidArg:     "JM_ERR_INVALID_ARG",
+	errNoDevice:       "JM_ERR_NO_DEVICE",
+	errDeviceBusy:     "JM_ERR_DEVICE_BUSY",
+	errTimeout:        "JM_ERR_TIMEOUT",
+	errBufferTooSmall: "JM_ERR_BUFFER_TOO_SMALL",
+	errNoFrame:        "JM_ERR_NO_FRAME",
+}
+
+// errorNamesC 缓存错误码名称的 C 字符串，分配后不释放，指针在进程内保持有效
+var errorNamesC = struct {
+	sync.Mutex
+	m map[int]*C.char
+}{m: map[int]*C.char{}}
+
+// errorNameC 返回错误码名称的 C 字符串，未知错误码返回 "JM_ERR_UNKNOWN"
+func errorNameC(code int) *C.char {
+	name, ok := errorNames[code]
+	if !ok {
+		name = "JM_ERR_UNKNOWN"
+		code = 1 // 所有未知错误码共用一个缓存项
+	}
+	errorNamesC.Lock()
+	defer errorNamesC.Unlock()
+	if s, ok := errorNamesC.m[code]; ok {
+		return s
+	}
+	s := C.CString(name)
+	errorNamesC.m[code] = s
+	return s
+}
+
+// fail 记录调用线程的最后错误并输出错误日志，返回错误码
+// 必须在导出函数所在的线程中调用（即导出函数内直接调用，不能在新 goroutine 中）
+func fail(code int, fn string, format string, args ...any) int {
+	msg := fmt.Sprintf("%s: %s (%s)", fn, fmt.Sprintf(format, args...), errorNames[code])
+	cs := C.CString(msg)
+	C.jm_set_last_error(cs)
+	C.free(unsafe.Pointer(cs))
+	logf(logError, "%s", msg)
+	return code
+}
+
+// succeed 清除调用线程的最后错误，返回 errOK
+func succeed() int {
+	C.jm_clear_last_error()
+	return errOK
+}
+
+// copyLastError 将最后错误拷贝到 buf，返回完整长度
+func copyLastError(buf *C.char, bufLen int) int {
+	return int(C.jm_copy_last_error(buf, C.int(bufLen)))
+}
+
+// 日志源名称，宿主可据此区分中间件和 librealsense 的日志
+const (
+	logSourceMiddleware = "middleware"
+	logSourceRealSense  = "librealsense"
+)
+
+// logSink 保存宿主注册的日志回调
+var logSink struct {
+	sync.RWMutex
+	cb    unsafe.Pointer
+	user  unsafe.Pointer
+	level int
+}
+
+// setLogSink 设置日志回调，cb 为 nil 时关闭日志；同时转发 librealsense 的日志
+func setLogSink(cb, user unsafe.Pointer, level int) error {
+	logSink.Lock()
+	logSink.cb, logSink.user, logSink.level = cb, user, level
+	logSink.Unlock()
+
+	if cb == nil {
+		return rs.SetLogHandler(rs.LogSeverityNone, nil)
+	}
+	return rs.SetLogHandler(rs.LogSeverity(level), func(sev rs.LogSeverity, msg string) {
+		emit(int(sev), logSourceRealSense, msg)
+	})
+}
+
+// emit 将一条日志交给宿主回调
+func emit(level int, source, msg string) {
+	logSink.RLock()
+	cb, user, min := logSink.cb, logSink.user, logSink.level
+	logSink.RUnlock()
+	if cb == nil || level < min {
+		return
+	}
+
+	csrc := C.CString(source)
+	cmsg := C.CString(msg)
+	C.jm_call_log(cb, C.int(level), csrc, cmsg, user)
+	C.free(unsafe.Pointer(csrc))
+	C.free(unsafe.Pointer(cmsg))
+}
+
+// logf 输出中间件日志
+func logf(level int, format string, args ...any) {
+	emit(level, logSourceMiddleware, fmt.Sprintf(format, args...))
+}
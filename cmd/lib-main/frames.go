@@ -0,0 +1,344 @@
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+// 帧信息，由 JM_GetFrameInfo 填充
+typedef struct {
+    int stream;                      // 流类型，与 rs2_stream 一致 (1 深度, 2 彩色, 3 红外)
+    int index;                       // 流索引，红外左右为 1 和 2
+    int width;
+    int height;
+    int stride;                      // 每行字节数
+    int bytes_per_pixel;
+    int format;                      // 像素格式，与 rs2_format 一致
+    char format_name[16];            // 格式名称，例如 "Z16"、"RGB8"
+    double timestamp;                // 时间戳（毫秒）
+    int timestamp_domain;            // 时间戳域，与 rs2_timestamp_domain 一致
+    unsigned long long frame_number;
+    float depth_scale;               // 深度单位（米/单位），非深度帧为 0
+    int data_size;                   // 数据字节数 (stride*height)，即 JM_CopyFrame 所需的缓冲区大小
+} JM_FrameInfo;
+
+// 帧回调，每帧调用一次，在库内部的推送线程中执行
+// lease 为该帧的借出编号，data 指向 librealsense 的帧数据（只读）
+// 返回 0 时库在回调返回后归还该帧；返回非 0 表示宿主保留该帧，用完后调用 JM_ReleaseFrame(lease)
+typedef int (*JM_FrameCallback)(int handle, int lease, const JM_FrameInfo* info, const void* data, void* user);
+*/
+import "C"
+import (
+	"time"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// 按帧集取帧：JM_FetchFrames/JM_FetchAlignedFrames 取得一组帧并保存在句柄中，
+// 之后用 JM_GetFrameInfo 查询各流的尺寸和元数据，用 JM_CopyFrame 按缓冲区大小拷贝
+// 帧集在下一次取帧、JM_StopStream 或 JM_Close 时释放
+
+// findFrame 在当前帧集中查找指定流，index <= 0 表示该类型的第一帧，返回的帧需要 Close
+func (c *camera) findFrame(fn string, stream *C.char, index int) (*rs.Frame, int) {
+	if stream == nil {
+		return nil, fail(errInvalidArg, fn, "stream is NULL")
+	}
+	name := C.GoString(stream)
+	stype, err := rs.ParseStreamType(name)
+	if err != nil {
+		return nil, fail(errInvalidArg, fn, "%v", err)
+	}
+	if c.current == nil {
+		return nil, fail(errState, fn, "no frames fetched, call JM_FetchFrames first")
+	}
+	if index <= 0 {
+		index = -1
+	}
+	frame, err := c.current.GetFrameByIndex(stype, index)
+	if err != nil {
+		return nil, fail(errNoFrame, fn, "%s[%d] not in frame set: %v", name, index, err)
+	}
+	return frame, errOK
+}
+
+// 等待一组帧（应用 JM_SetFilters 设置的处理链）并保存为当前帧集
+// 返回值: JM_OK 成功, JM_ERR_TIMEOUT 超时, 其他负值为失败
+//
+//export JM_FetchFrames
+func JM_FetchFrames(handle int, timeoutMs int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_FetchFrames", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if !c.streaming {
+		return fail(errState, "JM_FetchFrames", "handle %d is not streaming", handle)
+	}
+	frames, code := c.waitForFrames("JM_FetchFrames", timeoutMs)
+	if code != errOK {
+		return code
+	}
+	c.setCurrent(frames)
+	return succeed()
+}
+
+// 与 JM_FetchFrames 相同，但在处理链之后将其他流对齐到 alignTo ("color"、"depth"、"infrared")
+// 例如 alignTo 为 "color" 时深度帧与彩色帧逐像素对应，分辨率与彩色帧相同
+//
+//export JM_FetchAlignedFrames
+func JM_FetchAlignedFrames(handle int, alignTo *C.char, timeoutMs int) int {
+	if alignTo == nil {
+		return fail(errInvalidArg, "JM_FetchAlignedFrames", "alignTo is NULL")
+	}
+	target, err := rs.ParseStreamType(C.GoString(alignTo))
+	if err != nil {
+		return fail(errInvalidArg, "JM_FetchAlignedFrames", "%v", err)
+	}
+
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_FetchAlignedFrames", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if !c.streaming {
+		return fail(errState, "JM_FetchAlignedFrames", "handle %d is not streaming", handle)
+	}
+	if c.align == nil || c.align.Target() != target {
+		if c.align != nil {
+			c.align.Close()
+			c.align = nil
+		}
+		if c.align, err = rs.NewAlign(target); err != nil {
+			return fail(errRealSense, "JM_FetchAlignedFrames", "create align: %v", err)
+		}
+	}
+
+	frames, code := c.waitForFrames("JM_FetchAlignedFrames", timeoutMs)
+	if code != errOK {
+		return code
+	}
+	aligned, err := c.align.Process(frames)
+	frames.Close()
+	if err != nil {
+		return fail(errRealSense, "JM_FetchAlignedFrames", "align to %s: %v", C.GoString(alignTo), err)
+	}
+	c.setCurrent(aligned)
+	return succeed()
+}
+
+// 查询当前帧集中指定流的帧信息，stream 为 "depth"、"color" 或 "infrared"，index <= 0 表示任意
+// 返回值: JM_OK 成功, JM_ERR_NO_FRAME 帧集中没有该流, JM_ERR_STATE 尚未取帧
+//
+//export JM_GetFrameInfo
+func JM_GetFrameInfo(handle int, stream *C.char, index int, info *C.JM_FrameInfo) int {
+	if info == nil {
+		return fail(errInvalidArg, "JM_GetFrameInfo", "info is NULL")
+	}
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_GetFrameInfo", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	frame, code := c.findFrame("JM_GetFrameInfo", stream, index)
+	if code != errOK {
+		return code
+	}
+	defer frame.Close()
+
+	fillFrameInfo(frame, info)
+	return succeed()
+}
+
+// fillFrameInfo 填充帧信息，单项读取失败时保持为 0
+func fillFrameInfo(frame *rs.Frame, info *C.JM_FrameInfo) {
+	C.memset(unsafe.Pointer(info), 0, C.sizeof_JM_FrameInfo)
+	stype, _ := frame.GetStreamType()
+	sindex, _ := frame.GetStreamIndex()
+	format, _ := frame.GetFormat()
+	info.stream = C.int(stype)
+	info.index = C.int(sindex)
+	info.width = C.int(frame.GetWidth())
+	info.height = C.int(frame.GetHeight())
+	info.stride = C.int(frame.GetStride())
+	info.bytes_per_pixel = C.int(frame.GetBitsPerPixel() / 8)
+	info.format = C.int(format)
+	info.data_size = info.stride * info.height
+
+	// 截断拷贝，保证以 '\0' 结尾
+	cName := C.CString(format.String())
+	defer C.free(unsafe.Pointer(cName))
+	C.strncpy(&info.format_name[0], cName, C.size_t(len(info.format_name)-1))
+
+	if ts, err := frame.GetTimestamp(); err == nil {
+		info.timestamp = C.double(ts)
+	}
+	if domain, err := frame.GetTimestampDomain(); err == nil {
+		info.timestamp_domain = C.int(domain)
+	}
+	if n, err := frame.GetFrameNumber(); err == nil {
+		info.frame_number = C.ulonglong(n)
+	}
+	if format == rs.FormatZ16 {
+		if units, err := frame.GetDepthUnits(); err == nil {
+			info.depth_scale = C.float(units)
+		}
+	}
+}
+
+// 将当前帧集中指定流的数据（stride*height 字节，行间含填充）拷贝到 buf
+// 返回值: 成功时为拷贝的字节数；buf 为 NULL 时只返回所需大小；
+// 缓冲区过小时返回 JM_ERR_BUFFER_TOO_SMALL，不拷贝任何数据
+//
+//export JM_CopyFrame
+func JM_CopyFrame(handle int, stream *C.char, index int, buf unsafe.Pointer, bufLen int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_CopyFrame", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	frame, code := c.findFrame("JM_CopyFrame", stream, index)
+	if code != errOK {
+		return code
+	}
+	defer frame.Close()
+
+	data := frame.GetRawData()
+	if buf == nil {
+		succeed()
+		return len(data)
+	}
+	if len(data) > bufLen {
+		return fail(errBufferTooSmall, "JM_CopyFrame", "need %d bytes, got %d", len(data), bufLen)
+	}
+	if len(data) > 0 {
+		C.memcpy(buf, unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+	succeed()
+	return len(data)
+}
+
+// 零拷贝借帧：JM_AcquireFrame 返回指向 librealsense 帧缓冲区的只读指针并持有该帧的一个引用，
+// 宿主可直接上传到渲染器，用完后必须调用 JM_ReleaseFrame 归还
+// librealsense 的帧池容量有限，长时间不归还会导致丢帧或取流停顿
+
+// pushPollMs 是推送线程每次等待帧的超时，决定 JM_StopStream 的最长等待时间
+const pushPollMs = 100
+
+// 从当前帧集中借出指定流的帧，info 可为 NULL，*data 为帧数据指针（stride*height 字节，只读）
+// 返回值: >0 借出编号；失败时为负的错误码
+// 帧在 JM_ReleaseFrame 或 JM_Close 之前保持有效，不受下一次取帧影响
+//
+//export JM_AcquireFrame
+func JM_AcquireFrame(handle int, stream *C.char, index int, info *C.JM_FrameInfo, data *unsafe.Pointer) int {
+	if data == nil {
+		return fail(errInvalidArg, "JM_AcquireFrame", "data is NULL")
+	}
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_AcquireFrame", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	frame, code := c.findFrame("JM_AcquireFrame", stream, index)
+	if code != errOK {
+		return code
+	}
+	raw := frame.GetRawData()
+	if len(raw) == 0 {
+		frame.Close()
+		return fail(errRealSense, "JM_AcquireFrame", "frame of device %s has no data", c.serial)
+	}
+	if info != nil {
+		fillFrameInfo(frame, info)
+	}
+	*data = unsafe.Pointer(&raw[0])
+	id := acquireLease(handle, frame)
+	succeed()
+	return id
+}
+
+// 归还 JM_AcquireFrame 或帧回调借出的帧，之后其数据指针失效
+//
+//export JM_ReleaseFrame
+func JM_ReleaseFrame(lease int) int {
+	if !releaseLease(lease) {
+		return fail(errInvalidHandle, "JM_ReleaseFrame", "invalid frame lease %d", lease)
+	}
+	return succeed()
+}
+
+// 设置帧回调，cb 为 NULL 时取消；只能在未取流时调用
+// 设置后 JM_Start/JM_StartStream 启动推送线程，帧集（经过处理链）中的每一帧都会回调一次，
+// 此时 JM_WaitForFrames/JM_FetchFrames 返回 JM_ERR_STATE
+// 回调中不能调用同一句柄的 JM_StopStream/JM_Close
+//
+//export JM_SetFrameCallback
+func JM_SetFrameCallback(handle int, cb C.JM_FrameCallback, user unsafe.Pointer) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_SetFrameCallback", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.streaming {
+		return fail(errState, "JM_SetFrameCallback", "handle %d is streaming, stop it first", handle)
+	}
+	c.frameCB, c.frameUser = unsafe.Pointer(cb), user
+	return succeed()
+}
+
+// pushLoop 是推送线程：取帧、应用处理链并逐帧回调，直到 stop 关闭
+// 不持有 c.mu，只读取取流期间不变的字段
+func (c *camera) pushLoop(handle int, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		frames, ok, err := c.pipeline.TryWaitForFrames(pushPollMs)
+		if err != nil {
+			// 设备断开等错误会立即返回，稍作等待避免空转
+			logf(logWarn, "handle %d (%s): %v", handle, c.serial, err)
+			time.Sleep(pushPollMs * time.Millisecond)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if frames, err = c.applyFilters(frames); err != nil {
+			logf(logWarn, "handle %d (%s): filters: %v", handle, c.serial, err)
+			continue
+		}
+		c.push(handle, frames)
+		frames.Close()
+	}
+}
+
+// push 将帧集中的每一帧借给宿主回调
+func (c *camera) push(handle int, frames *rs.FrameSet) {
+	list, err := frames.Frames()
+	if err != nil {
+		logf(logWarn, "handle %d (%s): %v", handle, c.serial, err)
+		return
+	}
+	var info C.JM_FrameInfo
+	for _, frame := range list {
+		raw := frame.GetRawData()
+		if len(raw) == 0 {
+			frame.Close()
+			continue
+		}
+		fillFrameInfo(frame, &info)
+		id := acquireLease(handle, frame)
+		if !callFrame(c.frameCB, handle, id, unsafe.Pointer(&info), unsafe.Pointer(&raw[0]), c.frameUser) {
+			releaseLease(id)
+		}
+	}
+}
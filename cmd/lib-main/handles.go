@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// camera 是一个句柄对应的相机实例
+// 每个实例有独立的锁，不同相机的调用互不阻塞
+type camera struct {
+	mu        sync.Mutex
+	serial    string
+	ctx       *rs.Context
+	pipeline  *rs.Pipeline
+	config    *rs.Config
+	align     *rs.Align    // JM_FetchAlignedFrames 使用，按对齐目标缓存
+	current   *rs.FrameSet // JM_FetchFrames 取得的当前帧集，供 JM_GetFrameInfo/JM_CopyFrame 读取
+	streaming bool
+	closed    bool
+
+	rois      *roi.Engine // JM_SetROIs 设置的触发引擎，nil 表示未设置
+	roiResult []byte      // 最近一次 JM_EvaluateROIs 的 JSON 结果
+
+	// 处理链单独加锁，推送线程不持有 mu 也能读取
+	filterMu sync.Mutex
+	filters  *rs.Chain // 取帧时依次应用，nil 表示不处理
+
+	// 帧回调，仅在未取流时修改；设置后取流期间由推送线程取帧
+	frameCB   unsafe.Pointer
+	frameUser unsafe.Pointer
+	pushStop  chan struct{}
+	pushDone  chan struct{}
+}
+
+// 句柄表只保护句柄到实例的映射，不在持锁期间调用 librealsense
+var (
+	tableMu    sync.RWMutex
+	cameras    = map[int]*camera{}
+	nextHandle = 1 // 句柄单调递增、不复用，关闭后的旧句柄不会误指向新相机
+)
+
+// 所有相机共用一个上下文，按引用计数在最后一个句柄关闭时释放
+var (
+	ctxMu     sync.Mutex
+	sharedCtx *rs.Context
+	ctxRefs   int
+)
+
+// acquireContext 获取共享上下文，首次调用时创建
+func acquireContext() (*rs.Context, error) {
+	ctxMu.Lock()
+	defer ctxMu.Unlock()
+	if sharedCtx == nil {
+		ctx, err := rs.NewContext()
+		if err != nil {
+			return nil, err
+		}
+		sharedCtx = ctx
+	}
+	ctxRefs++
+	return sharedCtx, nil
+}
+
+// releaseContext 释放一次引用，引用归零时关闭上下文
+func releaseContext() {
+	ctxMu.Lock()
+	defer ctxMu.Unlock()
+	if ctxRefs == 0 {
+		return
+	}
+	ctxRefs--
+	if ctxRefs == 0 && sharedCtx != nil {
+		sharedCtx.Close()
+		sharedCtx = nil
+	}
+}
+
+// deviceSerials 返回当前连接的设备序列号
+func deviceSerials(ctx *rs.Context) ([]string, error) {
+	devices, err := ctx.QueryDevices()
+	if err != nil {
+		return nil, err
+	}
+	serials := make([]string, 0, len(devices))
+	for _, d := range devices {
+		s, err := d.GetInfo(rs.CameraInfoSerialNumber)
+		d.Close()
+		if err == nil {
+			serials = append(serials, s)
+		}
+	}
+	return serials, nil
+}
+
+// register 将实例放入句柄表并返回句柄；同一序列号只能打开一次
+func register(c *camera) (int, error) {
+	tableMu.Lock()
+	defer tableMu.Unlock()
+	for _, other := range cameras {
+		if other.serial == c.serial {
+			return 0, fmt.Errorf("device %s is already open", c.serial)
+		}
+	}
+	h := nextHandle
+	nextHandle++
+	cameras[h] = c
+	return h, nil
+}
+
+// lookup 返回句柄对应的实例，句柄无效时返回 nil
+func lookup(h int) *camera {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+	return cameras[h]
+}
+
+// unregister 从句柄表移除并返回实例
+func unregister(h int) *camera {
+	tableMu.Lock()
+	defer tableMu.Unlock()
+	c := cameras[h]
+	delete(cameras, h)
+	return c
+}
+
+// lock 锁定实例，实例已关闭时返回 false（此时不持有锁）
+func (c *camera) lock() bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// device 返回实例对应的设备，调用方需 Close
+// 取流时从管道获取，否则按序列号在已连接设备中查找
+func (c *camera) device() (*rs.Device, error) {
+	if c.streaming {
+		return c.pipeline.GetDevice()
+	}
+	devices, err := c.ctx.QueryDevices()
+	if err != nil {
+		return nil, err
+	}
+	var found *rs.Device
+	for _, d := range devices {
+		if s, err := d.GetInfo(rs.CameraInfoSerialNumber); err == nil && s == c.serial && found == nil {
+			found = d
+			continue
+		}
+		d.Close()
+	}
+	if found == nil {
+		return nil, fmt.Errorf("device %s disconnected", c.serial)
+	}
+	return found, nil
+}
+
+// setFilters 替换处理链，释放旧的处理链
+func (c *camera) setFilters(chain *rs.Chain) {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	if c.filters != nil {
+		c.filters.Close()
+	}
+	c.filters = chain
+}
+
+// applyFilters 对帧集应用处理链并释放输入帧集，返回的帧集需要 Close
+func (c *camera) applyFilters(frames *rs.FrameSet) (*rs.FrameSet, error) {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+	if c.filters == nil {
+		return frames, nil
+	}
+	processed, err := c.filters.ProcessFrameSet(frames)
+	frames.Close()
+	return processed, err
+}
+
+// setROIs 替换 ROI 触发引擎并清除上一次的结果，调用方需持有 c.mu
+func (c *camera) setROIs(e *roi.Engine) {
+	if c.rois != nil {
+		c.rois.Close()
+	}
+	c.rois = e
+	c.roiResult = nil
+}
+
+// setCurrent 替换当前帧集，释放旧的帧集
+func (c *camera) setCurrent(frames *rs.FrameSet) {
+	if c.current != nil {
+		c.current.Close()
+	}
+	c.current = frames
+}
+
+// stop 停止推送线程和取流，调用方需持有 c.mu
+func (c *camera) stop() {
+	if c.pushStop != nil {
+		close(c.pushStop)
+		<-c.pushDone
+		c.pushStop, c.pushDone = nil, nil
+	}
+	c.pipeline.Stop()
+	c.streaming = false
+	c.setCurrent(nil)
+}
+
+// close 停止取流并释放资源，调用方需持有 c.mu
+func (c *camera) close() {
+	if c.streaming {
+		c.stop()
+	}
+	c.setCurrent(nil)
+	c.setFilters(nil)
+	c.setROIs(nil)
+	if c.align != nil {
+		c.align.Close()
+		c.align = nil
+	}
+	c.pipeline.Close()
+	c.config.Close()
+	c.closed = true
+	releaseContext()
+}
+
+// start 按当前配置开始取流，调用方需持有 c.mu
+func (c *camera) start(fn string, handle int) int {
+	if err := c.pipeline.Start(c.config); err != nil {
+		return fail(errRealSense, fn, "start device %s: %v", c.serial, err)
+	}
+	c.streaming = true
+	if c.frameCB != nil {
+		c.pushStop, c.pushDone = make(chan struct{}), make(chan struct{})
+		go c.pushLoop(handle, c.pushStop, c.pushDone)
+	}
+	logf(logInfo, "handle %d (%s) streaming", handle, c.serial)
+	return succeed()
+}
+
+// waitForFrames 等待一组帧并应用处理链，返回的帧集需要 Close
+func (c *camera) waitForFrames(fn string, timeoutMs int) (*rs.FrameSet, int) {
+	if c.pushStop != nil {
+		return nil, fail(errState, fn, "frames of device %s are delivered by the frame callback", c.serial)
+	}
+	frames, ok, err := c.pipeline.TryWaitForFrames(uint(timeoutMs))
+	if err != nil {
+		return nil, fail(errRealSense, fn, "device %s: %v", c.serial, err)
+	}
+	if !ok {
+		return nil, fail(errTimeout, fn, "no frames from device %s within %d ms", c.serial, timeoutMs)
+	}
+	processed, err := c.applyFilters(frames)
+	if err != nil {
+		return nil, fail(errRealSense, fn, "filters on device %s: %v", c.serial, err)
+	}
+	return processed, errOK
+}
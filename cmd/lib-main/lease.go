@@ -0,0 +1,88 @@
+package main
+
+/*
+// 本文件不含 //export，可以在前导中定义 C 函数
+// info 以 void* 传递，JM_FrameInfo 只在 frames.go 的前导中定义
+
+typedef int (*jm_frame_fn)(int handle, int lease, const void* info, const void* data, void* user);
+
+static int jm_call_frame(void* cb, int handle, int lease, const void* info, const void* data, void* user) {
+	return ((jm_frame_fn)cb)(handle, lease, info, data, user);
+}
+*/
+import "C"
+import (
+	"math"
+	"sync"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// lease 是借给宿主的一帧，持有该帧的一个引用，JM_ReleaseFrame 时释放
+type lease struct {
+	handle int
+	frame  *rs.Frame
+}
+
+// leases 保存所有未归还的帧
+// 编号经 JM_FrameCallback 以 C int 传给宿主，因此限制在 [1, MaxInt32]，
+// 递增到上限后从 1 重新开始并跳过仍未归还的编号
+var leases = struct {
+	sync.Mutex
+	m    map[int]*lease
+	next int
+}{m: map[int]*lease{}, next: 1}
+
+// acquireLease 登记一帧并返回编号，frame 的所有权转移给登记表
+func acquireLease(handle int, frame *rs.Frame) int {
+	leases.Lock()
+	defer leases.Unlock()
+	for {
+		id := leases.next
+		if leases.next == math.MaxInt32 {
+			leases.next = 1
+		} else {
+			leases.next++
+		}
+		if _, used := leases.m[id]; !used {
+			leases.m[id] = &lease{handle: handle, frame: frame}
+			return id
+		}
+	}
+}
+
+// releaseLease 归还一帧，编号无效时返回 false
+func releaseLease(id int) bool {
+	leases.Lock()
+	l, ok := leases.m[id]
+	delete(leases.m, id)
+	leases.Unlock()
+	if !ok {
+		return false
+	}
+	l.frame.Close()
+	return true
+}
+
+// releaseLeases 归还句柄上所有未归还的帧，返回数量
+func releaseLeases(handle int) int {
+	leases.Lock()
+	var frames []*rs.Frame
+	for id, l := range leases.m {
+		if l.handle == handle {
+			frames = append(frames, l.frame)
+			delete(leases.m, id)
+		}
+	}
+	leases.Unlock()
+	for _, f := range frames {
+		f.Close()
+	}
+	return len(frames)
+}
+
+// callFrame 调用宿主注册的帧回调，返回非 0 表示宿主保留该帧
+func callFrame(cb unsafe.Pointer, handle, id int, info, data, user unsafe.Pointer) bool {
+	return C.jm_call_frame(cb, C.int(handle), C.int(id), info, data, user) != 0
+}
@@ -19,139 +19,337 @@ typedef struct {
     char usb_type[32];
 } TelemetryInfo;
 
+// 错误码，所有 JM_* 函数失败时返回负值，详细信息通过 JM_GetLastError 获取
+typedef enum {
+    JM_OK                   =  0,
+    JM_ERR_INVALID_HANDLE   = -1, // 句柄无效或已关闭
+    JM_ERR_REALSENSE        = -2, // librealsense 调用失败
+    JM_ERR_STATE            = -3, // 状态错误（重复启动、未启动即取帧）
+    JM_ERR_INVALID_ARG      = -4, // 参数错误
+    JM_ERR_NO_DEVICE        = -5, // 未连接设备或序列号不存在
+    JM_ERR_DEVICE_BUSY      = -6, // 设备已被其他句柄打开
+    JM_ERR_TIMEOUT          = -7, // 等待帧超时
+    JM_ERR_BUFFER_TOO_SMALL = -8, // 调用方提供的缓冲区过小
+    JM_ERR_NO_FRAME         = -9  // 帧集中没有请求的流
+} JM_ErrorCode;
+
+
+// 日志级别，取值与 librealsense 的 rs2_log_severity 一致
+typedef enum {
+    JM_LOG_DEBUG = 0,
+    JM_LOG_INFO  = 1,
+    JM_LOG_WARN  = 2,
+    JM_LOG_ERROR = 3,
+    JM_LOG_FATAL = 4,
+    JM_LOG_NONE  = 5
+} JM_LogLevel;
+
+// 日志回调，source 为 "middleware" 或 "librealsense"
+// 可能在 librealsense 的内部线程中调用，字符串仅在回调期间有效
+typedef void (*JM_LogCallback)(int level, const char* source, const char* message, void* user);
+
 */
 import "C"
 import (
-	"sync"
 	"unsafe"
 
 	"github.com/tianfei212/jetson-rs-middleware/rs"
 )
 
 // Version defines the current version of the shared library
-const Version = "v1.0.1-20260221"
-
-// 全局变量保持引用，防止 GC
-var (
-	ctx      *rs.Context
-	pipeline *rs.Pipeline
-	config   *rs.Config
-	mu       sync.Mutex
+const Version = "v2.1.0-20261019"
+
+// 所有接口以 JM_Open 返回的句柄区分相机，不同句柄可在不同线程中并发调用；
+// 同一句柄上的调用按顺序执行
+//
+// 返回值约定: JM_OK (JM_Open 返回正数句柄)，失败时返回 JM_ErrorCode 中的负值，
+// 并记录调用线程的最后错误信息
+
+// 错误码，与 JM_ErrorCode 一致
+const (
+	errOK             = int(C.JM_OK)
+	errInvalidHandle  = int(C.JM_ERR_INVALID_HANDLE)
+	errRealSense      = int(C.JM_ERR_REALSENSE)
+	errState          = int(C.JM_ERR_STATE)
+	errInvalidArg     = int(C.JM_ERR_INVALID_ARG)
+	errNoDevice       = int(C.JM_ERR_NO_DEVICE)
+	errDeviceBusy     = int(C.JM_ERR_DEVICE_BUSY)
+	errTimeout        = int(C.JM_ERR_TIMEOUT)
+	errBufferTooSmall = int(C.JM_ERR_BUFFER_TOO_SMALL)
+	errNoFrame        = int(C.JM_ERR_NO_FRAME)
 )
 
-//export JM_Init
-func JM_Init() int {
-	mu.Lock()
-	defer mu.Unlock()
+// 日志级别，与 JM_LogLevel 一致
+const (
+	logDebug = int(C.JM_LOG_DEBUG)
+	logInfo  = int(C.JM_LOG_INFO)
+	logWarn  = int(C.JM_LOG_WARN)
+	logError = int(C.JM_LOG_ERROR)
+	logNone  = int(C.JM_LOG_NONE)
+)
 
-	var err error
-	ctx, err = rs.NewContext()
+// 将调用线程最近一次失败的错误信息写入 buf（截断并以 '\0' 结尾）
+// 返回完整信息的长度，没有错误时返回 0；buf 为 NULL 时只返回长度
+//
+//export JM_GetLastError
+func JM_GetLastError(buf *C.char, bufLen int) int {
+	return copyLastError(buf, bufLen)
+}
+
+// 返回错误码的名称，例如 "JM_ERR_TIMEOUT"，字符串由库持有，无需释放
+//
+//export JM_GetErrorName
+func JM_GetErrorName(code int) *C.char {
+	return errorNameC(code)
+}
+
+// 设置日志回调，cb 为 NULL 时关闭日志；level 为最低输出级别 (JM_LogLevel)
+// librealsense 的日志同时转发到该回调，其最低级别以首次设置时为准
+//
+//export JM_SetLogCallback
+func JM_SetLogCallback(cb C.JM_LogCallback, user unsafe.Pointer, level int) int {
+	if level < logDebug || level > logNone {
+		return fail(errInvalidArg, "JM_SetLogCallback", "invalid log level %d", level)
+	}
+	if err := setLogSink(unsafe.Pointer(cb), user, level); err != nil {
+		return fail(errRealSense, "JM_SetLogCallback", "%v", err)
+	}
+	return succeed()
+}
+
+// 返回当前连接的设备数量，失败时返回负的错误码
+//
+//export JM_GetDeviceCount
+func JM_GetDeviceCount() int {
+	ctx, err := acquireContext()
 	if err != nil {
-		return -1
+		return fail(errRealSense, "JM_GetDeviceCount", "create context: %v", err)
 	}
+	defer releaseContext()
 
-	pipeline, err = rs.NewPipeline(ctx)
+	serials, err := deviceSerials(ctx)
 	if err != nil {
-		ctx.Close()
-		return -2
+		return fail(errRealSense, "JM_GetDeviceCount", "query devices: %v", err)
 	}
+	succeed()
+	return len(serials)
+}
 
-	config, err = rs.NewConfig()
+// 将第 index 台设备的序列号写入 buf（含结尾 '\0'）
+//
+//export JM_GetDeviceSerial
+func JM_GetDeviceSerial(index int, buf *C.char, bufLen int) int {
+	if buf == nil || bufLen <= 0 {
+		return fail(errInvalidArg, "JM_GetDeviceSerial", "buffer is NULL")
+	}
+	ctx, err := acquireContext()
+	if err != nil {
+		return fail(errRealSense, "JM_GetDeviceSerial", "create context: %v", err)
+	}
+	defer releaseContext()
+
+	serials, err := deviceSerials(ctx)
+	if err != nil {
+		return fail(errRealSense, "JM_GetDeviceSerial", "query devices: %v", err)
+	}
+	if index < 0 || index >= len(serials) {
+		return fail(errNoDevice, "JM_GetDeviceSerial", "device index %d out of range [0, %d)", index, len(serials))
+	}
+	s := serials[index]
+	if len(s)+1 > bufLen {
+		return fail(errBufferTooSmall, "JM_GetDeviceSerial", "need %d bytes, got %d", len(s)+1, bufLen)
+	}
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(buf)), bufLen)
+	copy(dst, s)
+	dst[len(s)] = 0
+	return succeed()
+}
+
+// 打开指定序列号的相机，serial 为 NULL 或空字符串时打开第一台
+// 返回值: >0 句柄；失败时为 JM_ERR_NO_DEVICE、JM_ERR_DEVICE_BUSY 或 JM_ERR_REALSENSE
+//
+//export JM_Open
+func JM_Open(serial *C.char) int {
+	want := ""
+	if serial != nil {
+		want = C.GoString(serial)
+	}
+
+	ctx, err := acquireContext()
+	if err != nil {
+		return fail(errRealSense, "JM_Open", "create context: %v", err)
+	}
+	serials, err := deviceSerials(ctx)
+	if err != nil {
+		releaseContext()
+		return fail(errRealSense, "JM_Open", "query devices: %v", err)
+	}
+	if len(serials) == 0 {
+		releaseContext()
+		return fail(errNoDevice, "JM_Open", "no device connected")
+	}
+	if want == "" {
+		want = serials[0]
+	} else {
+		found := false
+		for _, s := range serials {
+			if s == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			releaseContext()
+			return fail(errNoDevice, "JM_Open", "device %s not found, connected: %v", want, serials)
+		}
+	}
+
+	pipeline, err := rs.NewPipeline(ctx)
+	if err != nil {
+		releaseContext()
+		return fail(errRealSense, "JM_Open", "create pipeline: %v", err)
+	}
+	config, err := newDeviceConfig(want)
 	if err != nil {
 		pipeline.Close()
-		ctx.Close()
-		return -3
+		releaseContext()
+		return fail(errRealSense, "JM_Open", "create config: %v", err)
 	}
 
-	return 0 // Success
+	c := &camera{serial: want, ctx: ctx, pipeline: pipeline, config: config}
+	h, err := register(c)
+	if err != nil {
+		c.close()
+		return fail(errDeviceBusy, "JM_Open", "%v", err)
+	}
+	logf(logInfo, "opened device %s as handle %d", want, h)
+	succeed()
+	return h
 }
 
+// 以相同的分辨率和帧率启用深度 (Z16) 和彩色 (RGB8) 并开始取流
+// 需要不同分辨率、格式或更多流时，使用 JM_EnableStream/JM_ConfigureStreams 后调用 JM_Start
+//
 //export JM_StartStream
-func JM_StartStream(width int, height int, fps int) int {
-	mu.Lock()
-	defer mu.Unlock()
+func JM_StartStream(handle int, width int, height int, fps int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_StartStream", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
 
-	if pipeline == nil {
-		return -1
+	if c.streaming {
+		return fail(errState, "JM_StartStream", "handle %d is already streaming", handle)
 	}
 
 	// 启用深度和彩色流
-	config.EnableStream(rs.StreamDepth, width, height, fps, rs.FormatZ16)
-	config.EnableStream(rs.StreamColor, width, height, fps, rs.FormatRGB8)
+	if err := c.config.DisableAllStreams(); err != nil {
+		return fail(errRealSense, "JM_StartStream", "%v", err)
+	}
+	if err := c.config.EnableStream(rs.StreamDepth, width, height, fps, rs.FormatZ16); err != nil {
+		return fail(errInvalidArg, "JM_StartStream", "depth %dx%d@%d: %v", width, height, fps, err)
+	}
+	if err := c.config.EnableStream(rs.StreamColor, width, height, fps, rs.FormatRGB8); err != nil {
+		return fail(errInvalidArg, "JM_StartStream", "color %dx%d@%d: %v", width, height, fps, err)
+	}
+	return c.start("JM_StartStream", handle)
+}
 
-	err := pipeline.Start(config)
-	if err != nil {
-		return -2
+//export JM_StopStream
+func JM_StopStream(handle int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_StopStream", "invalid handle %d", handle)
 	}
+	defer c.mu.Unlock()
 
-	return 0
+	if !c.streaming {
+		return fail(errState, "JM_StopStream", "handle %d is not streaming", handle)
+	}
+	c.stop()
+	logf(logInfo, "handle %d (%s) stopped", handle, c.serial)
+	return succeed()
 }
 
-// 返回值: 0=成功, <0=失败
-// rgbBuffer: 指向 RGB8 数据的指针 (大小需为 width*height*3)
-// depthBuffer: 指向 Z16 数据的指针 (大小需为 width*height*2)
+// 返回值: JM_OK 成功, JM_ERR_TIMEOUT 超时, JM_ERR_NO_FRAME 缺少所请求的流, 其他负值为失败
+// rgbBuffer: 指向彩色数据的指针 (RGB8 时大小需为 width*height*3)，NULL 表示不需要
+// depthBuffer: 指向 Z16 数据的指针 (大小需为处理链输出的 width*height*2)，NULL 表示不需要
+// 本函数不检查缓冲区大小，建议使用 JM_FetchFrames + JM_CopyFrame
 //
 //export JM_WaitForFrames
-func JM_WaitForFrames(rgbBuffer unsafe.Pointer, depthBuffer unsafe.Pointer, timeoutMs int) int {
-	mu.Lock()
-	defer mu.Unlock()
+func JM_WaitForFrames(handle int, rgbBuffer unsafe.Pointer, depthBuffer unsafe.Pointer, timeoutMs int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_WaitForFrames", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
 
-	if pipeline == nil {
-		return -1
+	if !c.streaming {
+		return fail(errState, "JM_WaitForFrames", "handle %d is not streaming", handle)
 	}
 
-	frames, err := pipeline.WaitForFrames(uint(timeoutMs))
-	if err != nil {
-		return -2
+	frames, code := c.waitForFrames("JM_WaitForFrames", timeoutMs)
+	if code != errOK {
+		return code
 	}
 	defer frames.Close()
 
-	// 获取深度帧
-	depthFrame, err := frames.GetFrame(rs.StreamDepth)
-	if err == nil {
+	// 获取深度帧（经过处理链后分辨率可能改变，例如降采样）
+	if depthBuffer != nil {
+		depthFrame, err := frames.GetFrame(rs.StreamDepth)
+		if err != nil {
+			return fail(errNoFrame, "JM_WaitForFrames", "no depth frame from device %s", c.serial)
+		}
 		defer depthFrame.Close()
-		data := depthFrame.GetDepthData()
-		size := depthFrame.GetWidth() * depthFrame.GetHeight() * 2 // 2 bytes per pixel
 		// 拷贝数据到 C 缓冲区
-		if depthBuffer != nil {
-			C.memcpy(depthBuffer, unsafe.Pointer(&data[0]), C.size_t(size))
+		if data := depthFrame.GetRawData(); len(data) > 0 {
+			C.memcpy(depthBuffer, unsafe.Pointer(&data[0]), C.size_t(len(data)))
 		}
 	}
 
-	// 获取彩色帧
-	colorFrame, err := frames.GetFrame(rs.StreamColor)
-	if err == nil {
+	// 获取彩色帧（大小取决于配置的格式，RGB8 为 width*height*3）
+	if rgbBuffer != nil {
+		colorFrame, err := frames.GetFrame(rs.StreamColor)
+		if err != nil {
+			return fail(errNoFrame, "JM_WaitForFrames", "no color frame from device %s", c.serial)
+		}
 		defer colorFrame.Close()
-		data := colorFrame.GetRawData()
-		size := colorFrame.GetWidth() * colorFrame.GetHeight() * 3 // 3 bytes per pixel
-		if rgbBuffer != nil {
-			C.memcpy(rgbBuffer, unsafe.Pointer(&data[0]), C.size_t(size))
+		if data := colorFrame.GetRawData(); len(data) > 0 {
+			C.memcpy(rgbBuffer, unsafe.Pointer(&data[0]), C.size_t(len(data)))
 		}
 	}
 
-	return 0
+	return succeed()
 }
 
 //export JM_GetTelemetry
-func JM_GetTelemetry(info *C.TelemetryInfo) int {
-	mu.Lock()
-	defer mu.Unlock()
+func JM_GetTelemetry(handle int, info *C.TelemetryInfo) int {
+	if info == nil {
+		return fail(errInvalidArg, "JM_GetTelemetry", "info is NULL")
+	}
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_GetTelemetry", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
 
-	if pipeline == nil {
-		return -1
+	if !c.streaming {
+		return fail(errState, "JM_GetTelemetry", "handle %d is not streaming", handle)
 	}
 
-	dev, err := pipeline.GetDevice()
+	dev, err := c.pipeline.GetDevice()
 	if err != nil {
-		return -2
+		return fail(errRealSense, "JM_GetTelemetry", "get device %s: %v", c.serial, err)
 	}
 	defer dev.Close()
 
-	// 获取遥测数据
+	// 获取遥测数据，单项失败不影响其他项，只记录警告
 	telemetry, err := dev.GetTelemetry()
 	if err == nil {
 		info.asic_temp = C.float(telemetry.AsicTemperature)
 		info.projector_temp = C.float(telemetry.ProjectorTemperature)
+	} else {
+		logf(logWarn, "handle %d: telemetry unavailable: %v", handle, err)
 	}
 
 	// 获取同步模式
@@ -163,33 +361,33 @@ func JM_GetTelemetry(info *C.TelemetryInfo) int {
 	// 获取 USB 类型
 	usbType, err := dev.GetUSBTypeDescriptor()
 	if err == nil {
-		// 简单的字符串拷贝
+		// 截断拷贝，保证以 '\0' 结尾
 		cStr := C.CString(usbType)
 		defer C.free(unsafe.Pointer(cStr))
-		C.strcpy(&info.usb_type[0], cStr)
+		C.strncpy(&info.usb_type[0], cStr, C.size_t(len(info.usb_type)-1))
+		info.usb_type[len(info.usb_type)-1] = 0
 	}
 
-	return 0
+	return succeed()
 }
 
+// 停止取流并释放句柄，之后该句柄上的调用返回 JM_ERR_INVALID_HANDLE
+// 未归还的 JM_AcquireFrame 帧一并释放，其数据指针随之失效
+//
 //export JM_Close
-func JM_Close() {
-	mu.Lock()
-	defer mu.Unlock()
-
-	if pipeline != nil {
-		pipeline.Stop()
-		pipeline.Close()
-		pipeline = nil
+func JM_Close(handle int) int {
+	c := unregister(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_Close", "invalid handle %d", handle)
 	}
-	if config != nil {
-		config.Close()
-		config = nil
-	}
-	if ctx != nil {
-		ctx.Close()
-		ctx = nil
+	defer c.mu.Unlock()
+
+	c.close() // 先停止推送线程，之后不会再借出新的帧
+	if n := releaseLeases(handle); n > 0 {
+		logf(logWarn, "handle %d (%s): released %d frames not returned by JM_ReleaseFrame", handle, c.serial, n)
 	}
+	logf(logInfo, "closed handle %d (%s)", handle, c.serial)
+	return succeed()
 }
 
 func main() {
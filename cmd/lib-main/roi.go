@@ -0,0 +1,166 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// ROI 触发：JM_SetROIs 以 roi.Config 格式的 JSON 设置区域和体积，
+// JM_EvaluateROIs 对当前帧集中的深度帧判定一次，结果由 JM_GetROIResult 以 JSON 读取
+// 区域坐标为参考分辨率（通常是彩色流）下的像素坐标，深度帧应先用 JM_FetchAlignedFrames 对齐到彩色
+
+// roiResult 是 JM_GetROIResult 返回的 JSON
+type roiResult struct {
+	FrameNumber uint64               `json:"frame_number"`
+	Timestamp   float64              `json:"timestamp"`
+	Events      []roi.Event          `json:"events"` // 本帧产生的触发事件
+	Stats       map[string]roi.Stats `json:"stats"`  // 各区域和体积在本帧的统计
+	Active      map[string]bool      `json:"active"` // 各区域和体积是否处于已触发状态
+}
+
+// parseROIs 解析 roi.Config 格式的 JSON 并创建触发引擎
+func parseROIs(config string) (*roi.Engine, error) {
+	cfg, err := roi.ParseConfig(strings.NewReader(config))
+	if err != nil {
+		return nil, err
+	}
+	return roi.NewEngineFromConfig(cfg)
+}
+
+// 以 JSON 设置 ROI 区域和体积，格式与 roi.Config 一致，例如
+// {"reference_width": 640, "reference_height": 480, "regions": [{"name": "door", "rect": {"x": 270, "y": 190, "w": 100, "h": 100}, "min_distance": 0.1, "max_distance": 1.5, "min_points": 500}]}
+// config 为 NULL 或空字符串时清除；替换后去抖、迟滞和冷却状态从头开始
+//
+//export JM_SetROIs
+func JM_SetROIs(handle int, config *C.char) int {
+	var engine *roi.Engine
+	if config != nil && C.GoString(config) != "" {
+		var err error
+		if engine, err = parseROIs(C.GoString(config)); err != nil {
+			return fail(errInvalidArg, "JM_SetROIs", "%v", err)
+		}
+	}
+
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_SetROIs", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	c.setROIs(engine)
+	return succeed()
+}
+
+// 对当前帧集中的深度帧判定一次 ROI，结果保存在句柄中供 JM_GetROIResult 读取
+// 返回值: 成功时为本帧产生的触发事件数 (>= 0)；未设置 ROI 或尚未取帧时返回 JM_ERR_STATE，
+// 帧集中没有深度帧时返回 JM_ERR_NO_FRAME；设置了 3D 体积时深度帧需要带内参
+//
+//export JM_EvaluateROIs
+func JM_EvaluateROIs(handle int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_EvaluateROIs", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.rois == nil {
+		return fail(errState, "JM_EvaluateROIs", "no ROIs set, call JM_SetROIs first")
+	}
+	if c.current == nil {
+		return fail(errState, "JM_EvaluateROIs", "no frames fetched, call JM_FetchFrames first")
+	}
+	frame, err := c.current.GetFrameByIndex(rs.StreamDepth, -1)
+	if err != nil {
+		return fail(errNoFrame, "JM_EvaluateROIs", "depth not in frame set: %v", err)
+	}
+	defer frame.Close()
+
+	f, err := roiFrame(frame, len(c.rois.Volumes()) > 0)
+	if err != nil {
+		return fail(errRealSense, "JM_EvaluateROIs", "device %s: %v", c.serial, err)
+	}
+	events, err := c.rois.Process(f)
+	if err != nil {
+		return fail(errInvalidArg, "JM_EvaluateROIs", "%v", err)
+	}
+
+	res := roiResult{
+		FrameNumber: f.FrameNumber,
+		Timestamp:   f.Timestamp,
+		Events:      events,
+		Stats:       map[string]roi.Stats{},
+		Active:      map[string]bool{},
+	}
+	if res.Events == nil {
+		res.Events = []roi.Event{}
+	}
+	var names []string
+	for _, r := range c.rois.Regions() {
+		names = append(names, r.Name)
+	}
+	for _, v := range c.rois.Volumes() {
+		names = append(names, v.Name)
+	}
+	for _, name := range names {
+		res.Stats[name], _ = c.rois.Stats(name)
+		res.Active[name] = c.rois.Active(name)
+	}
+	if c.roiResult, err = json.Marshal(res); err != nil {
+		return fail(errRealSense, "JM_EvaluateROIs", "encode: %v", err)
+	}
+	succeed()
+	return len(events)
+}
+
+// 将最近一次 JM_EvaluateROIs 的结果以 JSON 写入 buf（含结尾 '\0'），格式为
+// {"frame_number": 42, "timestamp": 1234.5, "events": [...], "stats": {"door": {...}}, "active": {"door": true}}
+// events 和 stats 的字段与 roi.Event、roi.Stats 一致
+// 返回值: 成功时为写入的字节数（含 '\0'）；buf 为 NULL 时只返回所需大小；
+// 缓冲区过小时返回 JM_ERR_BUFFER_TOO_SMALL；尚未判定时返回 JM_ERR_STATE
+//
+//export JM_GetROIResult
+func JM_GetROIResult(handle int, buf *C.char, bufLen int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_GetROIResult", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.roiResult == nil {
+		return fail(errState, "JM_GetROIResult", "no ROI result, call JM_EvaluateROIs first")
+	}
+	return copyString("JM_GetROIResult", c.roiResult, buf, bufLen)
+}
+
+// roiFrame 将深度帧转换为 roi.Frame，深度数据直接引用帧缓冲区，只在帧释放前有效
+// withIntrinsics 为 true 时读取内参，评估 3D 体积需要
+func roiFrame(frame *rs.Frame, withIntrinsics bool) (roi.Frame, error) {
+	img, err := depth.FromBuffer(frame.GetDepthData(), frame.GetWidth(), frame.GetHeight())
+	if err != nil {
+		return roi.Frame{}, err
+	}
+	scale, err := frame.GetDepthUnits()
+	if err != nil {
+		return roi.Frame{}, err
+	}
+	f := roi.Frame{Depth: img, Scale: scale}
+	f.Timestamp, _ = frame.GetTimestamp()
+	f.FrameNumber, _ = frame.GetFrameNumber()
+	if withIntrinsics {
+		in, err := frame.GetIntrinsics()
+		if err != nil {
+			return roi.Frame{}, err
+		}
+		d := in.ToDepth()
+		f.Intrinsics = &d
+	}
+	return f, nil
+}
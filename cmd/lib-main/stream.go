@@ -0,0 +1,360 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// 流配置接口：JM_EnableStream/JM_ConfigureStreams 设置要启用的流，JM_Start 开始取流
+// 配置只在开始取流时生效，取流期间修改返回 JM_ERR_STATE；预设和处理链可随时修改
+
+// streamSpec 是 JM_ConfigureStreams 中单个流的配置
+type streamSpec struct {
+	Stream string `json:"stream"`          // depth, color, infrared
+	Index  int    `json:"index,omitempty"` // 流索引，红外左右为 1 和 2，0 表示任意
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	FPS    int    `json:"fps"`
+	Format string `json:"format,omitempty"` // z16, rgb8, y8 ...，为空表示任意
+}
+
+// streamsConfig 是 JM_ConfigureStreams 的 JSON 配置
+type streamsConfig struct {
+	Streams []streamSpec    `json:"streams"`
+	Preset  string          `json:"preset,omitempty"`
+	Filters *rs.ChainConfig `json:"filters,omitempty"`
+}
+
+// parsedStream 是校验后的 streamSpec
+type parsedStream struct {
+	streamSpec
+	stype  rs.StreamType
+	format rs.Format
+}
+
+// parse 校验流类型、格式和分辨率，不修改任何状态
+func (s streamSpec) parse() (parsedStream, error) {
+	p := parsedStream{streamSpec: s, format: rs.FormatAny}
+	var err error
+	if p.stype, err = rs.ParseStreamType(s.Stream); err != nil {
+		return p, err
+	}
+	if s.Format != "" {
+		if p.format, err = rs.ParseFormat(s.Format); err != nil {
+			return p, err
+		}
+	}
+	if s.Index < 0 || s.Width < 0 || s.Height < 0 || s.FPS < 0 {
+		return p, fmt.Errorf("%s: negative index, size or fps", s.Stream)
+	}
+	return p, nil
+}
+
+// enable 启用已校验的流
+func (p parsedStream) enable(cfg *rs.Config) error {
+	if err := cfg.EnableStreamIndex(p.stype, p.Index, p.Width, p.Height, p.FPS, p.format); err != nil {
+		return fmt.Errorf("%s[%d] %dx%d@%d %s: %w", p.Stream, p.Index, p.Width, p.Height, p.FPS, p.Format, err)
+	}
+	return nil
+}
+
+// setPreset 通过深度传感器设置视觉预设，调用方需持有 c.mu
+func (c *camera) setPreset(preset rs.VisualPreset) error {
+	dev, err := c.device()
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+	sensor, err := dev.GetDepthSensor()
+	if err != nil {
+		return err
+	}
+	defer sensor.Close()
+	return sensor.SetVisualPreset(preset)
+}
+
+// newDeviceConfig 创建只选择指定设备、尚未启用任何流的配置
+func newDeviceConfig(serial string) (*rs.Config, error) {
+	cfg, err := rs.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.EnableDevice(serial); err != nil {
+		cfg.Close()
+		return nil, fmt.Errorf("select device %s: %w", serial, err)
+	}
+	return cfg, nil
+}
+
+// parseChain 从 JSON 创建处理链
+func parseChain(text string) (*rs.Chain, error) {
+	cfg, err := rs.ParseChainConfig(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	return rs.NewChainFromConfig(cfg)
+}
+
+// 启用一个流，stream 为 "depth"、"color" 或 "infrared"，format 为 "z16"、"rgb8"、"y8" 等
+// index 为流索引 (0 表示任意)，width/height/fps 为 0 表示任意，format 为 NULL 表示任意
+// 可多次调用启用多个流，之后调用 JM_Start 开始取流
+//
+//export JM_EnableStream
+func JM_EnableStream(handle int, stream *C.char, index int, width int, height int, fps int, format *C.char) int {
+	if stream == nil {
+		return fail(errInvalidArg, "JM_EnableStream", "stream is NULL")
+	}
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_EnableStream", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.streaming {
+		return fail(errState, "JM_EnableStream", "handle %d is streaming, stop it first", handle)
+	}
+	spec := streamSpec{Stream: C.GoString(stream), Index: index, Width: width, Height: height, FPS: fps}
+	if format != nil {
+		spec.Format = C.GoString(format)
+	}
+	p, err := spec.parse()
+	if err != nil {
+		return fail(errInvalidArg, "JM_EnableStream", "%v", err)
+	}
+	if err := p.enable(c.config); err != nil {
+		return fail(errInvalidArg, "JM_EnableStream", "%v", err)
+	}
+	return succeed()
+}
+
+// 清除已启用的所有流
+//
+//export JM_DisableAllStreams
+func JM_DisableAllStreams(handle int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_DisableAllStreams", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.streaming {
+		return fail(errState, "JM_DisableAllStreams", "handle %d is streaming, stop it first", handle)
+	}
+	if err := c.config.DisableAllStreams(); err != nil {
+		return fail(errRealSense, "JM_DisableAllStreams", "%v", err)
+	}
+	return succeed()
+}
+
+// 按 JM_EnableStream/JM_ConfigureStreams 设置的流开始取流
+// 没有启用任何流时使用设备的默认配置
+//
+//export JM_Start
+func JM_Start(handle int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_Start", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.streaming {
+		return fail(errState, "JM_Start", "handle %d is already streaming", handle)
+	}
+	return c.start("JM_Start", handle)
+}
+
+// 以 JSON 一次性设置流、视觉预设和处理链，替换之前启用的流，例如:
+//
+//	{"streams": [{"stream": "depth", "width": 848, "height": 480, "fps": 30, "format": "z16"},
+//	             {"stream": "infrared", "index": 1, "width": 848, "height": 480, "fps": 30, "format": "y8"}],
+//	 "preset": "high_accuracy",
+//	 "filters": {"stages": [{"type": "decimation", "options": {"magnitude": 2}}]}}
+//
+// preset 和 filters 可省略，省略时保持不变
+// 流和处理链先在新的配置对象上建好，有误时返回 JM_ERR_INVALID_ARG 且不改变任何状态；
+// 之后写入预设，设备拒绝时返回 JM_ERR_REALSENSE，之前启用的流和处理链保持不变；
+// 全部成功后才替换流配置和处理链
+//
+//export JM_ConfigureStreams
+func JM_ConfigureStreams(handle int, config *C.char) int {
+	if config == nil {
+		return fail(errInvalidArg, "JM_ConfigureStreams", "config is NULL")
+	}
+	var cfg streamsConfig
+	dec := json.NewDecoder(strings.NewReader(C.GoString(config)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return fail(errInvalidArg, "JM_ConfigureStreams", "parse config: %v", err)
+	}
+
+	streams := make([]parsedStream, len(cfg.Streams))
+	for i, s := range cfg.Streams {
+		p, err := s.parse()
+		if err != nil {
+			return fail(errInvalidArg, "JM_ConfigureStreams", "stream #%d: %v", i, err)
+		}
+		streams[i] = p
+	}
+	var preset rs.VisualPreset
+	if cfg.Preset != "" {
+		var err error
+		if preset, err = rs.ParseVisualPreset(cfg.Preset); err != nil {
+			return fail(errInvalidArg, "JM_ConfigureStreams", "preset: %v", err)
+		}
+	}
+
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_ConfigureStreams", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	if c.streaming {
+		return fail(errState, "JM_ConfigureStreams", "handle %d is streaming, stop it first", handle)
+	}
+
+	// 新的流配置和处理链先在独立的对象上建好，全部成功后才替换，失败时不改变任何状态
+	next, err := newDeviceConfig(c.serial)
+	if err != nil {
+		return fail(errRealSense, "JM_ConfigureStreams", "create config: %v", err)
+	}
+	for i, p := range streams {
+		if err := p.enable(next); err != nil {
+			next.Close()
+			return fail(errInvalidArg, "JM_ConfigureStreams", "stream #%d: %v", i, err)
+		}
+	}
+	var chain *rs.Chain
+	if cfg.Filters != nil {
+		if chain, err = rs.NewChainFromConfig(*cfg.Filters); err != nil {
+			next.Close()
+			return fail(errInvalidArg, "JM_ConfigureStreams", "%v", err)
+		}
+	}
+
+	// 预设是唯一直接写入设备的一步，放在最后；设备拒绝时丢弃新的流配置和处理链
+	if cfg.Preset != "" {
+		if err := c.setPreset(preset); err != nil {
+			next.Close()
+			if chain != nil {
+				chain.Close()
+			}
+			return fail(errRealSense, "JM_ConfigureStreams", "preset %q on device %s: %v", cfg.Preset, c.serial, err)
+		}
+	}
+
+	c.config.Close()
+	c.config = next
+	if cfg.Filters != nil {
+		c.setFilters(chain)
+	}
+	logf(logInfo, "handle %d (%s) configured %d streams", handle, c.serial, len(cfg.Streams))
+	return succeed()
+}
+
+// 将设备支持的流配置以 JSON 数组写入 buf（含结尾 '\0'），格式与 Device.GetCapabilities 一致
+// 返回值: 成功时为写入的字节数（含 '\0'）；buf 为 NULL 时只返回所需大小；
+// 缓冲区过小时返回 JM_ERR_BUFFER_TOO_SMALL，所需大小见 JM_GetLastError
+//
+//export JM_GetCapabilities
+func JM_GetCapabilities(handle int, buf *C.char, bufLen int) int {
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_GetCapabilities", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	dev, err := c.device()
+	if err != nil {
+		return fail(errRealSense, "JM_GetCapabilities", "get device %s: %v", c.serial, err)
+	}
+	defer dev.Close()
+
+	profiles, err := dev.GetCapabilities()
+	if err != nil {
+		return fail(errRealSense, "JM_GetCapabilities", "device %s: %v", c.serial, err)
+	}
+	var out bytes.Buffer
+	if err := json.NewEncoder(&out).Encode(profiles); err != nil {
+		return fail(errRealSense, "JM_GetCapabilities", "encode: %v", err)
+	}
+	return copyString("JM_GetCapabilities", bytes.TrimSpace(out.Bytes()), buf, bufLen)
+}
+
+// copyString 将 data 写入 buf 并以 '\0' 结尾，返回所需字节数（含 '\0'）
+// buf 为 NULL 时只返回所需大小，容量不足时返回 JM_ERR_BUFFER_TOO_SMALL 且不写入
+func copyString(fn string, data []byte, buf *C.char, bufLen int) int {
+	need := len(data) + 1
+	if buf == nil {
+		succeed()
+		return need
+	}
+	if need > bufLen {
+		return fail(errBufferTooSmall, fn, "need %d bytes, got %d", need, bufLen)
+	}
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(buf)), bufLen)
+	copy(dst, data)
+	dst[len(data)] = 0
+	succeed()
+	return need
+}
+
+// 设置深度传感器的视觉预设，name 为 "default"、"high_accuracy"、"high_density" 等
+//
+//export JM_SetVisualPreset
+func JM_SetVisualPreset(handle int, name *C.char) int {
+	if name == nil {
+		return fail(errInvalidArg, "JM_SetVisualPreset", "name is NULL")
+	}
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		return fail(errInvalidHandle, "JM_SetVisualPreset", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	preset, err := rs.ParseVisualPreset(C.GoString(name))
+	if err != nil {
+		return fail(errInvalidArg, "JM_SetVisualPreset", "%v", err)
+	}
+	if err := c.setPreset(preset); err != nil {
+		return fail(errRealSense, "JM_SetVisualPreset", "device %s: %v", c.serial, err)
+	}
+	logf(logInfo, "handle %d (%s) visual preset %s", handle, c.serial, C.GoString(name))
+	return succeed()
+}
+
+// 以 JSON 设置取帧时应用的处理链，格式与 rs.ChainConfig 一致，例如
+// {"stages": [{"type": "decimation", "options": {"magnitude": 2}}, {"type": "align", "align_to": "color"}]}
+// config 为 NULL 或空字符串时清除处理链；取流期间也可调用
+//
+//export JM_SetFilters
+func JM_SetFilters(handle int, config *C.char) int {
+	var chain *rs.Chain
+	if config != nil && C.GoString(config) != "" {
+		var err error
+		if chain, err = parseChain(C.GoString(config)); err != nil {
+			return fail(errInvalidArg, "JM_SetFilters", "%v", err)
+		}
+	}
+
+	c := lookup(handle)
+	if c == nil || !c.lock() {
+		if chain != nil {
+			chain.Close()
+		}
+		return fail(errInvalidHandle, "JM_SetFilters", "invalid handle %d", handle)
+	}
+	defer c.mu.Unlock()
+
+	c.setFilters(chain)
+	return succeed()
+}
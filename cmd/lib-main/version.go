@@ -0,0 +1,71 @@
+package main
+
+/*
+#include <stdlib.h>
+
+// 接口版本，不兼容的 ABI 修改增加 MAJOR，新增接口增加 MINOR
+// 宿主可将编译时的宏与 JM_GetApiVersion() 的返回值比较，主版本不同时应拒绝运行
+#define JM_API_VERSION_MAJOR 2
+#define JM_API_VERSION_MINOR 2
+#define JM_API_VERSION (JM_API_VERSION_MAJOR * 100 + JM_API_VERSION_MINOR)
+
+// 功能位，由 JM_GetFeatures 返回
+typedef enum {
+    JM_FEATURE_MULTI_CAMERA  = 1 << 0, // 句柄式多相机接口 (JM_Open/JM_Close)
+    JM_FEATURE_ERROR_INFO    = 1 << 1, // JM_GetLastError/JM_GetErrorName/JM_SetLogCallback
+    JM_FEATURE_STREAM_CONFIG = 1 << 2, // JM_EnableStream/JM_ConfigureStreams/JM_GetCapabilities/JM_SetFilters
+    JM_FEATURE_FRAME_INFO    = 1 << 3, // JM_FetchFrames/JM_FetchAlignedFrames/JM_GetFrameInfo/JM_CopyFrame
+    JM_FEATURE_ZERO_COPY     = 1 << 4, // JM_AcquireFrame/JM_ReleaseFrame/JM_SetFrameCallback
+    JM_FEATURE_VERSION_QUERY = 1 << 5, // JM_GetVersion/JM_GetApiVersion/JM_GetLibrealsenseVersion/JM_GetFeatures
+    JM_FEATURE_ROI           = 1 << 6  // JM_SetROIs/JM_EvaluateROIs/JM_GetROIResult
+} JM_Feature;
+*/
+import "C"
+import (
+	"sync"
+
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+)
+
+// features 是本库支持的全部功能位
+const features = C.JM_FEATURE_MULTI_CAMERA | C.JM_FEATURE_ERROR_INFO | C.JM_FEATURE_STREAM_CONFIG |
+	C.JM_FEATURE_FRAME_INFO | C.JM_FEATURE_ZERO_COPY | C.JM_FEATURE_VERSION_QUERY | C.JM_FEATURE_ROI
+
+// 版本字符串只分配一次，指针在进程内保持有效
+var (
+	versionC     = C.CString(Version)
+	rsVersionC   *C.char
+	rsVersionOne sync.Once
+)
+
+// 返回中间件版本，例如 "v2.1.0-20261019"，字符串由库持有，无需释放
+//
+//export JM_GetVersion
+func JM_GetVersion() *C.char {
+	return versionC
+}
+
+// 返回接口版本 JM_API_VERSION_MAJOR*100 + JM_API_VERSION_MINOR
+//
+//export JM_GetApiVersion
+func JM_GetApiVersion() int {
+	return int(C.JM_API_VERSION)
+}
+
+// 返回运行时链接的 librealsense 版本，例如 "2.55.1"，获取失败时为空字符串
+// 字符串由库持有，无需释放
+//
+//export JM_GetLibrealsenseVersion
+func JM_GetLibrealsenseVersion() *C.char {
+	rsVersionOne.Do(func() {
+		rsVersionC = C.CString(rs.GetVersion())
+	})
+	return rsVersionC
+}
+
+// 返回本库支持的功能位 (JM_Feature 的组合)
+//
+//export JM_GetFeatures
+func JM_GetFeatures() int {
+	return features
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/archive"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// archiveSource 按录制时的时间戳回放 .rsda 深度归档，只有深度流，播放到结尾后从头循环
+type archiveSource struct {
+	path string
+	r    *archive.Reader
+
+	next   *snapshot.Frame // 已读出、等待播放时间的帧
+	base   time.Time       // 本轮播放开始的时刻
+	baseTS float64         // 本轮第一帧的时间戳（毫秒）
+	lastTS float64         // 上一帧的时间戳
+	cursor int             // 下一次读取的帧序号
+	newRun bool            // 下一个成功读出的帧开始新一轮播放
+
+	mu       sync.Mutex // 保护 loops、position，Status 在 HTTP 线程中调用
+	loops    int
+	position int
+}
+
+// openArchive 打开归档，空归档视为错误
+func openArchive(path string) (*archiveSource, error) {
+	r, err := archive.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() == 0 {
+		r.Close()
+		return nil, fmt.Errorf("%s: archive has no frames", path)
+	}
+	return &archiveSource{path: path, r: r, newRun: true}, nil
+}
+
+// Next 返回到达播放时间的帧；未到时最多等待 100 毫秒后返回 ok=false，以便及时响应退出信号
+func (s *archiveSource) Next() (snapshot.Frame, bool, error) {
+	if s.next == nil {
+		if err := s.read(); err != nil {
+			return snapshot.Frame{}, false, err
+		}
+	}
+	wait := time.Until(s.base.Add(time.Duration((s.next.Timestamp - s.baseTS) * float64(time.Millisecond))))
+	if wait > 0 {
+		time.Sleep(min(wait, 100*time.Millisecond))
+		if wait > 100*time.Millisecond {
+			return snapshot.Frame{}, false, nil
+		}
+	}
+	f := *s.next
+	s.next = nil
+	return f, true, nil
+}
+
+// read 读取下一帧，读完时回到开头开始新一轮
+// 损坏的帧被跳过：返回错误的同时前进到其后一帧，下次调用不会重复读取同一帧
+func (s *archiveSource) read() error {
+	af, err := s.r.Next()
+	if errors.Is(err, io.EOF) {
+		if err := s.r.Seek(0); err != nil {
+			return err
+		}
+		s.cursor, s.newRun = 0, true
+		s.mu.Lock()
+		s.loops++
+		s.mu.Unlock()
+		af, err = s.r.Next()
+	}
+	if err != nil {
+		s.cursor++
+		if serr := s.r.Seek(s.cursor); serr != nil {
+			return serr
+		}
+		return fmt.Errorf("%s: frame %d: %w", s.path, s.cursor-1, err)
+	}
+	s.cursor = af.Index + 1
+	img, err := s.r.Image(af) // af.Data 为新分配的切片，无需再拷贝
+	if err != nil {
+		return fmt.Errorf("%s: frame %d: %w", s.path, af.Index, err)
+	}
+	if s.newRun {
+		s.restart(af.Timestamp)
+		s.newRun = false
+	}
+	s.lastTS = af.Timestamp
+	s.mu.Lock()
+	s.position = af.Index
+	s.mu.Unlock()
+	s.next = &snapshot.Frame{
+		Depth:       img,
+		DepthScale:  s.r.Header().DepthScale,
+		Timestamp:   af.Timestamp,
+		FrameNumber: af.FrameNumber,
+	}
+	return nil
+}
+
+// restart 开始新一轮播放，与上一轮最后一帧间隔一个平均帧间隔
+func (s *archiveSource) restart(ts float64) {
+	if s.base.IsZero() {
+		s.base, s.baseTS = time.Now(), ts
+		return
+	}
+	interval := 1000.0 / 30 // 只有一帧或时间戳无效时按 30fps
+	if n := s.r.Len(); n > 1 && s.lastTS > s.baseTS {
+		interval = (s.lastTS - s.baseTS) / float64(n-1)
+	}
+	s.base = s.base.Add(time.Duration((s.lastTS - s.baseTS + interval) * float64(time.Millisecond)))
+	s.baseTS = ts
+}
+
+func (s *archiveSource) Status() map[string]any {
+	s.mu.Lock()
+	out := map[string]any{
+		"playback": s.path,
+		"frames":   s.r.Len(),
+		"position": s.position,
+		"loops":    s.loops,
+	}
+	s.mu.Unlock()
+	for k, v := range s.r.Header().Metadata {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *archiveSource) Close() {
+	s.r.Close()
+}
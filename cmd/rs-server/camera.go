@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/rs"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// cameraOptions 相机或 .bag 回放的参数
+type cameraOptions struct {
+	width, height, fps int
+	align              bool
+	bag                string // 非空时从 .bag 回放，按录像中的流和分辨率播放
+}
+
+// cameraSource 从 Pipeline 取帧，相机和 .bag 回放共用
+type cameraSource struct {
+	ctx        *rs.Context
+	pipeline   *rs.Pipeline
+	dev        *rs.Device
+	aligner    *rs.Align
+	depthScale float32
+	status     func() map[string]any
+}
+
+// openCamera 启动 Pipeline，失败时释放已创建的对象
+func openCamera(opts cameraOptions) (_ *cameraSource, err error) {
+	s := &cameraSource{}
+	defer func() {
+		if err != nil {
+			s.Close()
+		}
+	}()
+
+	if s.ctx, err = rs.NewContext(); err != nil {
+		return nil, fmt.Errorf("create context: %w", err)
+	}
+	if s.pipeline, err = rs.NewPipeline(s.ctx); err != nil {
+		return nil, fmt.Errorf("create pipeline: %w", err)
+	}
+	cfg, err := rs.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("create config: %w", err)
+	}
+	defer cfg.Close()
+	if opts.bag != "" {
+		if err := cfg.EnableDeviceFromFile(opts.bag); err != nil {
+			return nil, fmt.Errorf("open %s: %w", opts.bag, err)
+		}
+	} else {
+		if err := cfg.EnableStream(rs.StreamDepth, opts.width, opts.height, opts.fps, rs.FormatZ16); err != nil {
+			return nil, fmt.Errorf("enable depth stream: %w", err)
+		}
+		if err := cfg.EnableStream(rs.StreamColor, opts.width, opts.height, opts.fps, rs.FormatRGB8); err != nil {
+			return nil, fmt.Errorf("enable color stream: %w", err)
+		}
+	}
+	if err := s.pipeline.Start(cfg); err != nil {
+		return nil, fmt.Errorf("start pipeline: %w", err)
+	}
+
+	if s.dev, err = s.pipeline.GetDevice(); err != nil {
+		return nil, fmt.Errorf("get device: %w", err)
+	}
+	s.depthScale = depth.DefaultDepthScale
+	if sensor, err := s.dev.GetDepthSensor(); err == nil {
+		if v, err := sensor.GetDepthScale(); err == nil {
+			s.depthScale = v
+		}
+		sensor.Close()
+	}
+	if opts.align {
+		if s.aligner, err = rs.NewAlign(rs.StreamColor); err != nil {
+			return nil, fmt.Errorf("create align: %w", err)
+		}
+	}
+
+	s.status = deviceStatus(s.dev)
+	if opts.bag != "" {
+		device := s.status
+		s.status = func() map[string]any {
+			out := device()
+			out["playback"] = opts.bag
+			return out
+		}
+	}
+	return s, nil
+}
+
+// Next 等待下一组帧，最多 1 秒
+func (s *cameraSource) Next() (snapshot.Frame, bool, error) {
+	frames, ok, err := s.pipeline.TryWaitForFrames(1000)
+	if err != nil || !ok {
+		return snapshot.Frame{}, false, err
+	}
+	defer frames.Close()
+	f, err := toSnapshotFrame(frames, s.aligner, s.depthScale)
+	if err != nil {
+		return snapshot.Frame{}, false, err
+	}
+	return f, true, nil
+}
+
+func (s *cameraSource) Status() map[string]any {
+	return s.status()
+}
+
+// Close 停止 Pipeline 并释放资源，可用于部分初始化的对象
+func (s *cameraSource) Close() {
+	if s.aligner != nil {
+		s.aligner.Close()
+	}
+	if s.dev != nil {
+		s.dev.Close()
+	}
+	if s.pipeline != nil {
+		s.pipeline.Stop()
+		s.pipeline.Close()
+	}
+	if s.ctx != nil {
+		s.ctx.Close()
+	}
+}
+
+// deviceStatus 返回附加到 /status 的设备信息和遥测数据
+func deviceStatus(dev *rs.Device) func() map[string]any {
+	info := map[string]any{"librealsense": rs.GetVersion()}
+	for key, ci := range map[string]rs.CameraInfo{
+		"name":             rs.CameraInfoName,
+		"serial_number":    rs.CameraInfoSerialNumber,
+		"firmware_version": rs.CameraInfoFirmwareVersion,
+		"usb_type":         rs.CameraInfoUsbTypeDescriptor,
+	} {
+		if v, err := dev.GetInfo(ci); err == nil {
+			info[key] = v
+		}
+	}
+	return func() map[string]any {
+		out := make(map[string]any, len(info)+2)
+		for k, v := range info {
+			out[k] = v
+		}
+		if t, err := dev.GetTelemetry(); err == nil {
+			out["asic_temperature"] = t.AsicTemperature
+			out["projector_temperature"] = t.ProjectorTemperature
+		}
+		return out
+	}
+}
+
+// toSnapshotFrame 对齐（可选）并将彩色和深度数据拷贝到 Go 内存
+// 回放的录像中彩色流不是 RGB8 时只发布深度
+func toSnapshotFrame(frames *rs.FrameSet, aligner *rs.Align, scale float32) (snapshot.Frame, error) {
+	if aligner != nil {
+		aligned, err := aligner.Process(frames)
+		if err != nil {
+			return snapshot.Frame{}, err
+		}
+		defer aligned.Close()
+		frames = aligned
+	}
+
+	var color []byte
+	var cw, ch int
+	if colorFrame, err := frames.GetFrame(rs.StreamColor); err == nil {
+		defer colorFrame.Close()
+		if format, err := colorFrame.GetFormat(); err == nil && format == rs.FormatRGB8 {
+			color, cw, ch = colorFrame.GetRawData(), colorFrame.GetWidth(), colorFrame.GetHeight()
+		}
+	}
+	var depthData []uint16
+	var dw, dh int
+	var ts float64
+	var num uint64
+	if depthFrame, err := frames.GetFrame(rs.StreamDepth); err == nil {
+		defer depthFrame.Close()
+		depthData, dw, dh = depthFrame.GetDepthData(), depthFrame.GetWidth(), depthFrame.GetHeight()
+		ts, _ = depthFrame.GetTimestamp()
+		num, _ = depthFrame.GetFrameNumber()
+	}
+
+	f, err := snapshot.NewFrame(color, cw, ch, depthData, dw, dh)
+	if err != nil {
+		return snapshot.Frame{}, err
+	}
+	f.DepthScale, f.Timestamp, f.FrameNumber = scale, ts, num
+	return f, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/server"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// source 是帧来源：相机、.bag 回放或 .rsda 深度归档
+type source interface {
+	// Next 返回下一帧，ok 为 false 表示暂时没有帧（超时或未到播放时间）
+	Next() (f snapshot.Frame, ok bool, err error)
+	// Status 返回附加到 /status 的字段
+	Status() map[string]any
+	Close()
+}
+
+// 通过 HTTP 向浏览器提供相机画面：MJPEG 流、单帧抓拍、JSON 状态和 WebSocket 二进制帧流
+// 浏览器打开 http://<jetson-ip>:8080/ 即可预览；-playback 指定录像时不需要连接相机
+func main() {
+	addr := flag.String("addr", ":8080", "监听地址")
+	width := flag.Int("width", 640, "宽度")
+	height := flag.Int("height", 480, "高度")
+	fps := flag.Int("fps", 30, "帧率")
+	quality := flag.Int("quality", 80, "JPEG 质量 (1-100)")
+	align := flag.Bool("align", true, "深度对齐到彩色")
+	playback := flag.String("playback", "", "回放文件：.bag (librealsense 录像) 或 .rsda (深度归档)，循环播放")
+	allowOrigin := flag.String("allow-origin", "", "允许连接 /ws 的跨源页面，逗号分隔，如 http://192.168.1.20:3000；* 表示任意来源")
+	flag.Parse()
+
+	var src source
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(*playback)); {
+	case *playback == "":
+		src, err = openCamera(cameraOptions{width: *width, height: *height, fps: *fps, align: *align})
+	case ext == ".bag":
+		src, err = openCamera(cameraOptions{bag: *playback, align: *align})
+	case ext == ".rsda":
+		src, err = openArchive(*playback)
+	default:
+		err = fmt.Errorf("unsupported playback file %q (want .bag or .rsda)", *playback)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+
+	opts := server.Options{JPEGQuality: *quality, Status: src.Status}
+	if *allowOrigin != "" {
+		opts.AllowedOrigins = strings.Split(*allowOrigin, ",")
+	}
+	srv, err := server.New(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer srv.Close()
+
+	httpServer := &http.Server{Addr: *addr, Handler: srv.Handler()}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server error: %v", err)
+		}
+	}()
+	if *playback != "" {
+		fmt.Printf("Serving on %s (playback %s)\n", *addr, *playback)
+	} else {
+		fmt.Printf("Serving on %s (%dx%d@%d, align=%v)\n", *addr, *width, *height, *fps, *align)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	var backoff time.Duration // 连续出错时的等待时间，成功取帧后清零
+	for {
+		select {
+		case <-stop:
+			fmt.Println("Shutting down...")
+			shutdown, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			srv.Close() // 先断开 MJPEG 长连接，Shutdown 才能及时返回
+			httpServer.Shutdown(shutdown)
+			cancel()
+			return
+		default:
+		}
+
+		f, ok, err := src.Next()
+		if err != nil {
+			// 设备断开等持续错误时逐步延长等待（最长 1 秒），避免空转占满 CPU 和日志
+			backoff = min(max(2*backoff, 10*time.Millisecond), time.Second)
+			log.Printf("Frame error: %v (retry in %v)", err, backoff)
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+		if ok {
+			srv.Publish(f)
+		}
+	}
+}
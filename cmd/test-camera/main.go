@@ -1,40 +1,74 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/tianfei212/jetson-rs-middleware/archive"
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
 	"github.com/tianfei212/jetson-rs-middleware/rs"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
 )
 
-// ROI 触发逻辑模拟
-// 假设 ROI 是中心 100x100 的区域
-func checkROITrigger(depthData []uint16, width, height int, scale float32) bool {
-	roiW, roiH := 100, 100
-	startX := (width - roiW) / 2
-	startY := (height - roiH) / 2
-
-	validPoints := 0
-	minPoints := 500              // 阈值点数
-	distThreshold := float32(1.5) // 1.5米
-
-	for y := startY; y < startY+roiH; y++ {
-		for x := startX; x < startX+roiW; x++ {
-			idx := y*width + x
-			if idx < len(depthData) {
-				dist := float32(depthData[idx]) * scale
-				if dist > 0.1 && dist < distThreshold {
-					validPoints++
-				}
-			}
-		}
+// newROIEngine 创建 ROI 触发引擎
+// 区域定义在彩色图坐标 (640x480) 中：中心 100x100，0.1-1.5 米内至少 500 个点，
+// 连续 3 帧满足才触发，触发后 2 秒内不重复触发
+func newROIEngine() (*roi.Engine, error) {
+	return roi.NewEngine(640, 480, roi.Region{
+		Name:        "center",
+		Rect:        &roi.Rect{X: 270, Y: 190, W: 100, H: 100},
+		MinDistance: 0.1,
+		MaxDistance: 1.5,
+		MinPoints:   500,
+		Debounce:    3,
+		CooldownMS:  2000,
+	})
+}
+
+// saveSnapshot 拷贝当前帧放入抓拍缓冲，有触发事件时交给后台写出文件
+func saveSnapshot(c *snapshot.Capturer, fs *rs.FrameSet, img *depth.Image, scale float32, ts float64, frameNum uint64, events []roi.Event) {
+	var color []byte
+	var cw, ch int
+	colorFrame, err := fs.GetFrame(rs.StreamColor)
+	if err == nil {
+		defer colorFrame.Close()
+		color, cw, ch = colorFrame.GetRawData(), colorFrame.GetWidth(), colorFrame.GetHeight()
+	}
+
+	f, err := snapshot.NewFrame(color, cw, ch, img.Pix, img.Width, img.Height)
+	if err != nil {
+		log.Printf("Snapshot error: %v", err)
+		return
+	}
+	f.DepthScale, f.Timestamp, f.FrameNumber = scale, ts, frameNum
+
+	if err := c.Process(f, events); err != nil {
+		log.Printf("Snapshot error: %v", err)
 	}
+	for _, r := range c.Results() {
+		fmt.Printf("Snapshot saved to %s (%d frames)\n", r.Dir, len(r.Sidecar.Files))
+	}
+}
 
-	return validPoints > minPoints
+// newDepthArchive 按滤波后深度帧的分辨率和内参创建深度归档
+func newDepthArchive(path string, f *rs.Frame, scale float32) (*archive.Writer, error) {
+	hdr := archive.Header{Width: f.GetWidth(), Height: f.GetHeight(), DepthScale: scale}
+	if in, err := f.GetIntrinsics(); err == nil {
+		di := in.ToDepth()
+		hdr.Intrinsics = &di
+	}
+	return archive.Create(path, hdr)
 }
 
 func main() {
+	snapshotDir := flag.String("snapshot-dir", "", "ROI 触发时保存抓拍的目录，为空则不保存")
+	preTrigger := flag.Int("pre-trigger", 5, "抓拍时额外保存的触发前帧数")
+	archivePath := flag.String("depth-archive", "", "将滤波后的深度帧无损录制到该文件 (.rsda)，为空则不录制")
+	flag.Parse()
+
 	fmt.Println("Starting RealSense D455 Camera Comprehensive Test...")
 
 	// 1. 创建上下文
@@ -109,15 +143,23 @@ func main() {
 		}
 	}
 
-	// 6. 初始化过滤器 (Filters)
-	decimation, _ := rs.NewDecimationFilter()
-	defer decimation.Close()
-	spatial, _ := rs.NewSpatialFilter()
-	defer spatial.Close()
-	temporal, _ := rs.NewTemporalFilter()
-	defer temporal.Close()
-	holeFilling, _ := rs.NewHoleFillingFilter()
-	defer holeFilling.Close()
+	// 6. 初始化过滤器链 (Filters)
+	// 按 Intel 推荐顺序: Decimation -> Threshold -> Depth2Disparity -> Spatial -> Temporal -> Disparity2Depth -> HoleFilling
+	filters, err := rs.NewChainFromConfig(rs.ChainConfig{
+		Stages: []rs.StageConfig{
+			{Type: "decimation"},
+			{Type: "threshold", Options: map[string]float32{"min_distance": 0.1, "max_distance": 4.0}},
+			{Type: "depth_to_disparity"},
+			{Type: "spatial"},
+			{Type: "temporal"},
+			{Type: "disparity_to_depth"},
+			{Type: "hole_filling"},
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create filter chain: %v", err)
+	}
+	defer filters.Close()
 
 	// 初始化 Colorizer (深度伪彩色)
 	colorizer, _ := rs.NewColorizer()
@@ -129,11 +171,7 @@ func main() {
 		log.Fatalf("Failed to create align: %v", err)
 	}
 	// Align 也是一个 processing block，需要释放
-	// 注意：rs.Align 结构体没有 Close 方法? 检查 rs/align.go
-	// rs/align.go 中的 Align 有 ptr 和 queue，需要手动释放吗？
-	// Align 结构体本身应该有 Close 方法。如果没有，我们应该添加。
-	// 这里假设有 Close (如果没有，稍后添加)
-	// defer align.Close() // TODO: Check if Align has Close method
+	defer align.Close()
 
 	fmt.Println("Filters and Colorizer initialized.")
 
@@ -153,6 +191,54 @@ func main() {
 		}
 	}
 
+	// ROI 触发引擎，区域按彩色图坐标定义，降采样后的深度图会自动换算
+	trigger, err := newROIEngine()
+	if err != nil {
+		log.Fatalf("Failed to create ROI engine: %v", err)
+	}
+	defer trigger.Close()
+
+	// 触发抓拍（可选）
+	var capturer *snapshot.Capturer
+	if *snapshotDir != "" {
+		info := snapshot.DeviceInfo{}
+		if dev != nil {
+			info.Name, _ = dev.GetInfo(rs.CameraInfoName)
+			info.SerialNumber, _ = dev.GetInfo(rs.CameraInfoSerialNumber)
+			info.FirmwareVersion, _ = dev.GetInfo(rs.CameraInfoFirmwareVersion)
+			info.PhysicalPort, _ = dev.GetPhysicalPort()
+		}
+		capturer, err = snapshot.NewCapturer(snapshot.Options{
+			Dir:        *snapshotDir,
+			PreTrigger: *preTrigger,
+			Device:     info,
+			Settings: map[string]any{
+				"color":   "640x480@30 RGB8",
+				"depth":   "640x480@30 Z16",
+				"regions": trigger.Regions(),
+			},
+		})
+		if err != nil {
+			log.Fatalf("Failed to create snapshot capturer: %v", err)
+		}
+		defer func() {
+			if err := capturer.Close(); err != nil {
+				log.Printf("Snapshot error: %v", err)
+			}
+		}()
+	}
+
+	// 深度归档（可选），滤波后的分辨率在第一帧时确定
+	var depthArchive *archive.Writer
+	defer func() {
+		if depthArchive != nil {
+			if err := depthArchive.Close(); err != nil {
+				log.Printf("Failed to close depth archive: %v", err)
+			}
+			fmt.Printf("Depth archive saved to %s (%d frames)\n", *archivePath, depthArchive.Len())
+		}
+	}()
+
 	for time.Since(start) < 10*time.Second {
 		// 等待一组帧
 		frames, err := pipeline.WaitForFrames(5000)
@@ -172,19 +258,14 @@ func main() {
 		// 9. 获取深度帧并进行滤波处理
 		depthFrame, err := alignedFrames.GetFrame(rs.StreamDepth)
 		if err == nil {
-			// 链式滤波处理
-			// Decimation -> Spatial -> Temporal -> HoleFilling
-			f1, _ := decimation.Process(depthFrame)
+			// 链式滤波处理，中间帧由 Chain 负责释放
+			finalDepth, err := filters.Process(depthFrame)
 			depthFrame.Close() // 释放原始深度帧
-
-			f2, _ := spatial.Process(f1)
-			f1.Close()
-
-			f3, _ := temporal.Process(f2)
-			f2.Close()
-
-			finalDepth, _ := holeFilling.Process(f3)
-			f3.Close()
+			if err != nil {
+				log.Printf("Error filtering depth: %v", err)
+				alignedFrames.Close()
+				continue
+			}
 
 			// 10. 生成伪彩色深度图 (用于预览)
 			heatmapFrame, _ := colorizer.Process(finalDepth)
@@ -197,8 +278,34 @@ func main() {
 			ts, _ := finalDepth.GetTimestamp()
 			domain, _ := finalDepth.GetTimestampDomain()
 
-			// 11. 模拟 ROI 触发
-			triggered := checkROITrigger(depthData, 640, 480, depthScale)
+			// 11. ROI 触发（使用滤波后深度图的实际分辨率）
+			triggered := false
+			frameNum, _ := finalDepth.GetFrameNumber()
+			img, err := depth.FromBuffer(depthData, finalDepth.GetWidth(), finalDepth.GetHeight())
+			if err == nil && *archivePath != "" {
+				if depthArchive == nil {
+					depthArchive, err = newDepthArchive(*archivePath, finalDepth, depthScale)
+					if err != nil {
+						log.Fatalf("Failed to create depth archive: %v", err)
+					}
+				}
+				if err := depthArchive.WriteFrame(img.Pix, frameNum, ts); err != nil {
+					log.Printf("Depth archive error: %v", err)
+				}
+			}
+			if err == nil {
+				events, err := trigger.Process(roi.Frame{Depth: img, Scale: depthScale, Timestamp: ts, FrameNumber: frameNum})
+				if err != nil {
+					log.Printf("ROI error: %v", err)
+				}
+				for _, ev := range events {
+					triggered = true
+					fmt.Printf("ROI %q triggered: %d points, mean %.2fm\n", ev.Region, ev.Stats.Points, ev.Stats.MeanDepth)
+				}
+				if capturer != nil {
+					saveSnapshot(capturer, alignedFrames, img, depthScale, ts, frameNum, events)
+				}
+			}
 
 			if frameCount%30 == 0 {
 				fmt.Printf("Frame #%d: TS=%.2f (Domain: %d) | Depth Size: %d | Heatmap Size: %d | Trigger: %v\n",
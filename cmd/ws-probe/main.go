@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+	"github.com/tianfei212/jetson-rs-middleware/stream"
+)
+
+// 连接 rs-server 的 WebSocket 帧流，打印每帧的段大小与实际帧率
+// 可选地把收到的帧保存到目录，用于检查协议实现或测量带宽
+func main() {
+	url := flag.String("url", "ws://127.0.0.1:8080/ws", "帧流地址")
+	streams := flag.String("streams", "color,depth", "订阅的流 (color,depth,heatmap)")
+	fps := flag.Float64("fps", 0, "最大帧率，0 表示不限制")
+	maxWidth := flag.Int("max-width", 0, "缩放宽度，0 表示原始分辨率")
+	quality := flag.Int("quality", 0, "JPEG 质量，0 表示服务端默认")
+	rawDepth := flag.Bool("raw-depth", false, "深度不压缩")
+	count := flag.Int("n", 0, "接收帧数，0 表示一直接收")
+	out := flag.String("out", "", "保存目录：彩色/伪彩色保存为 JPEG，深度保存为 16 位 PNG")
+	flag.Parse()
+
+	sub := stream.Subscription{Streams: strings.Split(*streams, ","), MaxFPS: *fps, MaxWidth: *maxWidth, Quality: *quality}
+	if *rawDepth {
+		sub.DepthEncoding = "raw"
+	}
+	client, err := stream.Dial(*url, sub)
+	if err != nil {
+		log.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+	fmt.Printf("Connected to %s (protocol v%d, streams: %s)\n", *url, stream.ProtocolVersion, strings.Join(client.Streams(), ","))
+
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			log.Fatalf("Failed to create output dir: %v", err)
+		}
+	}
+
+	start := time.Now()
+	var total int
+	for i := 0; *count == 0 || i < *count; i++ {
+		m, err := client.Next()
+		if err != nil {
+			log.Fatalf("Receive: %v", err)
+		}
+		var parts []string
+		size := 32
+		for _, sec := range m.Sections {
+			parts = append(parts, fmt.Sprintf("%s/%s %dB", sec.Stream, sec.Encoding, len(sec.Data)))
+			size += 8 + len(sec.Data)
+		}
+		total += size
+		elapsed := time.Since(start).Seconds()
+		fmt.Printf("#%d frame=%d ts=%.3f %dx%d %s | %.1f fps %.1f KB/s\n", i, m.FrameNumber, m.Timestamp,
+			m.Width, m.Height, strings.Join(parts, ", "), float64(i+1)/elapsed, float64(total)/1024/elapsed)
+
+		if *out != "" {
+			if err := save(*out, m); err != nil {
+				log.Fatalf("Save frame %d: %v", m.FrameNumber, err)
+			}
+		}
+	}
+}
+
+// save 保存一帧的所有段
+func save(dir string, m *stream.Message) error {
+	for _, sec := range m.Sections {
+		name := filepath.Join(dir, fmt.Sprintf("%06d_%s", m.FrameNumber, sec.Stream))
+		if sec.Encoding == stream.EncodingJPEG {
+			if err := os.WriteFile(name+".jpg", sec.Data, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := m.Section(stream.StreamDepth); !ok {
+		return nil
+	}
+	p, err := m.Payload()
+	if err != nil {
+		return err
+	}
+	img, err := depth.FromBuffer(p.DepthBuffer, p.Width, p.Height)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%06d_depth.png", m.FrameNumber)))
+	if err != nil {
+		return err
+	}
+	if err := snapshot.EncodeDepth(f, img, snapshot.DepthPNG16); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,204 @@
+package depth
+
+import (
+	"fmt"
+	"image"
+)
+
+// ColorScheme 伪彩色配色方案，取值与 rs.ColorScheme 一致
+type ColorScheme int
+
+const (
+	ColorSchemeJet          ColorScheme = 0
+	ColorSchemeClassic      ColorScheme = 1
+	ColorSchemeWhiteToBlack ColorScheme = 2
+	ColorSchemeBlackToWhite ColorScheme = 3
+	ColorSchemeBio          ColorScheme = 4
+	ColorSchemeCold         ColorScheme = 5
+	ColorSchemeWarm         ColorScheme = 6
+	ColorSchemeQuantized    ColorScheme = 7
+	ColorSchemePattern      ColorScheme = 8
+	ColorSchemeHue          ColorScheme = 9
+)
+
+// colorMap 由均匀分布的控制点组成，steps > 0 时对输入做离散量化
+type colorMap struct {
+	points [][3]float32
+	steps  int
+}
+
+// colorMaps 与 librealsense colorizer 中的色表保持一致
+var colorMaps = map[ColorScheme]colorMap{
+	ColorSchemeJet: {points: [][3]float32{
+		{0, 0, 255}, {0, 255, 255}, {255, 255, 0}, {255, 0, 0}, {50, 0, 0},
+	}},
+	ColorSchemeClassic: {points: [][3]float32{
+		{30, 77, 203}, {25, 60, 192}, {45, 117, 220}, {204, 108, 191}, {196, 57, 178}, {198, 33, 24},
+	}},
+	ColorSchemeWhiteToBlack: {points: [][3]float32{
+		{255, 255, 255}, {0, 0, 0},
+	}},
+	ColorSchemeBlackToWhite: {points: [][3]float32{
+		{0, 0, 0}, {255, 255, 255},
+	}},
+	ColorSchemeBio: {points: [][3]float32{
+		{0, 0, 204}, {204, 230, 255}, {255, 255, 153}, {170, 255, 128}, {0, 153, 0}, {230, 242, 255},
+	}},
+	ColorSchemeCold: {points: [][3]float32{
+		{230, 247, 255}, {0, 92, 230}, {0, 179, 179}, {0, 51, 153}, {0, 5, 15},
+	}},
+	ColorSchemeWarm: {points: [][3]float32{
+		{255, 255, 230}, {255, 204, 0}, {255, 136, 77}, {255, 51, 0}, {128, 0, 0}, {10, 0, 0},
+	}},
+	ColorSchemeQuantized: {points: [][3]float32{
+		{255, 255, 255}, {0, 0, 0},
+	}, steps: 6},
+	ColorSchemePattern: {points: [][3]float32{
+		{255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0},
+		{255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0},
+		{255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0}, {255, 255, 255}, {0, 0, 0},
+	}},
+	ColorSchemeHue: {points: [][3]float32{
+		{255, 0, 0}, {255, 255, 0}, {0, 255, 0}, {0, 255, 255}, {0, 0, 255}, {255, 0, 255}, {255, 0, 0},
+	}},
+}
+
+// at 返回 t (0-1) 处的颜色，控制点之间线性插值
+func (cm colorMap) at(t float32) [3]uint8 {
+	if t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+	if cm.steps > 0 {
+		// 量化到 steps 个离散等级
+		level := int(t * float32(cm.steps))
+		if level >= cm.steps {
+			level = cm.steps - 1
+		}
+		t = float32(level) / float32(cm.steps-1)
+	}
+
+	pos := t * float32(len(cm.points)-1)
+	i := int(pos)
+	if i >= len(cm.points)-1 {
+		i = len(cm.points) - 2
+	}
+	frac := pos - float32(i)
+	a, b := cm.points[i], cm.points[i+1]
+
+	var c [3]uint8
+	for k := 0; k < 3; k++ {
+		c[k] = uint8(a[k] + (b[k]-a[k])*frac + 0.5)
+	}
+	return c
+}
+
+// ColorizeOptions 伪彩色参数，与 rs.ColorizerOptions 语义一致
+// 关闭直方图均衡时，按 [MinDistance, MaxDistance]（米）的固定范围映射颜色
+type ColorizeOptions struct {
+	Scheme                ColorScheme `json:"scheme"`
+	HistogramEqualization bool        `json:"histogram_equalization"`
+	MinDistance           float32     `json:"min_distance"`
+	MaxDistance           float32     `json:"max_distance"`
+}
+
+// DefaultColorizeOptions 返回与 librealsense 相同的默认参数
+func DefaultColorizeOptions() ColorizeOptions {
+	return ColorizeOptions{Scheme: ColorSchemeJet, HistogramEqualization: true, MinDistance: 0, MaxDistance: 6}
+}
+
+// Validate 校验参数
+func (o ColorizeOptions) Validate() error {
+	if _, ok := colorMaps[o.Scheme]; !ok {
+		return fmt.Errorf("unknown color scheme %d", o.Scheme)
+	}
+	if o.MinDistance < 0 || o.MinDistance > o.MaxDistance {
+		return fmt.Errorf("invalid colorize range [%g, %g]", o.MinDistance, o.MaxDistance)
+	}
+	return nil
+}
+
+// ColorizeDepth 将 Z16 深度数据渲染为 RGB8 字节流（每像素 3 字节）
+// scale 为深度比例（米/单位），无效深度 (0) 渲染为黑色
+// 输出格式与 rs.Colorizer 的结果一致，可直接替换
+func ColorizeDepth(data []uint16, scale float32, opts ColorizeOptions) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if scale <= 0 {
+		return nil, fmt.Errorf("invalid depth scale %g", scale)
+	}
+
+	out := make([]byte, len(data)*3)
+	cm := colorMaps[opts.Scheme]
+
+	if opts.HistogramEqualization {
+		// 累积直方图：颜色由小于等于该深度的有效像素比例决定
+		hist := make([]uint32, 0x10000)
+		for _, d := range data {
+			hist[d]++
+		}
+		for i := 1; i < len(hist); i++ {
+			hist[i] += hist[i-1]
+		}
+		total := hist[0xFFFF] - hist[0]
+		if total == 0 {
+			return out, nil
+		}
+
+		for i, d := range data {
+			if d == 0 {
+				continue
+			}
+			c := cm.at(float32(hist[d]-hist[0]) / float32(total))
+			out[i*3], out[i*3+1], out[i*3+2] = c[0], c[1], c[2]
+		}
+		return out, nil
+	}
+
+	span := opts.MaxDistance - opts.MinDistance
+	for i, d := range data {
+		if d == 0 {
+			continue
+		}
+		var t float32
+		if span > 0 {
+			t = (float32(d)*scale - opts.MinDistance) / span
+		}
+		c := cm.at(t)
+		out[i*3], out[i*3+1], out[i*3+2] = c[0], c[1], c[2]
+	}
+	return out, nil
+}
+
+// Colorize 将深度图渲染为 *image.RGBA，便于直接叠加 HUD
+func Colorize(img *Image, scale float32, opts ColorizeOptions) (*image.RGBA, error) {
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+
+	rgb, err := ColorizeDepth(img.Pix, scale, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, img.Width, img.Height))
+	for i := 0; i < len(img.Pix); i++ {
+		dst.Pix[i*4+0] = rgb[i*3+0]
+		dst.Pix[i*4+1] = rgb[i*3+1]
+		dst.Pix[i*4+2] = rgb[i*3+2]
+		dst.Pix[i*4+3] = 255
+	}
+	return dst, nil
+}
+
+// SchemeColor 返回配色方案在 t (0-1) 处的颜色，可用于绘制色标
+func SchemeColor(scheme ColorScheme, t float32) ([3]uint8, error) {
+	cm, ok := colorMaps[scheme]
+	if !ok {
+		return [3]uint8{}, fmt.Errorf("unknown color scheme %d", scheme)
+	}
+	return cm.at(t), nil
+}
@@ -0,0 +1,180 @@
+package depth
+
+import (
+	"image"
+	"testing"
+)
+
+func TestSchemeColorEndpoints(t *testing.T) {
+	black, white := [3]uint8{0, 0, 0}, [3]uint8{255, 255, 255}
+	tests := []struct {
+		scheme     ColorScheme
+		start, end [3]uint8
+	}{
+		{ColorSchemeJet, [3]uint8{0, 0, 255}, [3]uint8{50, 0, 0}},
+		{ColorSchemeClassic, [3]uint8{30, 77, 203}, [3]uint8{198, 33, 24}},
+		{ColorSchemeWhiteToBlack, white, black},
+		{ColorSchemeBlackToWhite, black, white},
+		{ColorSchemeBio, [3]uint8{0, 0, 204}, [3]uint8{230, 242, 255}},
+		{ColorSchemeCold, [3]uint8{230, 247, 255}, [3]uint8{0, 5, 15}},
+		{ColorSchemeWarm, [3]uint8{255, 255, 230}, [3]uint8{10, 0, 0}},
+		{ColorSchemeQuantized, white, black},
+		{ColorSchemePattern, white, black},
+		{ColorSchemeHue, [3]uint8{255, 0, 0}, [3]uint8{255, 0, 0}},
+	}
+	for _, tt := range tests {
+		// 超出 0-1 的 t 截断到端点
+		for _, c := range []struct {
+			t    float32
+			want [3]uint8
+		}{{0, tt.start}, {-0.5, tt.start}, {1, tt.end}, {1.5, tt.end}} {
+			got, err := SchemeColor(tt.scheme, c.t)
+			if err != nil {
+				t.Fatalf("scheme %d: %v", tt.scheme, err)
+			}
+			if got != c.want {
+				t.Errorf("scheme %d at %g = %v, want %v", tt.scheme, c.t, got, c.want)
+			}
+		}
+	}
+}
+
+func TestSchemeColorInterpolation(t *testing.T) {
+	tests := []struct {
+		scheme ColorScheme
+		t      float32
+		want   [3]uint8
+	}{
+		// 正好落在中间的控制点上
+		{ColorSchemeJet, 0.5, [3]uint8{255, 255, 0}},
+		{ColorSchemeBlackToWhite, 0.5, [3]uint8{128, 128, 128}},
+		{ColorSchemeBlackToWhite, 0.75, [3]uint8{191, 191, 191}},
+		// 量化为 6 级：0.5 落在第 3 级，即 t=0.6
+		{ColorSchemeQuantized, 0.5, [3]uint8{102, 102, 102}},
+		{ColorSchemeQuantized, 0.55, [3]uint8{102, 102, 102}},
+	}
+	for _, tt := range tests {
+		got, err := SchemeColor(tt.scheme, tt.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("scheme %d at %g = %v, want %v", tt.scheme, tt.t, got, tt.want)
+		}
+	}
+
+	if _, err := SchemeColor(ColorScheme(42), 0.5); err == nil {
+		t.Error("unknown scheme should fail")
+	}
+}
+
+// pixel 返回 RGB8 字节流中第 i 个像素
+func pixel(rgb []byte, i int) [3]uint8 {
+	return [3]uint8{rgb[i*3], rgb[i*3+1], rgb[i*3+2]}
+}
+
+func TestColorizeDepthRange(t *testing.T) {
+	// 固定范围 [1, 3] 米，Jet 方案：近处蓝色，远处深红
+	opts := ColorizeOptions{Scheme: ColorSchemeJet, MinDistance: 1, MaxDistance: 3}
+	data := []uint16{0, 500, 1000, 2000, 3000, 5000}
+	want := [][3]uint8{
+		{0, 0, 0},     // 无效深度为黑色
+		{0, 0, 255},   // 小于最小距离，截断到起点
+		{0, 0, 255},   // 最小距离
+		{255, 255, 0}, // 中点
+		{50, 0, 0},    // 最大距离
+		{50, 0, 0},    // 大于最大距离，截断到终点
+	}
+	out, err := ColorizeDepth(data, DefaultDepthScale, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(data)*3 {
+		t.Fatalf("output has %d bytes, want %d", len(out), len(data)*3)
+	}
+	for i, w := range want {
+		if got := pixel(out, i); got != w {
+			t.Errorf("depth %d: got %v, want %v", data[i], got, w)
+		}
+	}
+}
+
+func TestColorizeDepthHistogram(t *testing.T) {
+	// 颜色由小于等于该深度的有效像素比例决定，与距离范围无关
+	opts := ColorizeOptions{Scheme: ColorSchemeBlackToWhite, HistogramEqualization: true, MinDistance: 0, MaxDistance: 0.01}
+	data := []uint16{0, 100, 100, 200, 9000}
+	want := [][3]uint8{
+		{0, 0, 0},       // 无效深度为黑色，不计入直方图
+		{128, 128, 128}, // 2/4
+		{128, 128, 128},
+		{191, 191, 191}, // 3/4
+		{255, 255, 255}, // 4/4
+	}
+	out, err := ColorizeDepth(data, DefaultDepthScale, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range want {
+		if got := pixel(out, i); got != w {
+			t.Errorf("depth %d: got %v, want %v", data[i], got, w)
+		}
+	}
+
+	// 没有有效像素时整幅图为黑色
+	out, err = ColorizeDepth([]uint16{0, 0, 0}, DefaultDepthScale, DefaultColorizeOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range out {
+		if b != 0 {
+			t.Fatalf("all-zero depth: byte %d = %d, want 0", i, b)
+		}
+	}
+}
+
+func TestColorizeDepthInvalid(t *testing.T) {
+	data := []uint16{1000}
+	tests := []struct {
+		name  string
+		scale float32
+		opts  ColorizeOptions
+	}{
+		{"zero scale", 0, DefaultColorizeOptions()},
+		{"negative scale", -0.001, DefaultColorizeOptions()},
+		{"unknown scheme", DefaultDepthScale, ColorizeOptions{Scheme: 42, MaxDistance: 6}},
+		{"negative min", DefaultDepthScale, ColorizeOptions{MinDistance: -1, MaxDistance: 6}},
+		{"min above max", DefaultDepthScale, ColorizeOptions{MinDistance: 4, MaxDistance: 2}},
+	}
+	for _, tt := range tests {
+		if _, err := ColorizeDepth(data, tt.scale, tt.opts); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestColorize(t *testing.T) {
+	img := imageOf(
+		[]uint16{0, 1000},
+		[]uint16{2000, 3000},
+	)
+	opts := ColorizeOptions{Scheme: ColorSchemeJet, MinDistance: 1, MaxDistance: 3}
+	dst, err := Colorize(img, DefaultDepthScale, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Bounds() != image.Rect(0, 0, 2, 2) {
+		t.Fatalf("bounds = %v, want 2x2", dst.Bounds())
+	}
+	want := [][3]uint8{{0, 0, 0}, {0, 0, 255}, {255, 255, 0}, {50, 0, 0}}
+	for i, w := range want {
+		x, y := i%2, i/2
+		c := dst.RGBAAt(x, y)
+		if got := [3]uint8{c.R, c.G, c.B}; got != w || c.A != 255 {
+			t.Errorf("pixel (%d,%d) = %v alpha %d, want %v alpha 255", x, y, got, c.A, w)
+		}
+	}
+
+	if _, err := Colorize(&Image{Width: 2, Height: 2, Pix: make([]uint16, 3)}, DefaultDepthScale, opts); err == nil {
+		t.Error("mismatched image size should fail")
+	}
+}
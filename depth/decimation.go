@@ -0,0 +1,93 @@
+package depth
+
+import "sort"
+
+// DecimationMode 降采样时的聚合方式
+type DecimationMode int
+
+const (
+	// DecimationAuto 与 librealsense 一致：倍数 2、3 使用中值，4 及以上使用均值
+	DecimationAuto DecimationMode = iota
+	// DecimationMedian 始终使用非零像素的中值
+	DecimationMedian
+	// DecimationMean 始终使用非零像素的均值
+	DecimationMean
+)
+
+// DecimationOptions 降采样参数，与 rs.DecimationFilterOptions 语义一致
+type DecimationOptions struct {
+	Magnitude int            `json:"magnitude"` // 降采样倍数 (1-8)
+	Mode      DecimationMode `json:"mode"`      // 聚合方式，默认与 librealsense 相同
+}
+
+// DefaultDecimationOptions 返回与 librealsense 相同的默认参数
+func DefaultDecimationOptions() DecimationOptions {
+	return DecimationOptions{Magnitude: 2, Mode: DecimationAuto}
+}
+
+// Validate 校验参数范围
+func (o DecimationOptions) Validate() error {
+	if err := checkRange("magnitude", float32(o.Magnitude), 1, 8); err != nil {
+		return err
+	}
+	return checkRange("mode", float32(o.Mode), float32(DecimationAuto), float32(DecimationMean))
+}
+
+// Decimate 按 Magnitude x Magnitude 的窗口降采样
+// 与 librealsense 一致，有效区域为 floor(W/M) x floor(H/M)，输出宽高再向上补齐到 4 的倍数，
+// 补齐的行列为 0（例如 640x480 按 3 倍降采样得到 216x160，有效区域为 213x160）
+// 窗口内的无效像素 (0) 不参与计算，全部无效时输出 0；Magnitude 为 1 时原样拷贝
+func Decimate(src *Image, opts DecimationOptions) (*Image, error) {
+	if err := src.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	m := opts.Magnitude
+	if m == 1 {
+		return src.Clone(), nil
+	}
+
+	useMedian := opts.Mode == DecimationMedian || (opts.Mode == DecimationAuto && m <= 3)
+
+	realW, realH := src.Width/m, src.Height/m
+	dst := NewImage(padTo4(realW), padTo4(realH))
+	window := make([]uint16, 0, m*m)
+
+	for dy := 0; dy < realH; dy++ {
+		for dx := 0; dx < realW; dx++ {
+			window = window[:0]
+			for ky := 0; ky < m; ky++ {
+				row := (dy*m + ky) * src.Width
+				for kx := 0; kx < m; kx++ {
+					if v := src.Pix[row+dx*m+kx]; v != 0 {
+						window = append(window, v)
+					}
+				}
+			}
+			if len(window) == 0 {
+				continue
+			}
+
+			if useMedian {
+				sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+				dst.Pix[dy*dst.Width+dx] = window[len(window)/2]
+			} else {
+				var sum uint32
+				for _, v := range window {
+					sum += uint32(v)
+				}
+				dst.Pix[dy*dst.Width+dx] = uint16(sum / uint32(len(window)))
+			}
+		}
+	}
+
+	return dst, nil
+}
+
+// padTo4 向上补齐到 4 的倍数，与 librealsense 降采样输出的对齐方式相同
+func padTo4(n int) int {
+	return (n + 3) / 4 * 4
+}
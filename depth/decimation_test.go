@@ -0,0 +1,128 @@
+package depth
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecimateSize(t *testing.T) {
+	tests := []struct {
+		width, height, magnitude int
+		wantW, wantH             int
+	}{
+		{640, 480, 2, 320, 240},
+		{640, 480, 3, 216, 160}, // 213 补齐到 216
+		{848, 480, 3, 284, 160}, // 282 补齐到 284
+		{1280, 720, 3, 428, 240},
+		{1280, 720, 5, 256, 144},
+		{64, 48, 3, 24, 16}, // 21 补齐到 24
+		{64, 48, 1, 64, 48},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%dx%d/%d", tt.width, tt.height, tt.magnitude), func(t *testing.T) {
+			out, err := Decimate(NewImage(tt.width, tt.height), DecimationOptions{Magnitude: tt.magnitude})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Width != tt.wantW || out.Height != tt.wantH {
+				t.Errorf("size %dx%d, want %dx%d", out.Width, out.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestDecimateValues(t *testing.T) {
+	src := imageOf(
+		[]uint16{100, 200, 0, 0, 7, 7},
+		[]uint16{300, 400, 0, 500, 7, 7},
+		[]uint16{0, 0, 10, 20, 7, 7},
+		[]uint16{0, 0, 30, 0, 7, 7},
+	)
+	tests := []struct {
+		name string
+		opts DecimationOptions
+		src  *Image
+		want *Image
+	}{
+		{
+			// 中值只统计有效像素，偶数个时取较大的一个；全部无效时为 0；补齐的行列为 0
+			name: "median",
+			opts: DecimationOptions{Magnitude: 2},
+			src:  src,
+			want: imageOf(
+				[]uint16{300, 500, 7, 0},
+				[]uint16{0, 20, 7, 0},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+			),
+		},
+		{
+			// 均值只统计有效像素，整数截断
+			name: "mean",
+			opts: DecimationOptions{Magnitude: 2, Mode: DecimationMean},
+			src:  src,
+			want: imageOf(
+				[]uint16{250, 500, 7, 0},
+				[]uint16{0, 20, 7, 0},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+			),
+		},
+		{
+			// 倍数 4 及以上自动使用均值
+			name: "auto mean",
+			opts: DecimationOptions{Magnitude: 4},
+			src: imageOf(
+				[]uint16{1, 2, 3, 4},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 10},
+			),
+			want: imageOf(
+				[]uint16{4, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+				[]uint16{0, 0, 0, 0},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Decimate(tt.src, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPix(t, out, tt.want)
+		})
+	}
+}
+
+func TestDecimateGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DecimationOptions
+	}{
+		{"decimation_m2.png", DecimationOptions{Magnitude: 2}},
+		{"decimation_m3.png", DecimationOptions{Magnitude: 3}},
+		{"decimation_m5.png", DecimationOptions{Magnitude: 5}},
+		{"decimation_m2_mean.png", DecimationOptions{Magnitude: 2, Mode: DecimationMean}},
+	}
+	src := loadScene(t, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Decimate(src, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, out)
+		})
+	}
+}
+
+func TestDecimateInvalid(t *testing.T) {
+	for _, m := range []int{0, 9} {
+		if _, err := Decimate(NewImage(8, 8), DecimationOptions{Magnitude: m}); err == nil {
+			t.Errorf("magnitude %d: expected error", m)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package depth
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata 中的期望输出")
+
+// sceneFrames 是 golden 测试的输入序列，testdata/scene_<i>.png
+const sceneFrames = 3
+
+// makeScene 生成确定性的合成深度图：倾斜地面、前景方块（边缘深度差大于 SmoothDelta）、
+// 随帧移动的孔洞以及小幅噪声，尺寸 64x48 不能被 3 整除，用于覆盖降采样的补齐
+func makeScene(frame int) *Image {
+	img := NewImage(64, 48)
+	seed := uint32(12345 + frame)
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			seed = seed*1664525 + 1013904223
+			noise := int(seed>>28) - 8
+			v := 1500 + 20*y + noise
+			if x >= 20 && x < 40 && y >= 12 && y < 32 {
+				v = 800 + noise
+			}
+			if (x+3*frame)%17 == 0 || (y > 40 && x < 8+frame) {
+				v = 0
+			}
+			img.Pix[y*img.Width+x] = uint16(v)
+		}
+	}
+	return img
+}
+
+// readPNG16 读取 16 位灰度 PNG
+func readPNG16(t *testing.T, path string) *Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v (run go test -update to create it)", path, err)
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	g, ok := src.(*image.Gray16)
+	if !ok {
+		t.Fatalf("%s is %T, want 16-bit grayscale", path, src)
+	}
+	b := g.Bounds()
+	img := NewImage(b.Dx(), b.Dy())
+	for i := range img.Pix {
+		img.Pix[i] = binary.BigEndian.Uint16(g.Pix[i*2:])
+	}
+	return img
+}
+
+// writePNG16 将深度图写为 16 位灰度 PNG
+func writePNG16(t *testing.T, path string, img *Image) {
+	t.Helper()
+	g := image.NewGray16(image.Rect(0, 0, img.Width, img.Height))
+	for i, v := range img.Pix {
+		binary.BigEndian.PutUint16(g.Pix[i*2:], v)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, g); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadScene 读取第 i 帧输入，-update 时先重新生成
+func loadScene(t *testing.T, i int) *Image {
+	t.Helper()
+	path := filepath.Join("testdata", fmt.Sprintf("scene_%d.png", i))
+	if *update {
+		writePNG16(t, path, makeScene(i))
+	}
+	return readPNG16(t, path)
+}
+
+// checkGolden 将结果与 testdata/name 逐像素比较，-update 时写入
+func checkGolden(t *testing.T, name string, got *Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		writePNG16(t, path, got)
+		return
+	}
+	want := readPNG16(t, path)
+	if got.Width != want.Width || got.Height != want.Height {
+		t.Fatalf("%s: size %dx%d, want %dx%d", name, got.Width, got.Height, want.Width, want.Height)
+	}
+	diff, first := 0, -1
+	for i := range want.Pix {
+		if got.Pix[i] != want.Pix[i] {
+			if first < 0 {
+				first = i
+			}
+			diff++
+		}
+	}
+	if diff > 0 {
+		x, y := first%want.Width, first/want.Width
+		t.Errorf("%s: %d pixels differ, first at (%d,%d): got %d, want %d",
+			name, diff, x, y, got.Pix[first], want.Pix[first])
+	}
+}
+
+// imageOf 由行数据创建深度图，便于书写小型测试用例
+func imageOf(rows ...[]uint16) *Image {
+	img := NewImage(len(rows[0]), len(rows))
+	for y, row := range rows {
+		copy(img.Pix[y*img.Width:], row)
+	}
+	return img
+}
+
+// checkPix 逐像素比较小型测试用例
+func checkPix(t *testing.T, got *Image, want *Image) {
+	t.Helper()
+	if got.Width != want.Width || got.Height != want.Height {
+		t.Fatalf("size %dx%d, want %dx%d", got.Width, got.Height, want.Width, want.Height)
+	}
+	for i := range want.Pix {
+		if got.Pix[i] != want.Pix[i] {
+			t.Fatalf("pixel (%d,%d) = %d, want %d\ngot  %v\nwant %v",
+				i%want.Width, i/want.Width, got.Pix[i], want.Pix[i], got.Pix, want.Pix)
+		}
+	}
+}
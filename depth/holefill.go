@@ -0,0 +1,90 @@
+package depth
+
+// HoleFillingMode 孔洞填充模式，取值与 rs.HoleFillingMode 一致
+type HoleFillingMode int
+
+const (
+	HoleFillFromLeft      HoleFillingMode = 0 // 使用左侧最近的有效像素填充
+	HoleFillFarestAround  HoleFillingMode = 1 // 使用周围最远的有效像素填充（默认）
+	HoleFillNearestAround HoleFillingMode = 2 // 使用周围最近的有效像素填充
+)
+
+// HoleFillingOptions 孔洞填充参数，与 rs.HoleFillingFilterOptions 语义一致
+type HoleFillingOptions struct {
+	Mode HoleFillingMode `json:"mode"` // 填充模式 (0-2)
+}
+
+// DefaultHoleFillingOptions 返回与 librealsense 相同的默认参数
+func DefaultHoleFillingOptions() HoleFillingOptions {
+	return HoleFillingOptions{Mode: HoleFillFarestAround}
+}
+
+// Validate 校验参数范围
+func (o HoleFillingOptions) Validate() error {
+	return checkRange("mode", float32(o.Mode), 0, 2)
+}
+
+// FillHoles 填充深度为 0 的像素，返回新的深度图
+// FromLeft 模式沿行方向扫描，使用左侧最后一个有效值；
+// Around 模式使用上、下、左、右四邻域中的最远/最近有效值（左、上邻域使用已填充的结果）
+func FillHoles(src *Image, opts HoleFillingOptions) (*Image, error) {
+	if err := src.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	w, h := src.Width, src.Height
+	dst := src.Clone()
+
+	if opts.Mode == HoleFillFromLeft {
+		for y := 0; y < h; y++ {
+			row := dst.Pix[y*w : (y+1)*w]
+			var last uint16
+			for x, v := range row {
+				if v != 0 {
+					last = v
+				} else {
+					row[x] = last
+				}
+			}
+		}
+		return dst, nil
+	}
+
+	farest := opts.Mode == HoleFillFarestAround
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if dst.Pix[i] != 0 {
+				continue
+			}
+
+			var best uint16
+			pick := func(v uint16) {
+				if v == 0 {
+					return
+				}
+				if best == 0 || (farest && v > best) || (!farest && v < best) {
+					best = v
+				}
+			}
+			if x > 0 {
+				pick(dst.Pix[i-1])
+			}
+			if y > 0 {
+				pick(dst.Pix[i-w])
+			}
+			if x < w-1 {
+				pick(src.Pix[i+1])
+			}
+			if y < h-1 {
+				pick(src.Pix[i+w])
+			}
+			dst.Pix[i] = best
+		}
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,70 @@
+package depth
+
+import "testing"
+
+func TestFillHolesValues(t *testing.T) {
+	src := imageOf(
+		[]uint16{0, 300, 0},
+		[]uint16{200, 0, 500},
+		[]uint16{0, 400, 0},
+	)
+	tests := []struct {
+		mode HoleFillingMode
+		want *Image
+	}{
+		{
+			// 行首没有左侧有效值时保持 0
+			mode: HoleFillFromLeft,
+			want: imageOf(
+				[]uint16{0, 300, 300},
+				[]uint16{200, 200, 500},
+				[]uint16{0, 400, 400},
+			),
+		},
+		{
+			// 四邻域中最远（最大）的有效值，左、上使用已填充的结果
+			mode: HoleFillFarestAround,
+			want: imageOf(
+				[]uint16{300, 300, 500},
+				[]uint16{200, 500, 500},
+				[]uint16{400, 400, 500},
+			),
+		},
+		{
+			mode: HoleFillNearestAround,
+			want: imageOf(
+				[]uint16{200, 300, 300},
+				[]uint16{200, 200, 500},
+				[]uint16{200, 400, 400},
+			),
+		},
+	}
+	for _, tt := range tests {
+		out, err := FillHoles(src, HoleFillingOptions{Mode: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPix(t, out, tt.want)
+	}
+}
+
+func TestFillHolesGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		mode HoleFillingMode
+	}{
+		{"holefill_left.png", HoleFillFromLeft},
+		{"holefill_farest.png", HoleFillFarestAround},
+		{"holefill_nearest.png", HoleFillNearestAround},
+	}
+	src := loadScene(t, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := FillHoles(src, HoleFillingOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, out)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package depth 提供深度图后处理实现
+// 处理对象是原始 Z16 深度缓冲 ([]uint16)，参数语义与 librealsense 的处理块保持一致，
+// 可用于处理网络传输或文件回放得到的深度数据
+// depth 及在其之上构建的 roi、snapshot、overlay、archive、server、stream 都是纯 Go，
+// 不依赖 librealsense，只有 rs 包及使用它的程序需要 CGO
+package depth
+
+import "fmt"
+
+// DefaultDepthScale 是 D400 系列的默认深度单位（米/单位），帧中没有有效的深度比例时使用
+const DefaultDepthScale float32 = 0.001
+
+// Image 是一帧 Z16 深度图，按行优先存储，0 表示无效深度
+type Image struct {
+	Width  int
+	Height int
+	Pix    []uint16
+}
+
+// NewImage 创建指定尺寸的空深度图
+func NewImage(width, height int) *Image {
+	return &Image{Width: width, Height: height, Pix: make([]uint16, width*height)}
+}
+
+// FromBuffer 使用已有缓冲创建深度图（不拷贝）
+func FromBuffer(pix []uint16, width, height int) (*Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid depth image size %dx%d", width, height)
+	}
+	if len(pix) < width*height {
+		return nil, fmt.Errorf("depth buffer too small: %d < %d", len(pix), width*height)
+	}
+	return &Image{Width: width, Height: height, Pix: pix[:width*height]}, nil
+}
+
+// At 返回 (x, y) 处的深度值，越界返回 0
+func (img *Image) At(x, y int) uint16 {
+	if x < 0 || y < 0 || x >= img.Width || y >= img.Height {
+		return 0
+	}
+	return img.Pix[y*img.Width+x]
+}
+
+// Clone 深拷贝深度图
+func (img *Image) Clone() *Image {
+	out := &Image{Width: img.Width, Height: img.Height, Pix: make([]uint16, len(img.Pix))}
+	copy(out.Pix, img.Pix)
+	return out
+}
+
+// validate 检查尺寸与缓冲是否一致
+func (img *Image) validate() error {
+	if img == nil {
+		return fmt.Errorf("nil depth image")
+	}
+	if img.Width <= 0 || img.Height <= 0 || len(img.Pix) != img.Width*img.Height {
+		return fmt.Errorf("invalid depth image %dx%d with %d pixels", img.Width, img.Height, len(img.Pix))
+	}
+	return nil
+}
+
+// checkRange 校验参数范围，错误信息与 rs 包保持一致
+func checkRange(name string, value, min, max float32) error {
+	if value < min || value > max {
+		return fmt.Errorf("option %s value %g out of range [%g, %g]", name, value, min, max)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package depth
+
+import "math"
+
+// Distortion 畸变模型，取值与 rs.Distortion 一致
+type Distortion int
+
+const (
+	DistortionNone                 Distortion = 0
+	DistortionModifiedBrownConrady Distortion = 1
+	DistortionInverseBrownConrady  Distortion = 2
+	DistortionFTheta               Distortion = 3
+	DistortionBrownConrady         Distortion = 4
+	DistortionKannalaBrandt4       Distortion = 5
+)
+
+// float32Epsilon 对应 C 的 FLT_EPSILON
+const float32Epsilon = 1.1920929e-07
+
+// Intrinsics 相机内参，字段语义与 rs.Intrinsics 一致
+type Intrinsics struct {
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	PPX    float32    `json:"ppx"`
+	PPY    float32    `json:"ppy"`
+	FX     float32    `json:"fx"`
+	FY     float32    `json:"fy"`
+	Model  Distortion `json:"model"`
+	Coeffs [5]float32 `json:"coeffs"`
+}
+
+// Deproject 将像素 (px, py) 和距离 depth（米）反投影为相机坐标系中的 3D 点（米）
+// 算法与 librealsense 的 rs2_deproject_pixel_to_point 一致；
+// 坐标系：X 向右，Y 向下，Z 向前
+func (in Intrinsics) Deproject(px, py, depth float32) [3]float32 {
+	x, y := in.undistort(px, py)
+	return [3]float32{depth * x, depth * y, depth}
+}
+
+// Ray 返回像素在深度为 1 米处的反投影方向 (x, y, 1)
+// 对同一内参可以预先计算，之后乘以深度即得 3D 点
+func (in Intrinsics) Ray(px, py float32) [3]float32 {
+	x, y := in.undistort(px, py)
+	return [3]float32{x, y, 1}
+}
+
+// undistort 将像素坐标转换为去畸变后的归一化坐标
+func (in Intrinsics) undistort(px, py float32) (float32, float32) {
+	x := (px - in.PPX) / in.FX
+	y := (py - in.PPY) / in.FY
+	c := in.Coeffs
+	xo, yo := x, y
+
+	switch in.Model {
+	case DistortionInverseBrownConrady:
+		for i := 0; i < 10; i++ {
+			r2 := x*x + y*y
+			icdist := 1 / (1 + ((c[4]*r2+c[1])*r2+c[0])*r2)
+			xq, yq := x/icdist, y/icdist
+			dx := 2*c[2]*xq*yq + c[3]*(r2+2*xq*xq)
+			dy := 2*c[3]*xq*yq + c[2]*(r2+2*yq*yq)
+			x = (xo - dx) * icdist
+			y = (yo - dy) * icdist
+		}
+	case DistortionBrownConrady:
+		for i := 0; i < 10; i++ {
+			r2 := x*x + y*y
+			icdist := 1 / (1 + ((c[4]*r2+c[1])*r2+c[0])*r2)
+			dx := 2*c[2]*x*y + c[3]*(r2+2*x*x)
+			dy := 2*c[3]*x*y + c[2]*(r2+2*y*y)
+			x = (xo - dx) * icdist
+			y = (yo - dy) * icdist
+		}
+	case DistortionKannalaBrandt4:
+		rd := float32(math.Sqrt(float64(x*x + y*y)))
+		if rd < float32Epsilon {
+			rd = float32Epsilon
+		}
+		theta := rd
+		theta2 := rd * rd
+		for i := 0; i < 4; i++ {
+			f := theta*(1+theta2*(c[0]+theta2*(c[1]+theta2*(c[2]+theta2*c[3])))) - rd
+			if math.Abs(float64(f)) < float32Epsilon {
+				break
+			}
+			df := 1 + theta2*(3*c[0]+theta2*(5*c[1]+theta2*(7*c[2]+9*theta2*c[3])))
+			theta -= f / df
+			theta2 = theta * theta
+		}
+		r := float32(math.Tan(float64(theta)))
+		x *= r / rd
+		y *= r / rd
+	case DistortionFTheta:
+		rd := float32(math.Sqrt(float64(x*x + y*y)))
+		if rd < float32Epsilon {
+			rd = float32Epsilon
+		}
+		r := float32(math.Tan(float64(c[0]*rd)) / math.Atan(2*math.Tan(float64(c[0])/2)))
+		x *= r / rd
+		y *= r / rd
+	}
+	return x, y
+}
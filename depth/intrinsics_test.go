@@ -0,0 +1,100 @@
+package depth
+
+import (
+	"math"
+	"testing"
+)
+
+var testIntrinsics = Intrinsics{Width: 640, Height: 480, PPX: 320, PPY: 240, FX: 500, FY: 400}
+
+func TestDeprojectPinhole(t *testing.T) {
+	tests := []struct {
+		px, py, d float32
+		want      [3]float32
+	}{
+		{320, 240, 1, [3]float32{0, 0, 1}},     // 主点
+		{570, 240, 2, [3]float32{1, 0, 2}},     // (570-320)/500*2
+		{320, 440, 4, [3]float32{0, 2, 4}},     // (440-240)/400*4，Y 向下
+		{70, 40, 1, [3]float32{-0.5, -0.5, 1}}, // 左上
+		{123, 45, 0, [3]float32{0, 0, 0}},      // 深度为 0 得到原点
+	}
+	for _, tt := range tests {
+		got := testIntrinsics.Deproject(tt.px, tt.py, tt.d)
+		if !near3(got, tt.want, 1e-6) {
+			t.Errorf("Deproject(%g, %g, %g) = %v, want %v", tt.px, tt.py, tt.d, got, tt.want)
+		}
+		// Ray 乘以深度与 Deproject 一致
+		r := testIntrinsics.Ray(tt.px, tt.py)
+		if r[2] != 1 || !near3([3]float32{r[0] * tt.d, r[1] * tt.d, tt.d}, got, 1e-6) {
+			t.Errorf("Ray(%g, %g) = %v inconsistent with Deproject", tt.px, tt.py, r)
+		}
+	}
+}
+
+func TestDeprojectZeroCoefficients(t *testing.T) {
+	// 系数全为 0 时 Brown-Conrady 两种模型都退化为针孔模型
+	for _, m := range []Distortion{DistortionBrownConrady, DistortionInverseBrownConrady} {
+		in := testIntrinsics
+		in.Model = m
+		if got, want := in.Deproject(500, 100, 2), testIntrinsics.Deproject(500, 100, 2); !near3(got, want, 1e-5) {
+			t.Errorf("model %d: %v, want %v", m, got, want)
+		}
+	}
+}
+
+func TestDeprojectKannalaBrandtEquidistant(t *testing.T) {
+	// 系数全为 0 的 Kannala-Brandt 是等距鱼眼：归一化半径 rd 即入射角，反投影半径为 tan(rd)
+	in := testIntrinsics
+	in.Model = DistortionKannalaBrandt4
+	x, y := (500.0-320)/500, (100.0-240)/400
+	rd := math.Hypot(x, y)
+	s := math.Tan(rd) / rd
+	want := [3]float32{float32(2 * x * s), float32(2 * y * s), 2}
+	if got := in.Deproject(500, 100, 2); !near3(got, want, 1e-5) {
+		t.Errorf("Deproject = %v, want %v", got, want)
+	}
+}
+
+// projectModifiedBrownConrady 是 librealsense rs2_project_point_to_pixel 的 Modified Brown-Conrady 分支，
+// 与 Inverse Brown-Conrady 反投影互逆
+func projectModifiedBrownConrady(in Intrinsics, p [3]float64) (float64, float64) {
+	x, y := p[0]/p[2], p[1]/p[2]
+	c := in.Coeffs
+	r2 := x*x + y*y
+	f := 1 + float64(c[0])*r2 + float64(c[1])*r2*r2 + float64(c[4])*r2*r2*r2
+	x, y = x*f, y*f
+	dx := x + 2*float64(c[2])*x*y + float64(c[3])*(r2+2*x*x)
+	dy := y + 2*float64(c[3])*x*y + float64(c[2])*(r2+2*y*y)
+	return dx*float64(in.FX) + float64(in.PPX), dy*float64(in.FY) + float64(in.PPY)
+}
+
+func TestDeprojectInverseBrownConradyRoundTrip(t *testing.T) {
+	in := testIntrinsics
+	in.Model = DistortionInverseBrownConrady
+	in.Coeffs = [5]float32{0.1, -0.05, 0.001, 0.002, 0.01}
+	for _, px := range []float32{20, 200, 320, 450, 620} {
+		for _, py := range []float32{10, 240, 470} {
+			p := in.Deproject(px, py, 1.5)
+			if p[2] != 1.5 {
+				t.Fatalf("depth %g, want 1.5", p[2])
+			}
+			x, y := projectModifiedBrownConrady(in, [3]float64{float64(p[0]), float64(p[1]), float64(p[2])})
+			if math.Abs(x-float64(px)) > 0.01 || math.Abs(y-float64(py)) > 0.01 {
+				t.Errorf("(%g, %g) -> %v -> (%.3f, %.3f)", px, py, p, x, y)
+			}
+		}
+	}
+	// 畸变确实改变了结果
+	if near3(in.Deproject(20, 10, 1), testIntrinsics.Deproject(20, 10, 1), 1e-3) {
+		t.Error("distortion coefficients had no effect")
+	}
+}
+
+func near3(a, b [3]float32, eps float64) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > eps {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package depth
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// referenceDir 保存 librealsense 处理同一组输入得到的参考输出，
+// 由 scripts/gen_depth_references.py 生成，说明见该目录下的 README.md
+var referenceDir = filepath.Join("testdata", "librealsense")
+
+// tolerance 是与 librealsense 参考输出比较时允许的差异
+type tolerance struct {
+	MaxDiff     int     // 双方都有效的像素允许的最大差值（深度单位）
+	MaxMismatch float64 // 超出 MaxDiff 或有效性不同（一方为 0）的像素占比上限
+}
+
+// exact 表示逐像素一致
+var exact = tolerance{}
+
+// referenceCase 是一个对照用例，参数与 scripts/gen_depth_references.py 中的 CASES 一一对应
+type referenceCase struct {
+	name string // 参考文件为 referenceDir/<name>.png
+	tol  tolerance
+	run  func(scene []*Image) (*Image, error)
+}
+
+var referenceCases = []referenceCase{
+	{"decimation_m2", exact, decimateWith(2)},
+	{"decimation_m3", exact, decimateWith(3)},
+	{"decimation_m5", exact, decimateWith(5)},
+	{"holefill_left", exact, fillWith(HoleFillFromLeft)},
+	{"holefill_farest", exact, fillWith(HoleFillFarestAround)},
+	{"holefill_nearest", exact, fillWith(HoleFillNearestAround)},
+	// 空间和时间过滤器是近似实现，容差见 testdata/librealsense/README.md
+	{"spatial_default", tolerance{MaxDiff: 4, MaxMismatch: 0.02}, func(s []*Image) (*Image, error) {
+		return Spatial(s[0], DefaultSpatialOptions())
+	}},
+	{"spatial_m3_fill4", tolerance{MaxDiff: 4, MaxMismatch: 0.05}, func(s []*Image) (*Image, error) {
+		return Spatial(s[0], SpatialOptions{Magnitude: 3, SmoothAlpha: 0.4, SmoothDelta: 30, HolesFill: 2})
+	}},
+	{"temporal_default", tolerance{MaxDiff: 2, MaxMismatch: 0.01}, func(s []*Image) (*Image, error) {
+		// 依次处理全部输入帧，比较最后一帧的输出
+		f, err := NewTemporalFilter(DefaultTemporalOptions())
+		if err != nil {
+			return nil, err
+		}
+		var out *Image
+		for _, img := range s {
+			if out, err = f.Process(img); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}},
+}
+
+func decimateWith(m int) func([]*Image) (*Image, error) {
+	return func(s []*Image) (*Image, error) {
+		return Decimate(s[0], DecimationOptions{Magnitude: m})
+	}
+}
+
+func fillWith(mode HoleFillingMode) func([]*Image) (*Image, error) {
+	return func(s []*Image) (*Image, error) {
+		return FillHoles(s[0], HoleFillingOptions{Mode: mode})
+	}
+}
+
+// referenceDiff 是与参考输出的差异统计
+type referenceDiff struct {
+	Pixels   int // 参与比较的像素数
+	Mismatch int // 超出容差的像素数
+	Validity int // 其中一方为 0、另一方不为 0 的像素数
+	MaxDiff  int // 双方都有效的像素的最大差值
+	MeanDiff float64
+}
+
+func (d referenceDiff) String() string {
+	return fmt.Sprintf("%d/%d pixels out of tolerance (%.2f%%), %d validity flips, max diff %d, mean diff %.3f",
+		d.Mismatch, d.Pixels, 100*float64(d.Mismatch)/float64(d.Pixels), d.Validity, d.MaxDiff, d.MeanDiff)
+}
+
+// compareReference 按容差比较 got 与参考输出
+func compareReference(got, want *Image, tol tolerance) referenceDiff {
+	d := referenceDiff{Pixels: len(want.Pix)}
+	var sum float64
+	valid := 0
+	for i, w := range want.Pix {
+		g := got.Pix[i]
+		if (g == 0) != (w == 0) {
+			d.Validity++
+			d.Mismatch++
+			continue
+		}
+		diff := int(g) - int(w)
+		if diff < 0 {
+			diff = -diff
+		}
+		d.MaxDiff = max(d.MaxDiff, diff)
+		if diff > tol.MaxDiff {
+			d.Mismatch++
+		}
+		if w != 0 {
+			sum += float64(diff)
+			valid++
+		}
+	}
+	if valid > 0 {
+		d.MeanDiff = sum / float64(valid)
+	}
+	return d
+}
+
+func TestLibrealsenseReference(t *testing.T) {
+	scene := make([]*Image, sceneFrames)
+	for i := range scene {
+		scene[i] = loadScene(t, i)
+	}
+	for _, tc := range referenceCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(referenceDir, tc.name+".png")
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				t.Skipf("%s not generated; run scripts/gen_depth_references.py on a machine with pyrealsense2", path)
+			}
+			want := readPNG16(t, path)
+			got, err := tc.run(scene)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Width != want.Width || got.Height != want.Height {
+				t.Fatalf("size %dx%d, librealsense %dx%d", got.Width, got.Height, want.Width, want.Height)
+			}
+			d := compareReference(got, want, tc.tol)
+			t.Logf("%s: %v", tc.name, d)
+			if float64(d.Mismatch) > math.Floor(tc.tol.MaxMismatch*float64(d.Pixels)) {
+				t.Errorf("differs from librealsense beyond tolerance %+v: %v", tc.tol, d)
+			}
+		})
+	}
+}
+
+func TestCompareReference(t *testing.T) {
+	want := imageOf([]uint16{0, 100, 200, 300})
+	tests := []struct {
+		got  *Image
+		tol  tolerance
+		want referenceDiff
+	}{
+		{imageOf([]uint16{0, 100, 200, 300}), exact, referenceDiff{Pixels: 4}},
+		{imageOf([]uint16{0, 101, 198, 300}), exact, referenceDiff{Pixels: 4, Mismatch: 2, MaxDiff: 2, MeanDiff: 1}},
+		{imageOf([]uint16{0, 101, 198, 300}), tolerance{MaxDiff: 2}, referenceDiff{Pixels: 4, MaxDiff: 2, MeanDiff: 1}},
+		// 有效性不同总是计为不一致，不计入差值
+		{imageOf([]uint16{5, 0, 200, 300}), tolerance{MaxDiff: 1000}, referenceDiff{Pixels: 4, Mismatch: 2, Validity: 2}},
+	}
+	for i, tt := range tests {
+		if got := compareReference(tt.got, want, tt.tol); got != tt.want {
+			t.Errorf("case %d: %+v, want %+v", i, got, tt.want)
+		}
+	}
+}
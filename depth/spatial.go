@@ -0,0 +1,121 @@
+package depth
+
+import "math"
+
+// SpatialOptions 空间过滤参数，与 rs.SpatialFilterOptions 语义一致
+type SpatialOptions struct {
+	Magnitude   int     `json:"magnitude"`    // 迭代次数 (1-5)
+	SmoothAlpha float32 `json:"smooth_alpha"` // 平滑系数 (0.25-1)，越小越平滑
+	SmoothDelta float32 `json:"smooth_delta"` // 边缘阈值 (1-50)，相邻深度差超过该值视为边缘，不做平滑
+	HolesFill   int     `json:"holes_fill"`   // 孔洞填充半径 (0=关闭, 1=2px, 2=4px, 3=8px, 4=16px, 5=无限)
+}
+
+// DefaultSpatialOptions 返回与 librealsense 相同的默认参数
+func DefaultSpatialOptions() SpatialOptions {
+	return SpatialOptions{Magnitude: 2, SmoothAlpha: 0.5, SmoothDelta: 20, HolesFill: 0}
+}
+
+// Validate 校验参数范围
+func (o SpatialOptions) Validate() error {
+	if err := checkRange("magnitude", float32(o.Magnitude), 1, 5); err != nil {
+		return err
+	}
+	if err := checkRange("smooth_alpha", o.SmoothAlpha, 0.25, 1); err != nil {
+		return err
+	}
+	if err := checkRange("smooth_delta", o.SmoothDelta, 1, 50); err != nil {
+		return err
+	}
+	return checkRange("holes_fill", float32(o.HolesFill), 0, 5)
+}
+
+// holesFillRadius 将 HolesFill 档位转换为像素半径，-1 表示无限
+func holesFillRadius(level int) int {
+	switch level {
+	case 0:
+		return 0
+	case 5:
+		return -1
+	default:
+		return 1 << uint(level) // 1=2px, 2=4px, 3=8px, 4=16px
+	}
+}
+
+// Spatial 对深度图做边缘保持的递归平滑（domain transform），返回新的深度图
+// 每次迭代依次进行左右、右左、上下、下上四个方向的一阶递归滤波；
+// 相邻像素均有效且深度差小于 SmoothDelta 时才混合，从而保留物体边缘
+func Spatial(src *Image, opts SpatialOptions) (*Image, error) {
+	if err := src.validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := make([]float32, len(src.Pix))
+	for i, v := range src.Pix {
+		buf[i] = float32(v)
+	}
+
+	w, h := src.Width, src.Height
+	radius := holesFillRadius(opts.HolesFill)
+
+	for it := 0; it < opts.Magnitude; it++ {
+		for y := 0; y < h; y++ {
+			// 孔洞填充只在第一次左到右扫描时进行，避免重复扩散
+			fill := 0
+			if it == 0 {
+				fill = radius
+			}
+			smoothLine(buf, y*w, 1, w, opts.SmoothAlpha, opts.SmoothDelta, fill)
+			smoothLine(buf, y*w+w-1, -1, w, opts.SmoothAlpha, opts.SmoothDelta, 0)
+		}
+		for x := 0; x < w; x++ {
+			smoothLine(buf, x, w, h, opts.SmoothAlpha, opts.SmoothDelta, 0)
+			smoothLine(buf, (h-1)*w+x, -w, h, opts.SmoothAlpha, opts.SmoothDelta, 0)
+		}
+	}
+
+	dst := NewImage(w, h)
+	for i, v := range buf {
+		dst.Pix[i] = uint16(math.Round(float64(v)))
+	}
+	return dst, nil
+}
+
+// smoothLine 沿一条线做一阶递归滤波
+// start 为起点下标，step 为步长（可为负），n 为像素数
+// fill 为孔洞填充半径（0 关闭，-1 无限）
+func smoothLine(buf []float32, start, step, n int, alpha, delta float32, fill int) {
+	prev := buf[start]
+	gap := 0
+	idx := start
+
+	for i := 1; i < n; i++ {
+		idx += step
+		cur := buf[idx]
+
+		switch {
+		case cur > 0 && prev > 0:
+			diff := cur - prev
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff < delta {
+				cur = alpha*cur + (1-alpha)*prev
+				buf[idx] = cur
+			}
+			gap = 0
+		case cur == 0 && prev > 0 && fill != 0:
+			if fill < 0 || gap < fill {
+				buf[idx] = prev
+				cur = prev
+				gap++
+			}
+		default:
+			gap = 0
+		}
+
+		prev = cur
+	}
+}
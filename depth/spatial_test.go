@@ -0,0 +1,76 @@
+package depth
+
+import "testing"
+
+func TestSpatialValues(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SpatialOptions
+		src  *Image
+		want *Image
+	}{
+		{
+			name: "constant",
+			opts: DefaultSpatialOptions(),
+			src:  imageOf([]uint16{500, 500, 500}, []uint16{500, 500, 500}),
+			want: imageOf([]uint16{500, 500, 500}, []uint16{500, 500, 500}),
+		},
+		{
+			// 深度差不小于 SmoothDelta 视为边缘，两侧都不平滑；无效像素保持 0
+			name: "edge",
+			opts: SpatialOptions{Magnitude: 1, SmoothAlpha: 0.5, SmoothDelta: 20, HolesFill: 0},
+			src:  imageOf([]uint16{100, 100, 200, 200, 0}),
+			want: imageOf([]uint16{100, 100, 200, 200, 0}),
+		},
+		{
+			// 左到右：110 与 100 混合为 105，孔洞用 105 填充 2 个像素；右到左：100 与 105 混合为 102.5
+			name: "holes fill 2px",
+			opts: SpatialOptions{Magnitude: 1, SmoothAlpha: 0.5, SmoothDelta: 20, HolesFill: 1},
+			src:  imageOf([]uint16{100, 110, 0, 0, 0}),
+			want: imageOf([]uint16{103, 105, 105, 105, 0}),
+		},
+		{
+			name: "holes fill unlimited",
+			opts: SpatialOptions{Magnitude: 1, SmoothAlpha: 1, SmoothDelta: 20, HolesFill: 5},
+			src:  imageOf([]uint16{100, 0, 0, 0, 0}),
+			want: imageOf([]uint16{100, 100, 100, 100, 100}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Spatial(tt.src, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPix(t, out, tt.want)
+		})
+	}
+}
+
+func TestSpatialGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SpatialOptions
+	}{
+		{"spatial_default.png", DefaultSpatialOptions()},
+		{"spatial_m3_fill4.png", SpatialOptions{Magnitude: 3, SmoothAlpha: 0.4, SmoothDelta: 30, HolesFill: 2}},
+	}
+	src := loadScene(t, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Spatial(src, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, out)
+		})
+	}
+}
+
+func TestSpatialInvalid(t *testing.T) {
+	opts := DefaultSpatialOptions()
+	opts.SmoothAlpha = 0.1
+	if _, err := Spatial(NewImage(4, 4), opts); err == nil {
+		t.Error("expected error for smooth_alpha 0.1")
+	}
+}
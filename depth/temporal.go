@@ -0,0 +1,168 @@
+package depth
+
+import (
+	"math"
+	"math/bits"
+)
+
+// PersistencyMode 时间过滤的持续性模式，取值与 rs.PersistencyMode 一致
+type PersistencyMode int
+
+const (
+	PersistencyDisabled     PersistencyMode = 0 // 不使用历史数据填充
+	PersistencyValid8of8    PersistencyMode = 1 // 最近 8 帧均有效
+	PersistencyValid2of3    PersistencyMode = 2 // 最近 3 帧中 2 帧有效
+	PersistencyValid2of4    PersistencyMode = 3 // 最近 4 帧中 2 帧有效（默认）
+	PersistencyValid2of8    PersistencyMode = 4 // 最近 8 帧中 2 帧有效
+	PersistencyValid1of2    PersistencyMode = 5 // 最近 2 帧中 1 帧有效
+	PersistencyValid1of5    PersistencyMode = 6 // 最近 5 帧中 1 帧有效
+	PersistencyValid1of8    PersistencyMode = 7 // 最近 8 帧中 1 帧有效
+	PersistencyIndefinitely PersistencyMode = 8 // 始终使用最后一次有效值
+)
+
+// persistencyRules 每种模式对应的 (最少有效帧数, 观察窗口)
+var persistencyRules = map[PersistencyMode][2]int{
+	PersistencyValid8of8: {8, 8},
+	PersistencyValid2of3: {2, 3},
+	PersistencyValid2of4: {2, 4},
+	PersistencyValid2of8: {2, 8},
+	PersistencyValid1of2: {1, 2},
+	PersistencyValid1of5: {1, 5},
+	PersistencyValid1of8: {1, 8},
+}
+
+// TemporalOptions 时间过滤参数，与 rs.TemporalFilterOptions 语义一致
+type TemporalOptions struct {
+	Alpha           float32         `json:"alpha"`            // 平滑系数 (0-1)，越小历史权重越大
+	Delta           float32         `json:"delta"`            // 边缘阈值 (1-100)，与上一帧深度差超过该值时不做平滑
+	PersistencyMode PersistencyMode `json:"persistency_mode"` // 当前帧无效时使用历史值的条件 (0-8)
+}
+
+// DefaultTemporalOptions 返回与 librealsense 相同的默认参数
+func DefaultTemporalOptions() TemporalOptions {
+	return TemporalOptions{Alpha: 0.4, Delta: 20, PersistencyMode: PersistencyValid2of4}
+}
+
+// Validate 校验参数范围
+func (o TemporalOptions) Validate() error {
+	if err := checkRange("alpha", o.Alpha, 0, 1); err != nil {
+		return err
+	}
+	if err := checkRange("delta", o.Delta, 1, 100); err != nil {
+		return err
+	}
+	return checkRange("persistency_mode", float32(o.PersistencyMode), 0, 8)
+}
+
+// TemporalFilter 是有状态的时间过滤器，需要按帧顺序调用 Process
+// 分辨率变化时会自动重置历史
+type TemporalFilter struct {
+	opts    TemporalOptions
+	last    []float32 // 上一帧的输出
+	history []uint16  // 每个像素最近若干帧的有效位，最低位为最新一帧
+	width   int
+	height  int
+}
+
+// NewTemporalFilter 创建时间过滤器
+func NewTemporalFilter(opts TemporalOptions) (*TemporalFilter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &TemporalFilter{opts: opts}, nil
+}
+
+// Options 返回当前参数
+func (t *TemporalFilter) Options() TemporalOptions {
+	return t.opts
+}
+
+// SetOptions 修改参数，历史数据保留
+func (t *TemporalFilter) SetOptions(opts TemporalOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	t.opts = opts
+	return nil
+}
+
+// Reset 清空历史，例如切换场景或跳帧后调用
+func (t *TemporalFilter) Reset() {
+	t.last = nil
+	t.history = nil
+	t.width, t.height = 0, 0
+}
+
+// Process 处理一帧，返回新的深度图
+func (t *TemporalFilter) Process(src *Image) (*Image, error) {
+	if err := src.validate(); err != nil {
+		return nil, err
+	}
+
+	dst := NewImage(src.Width, src.Height)
+
+	// 第一帧或分辨率变化：直接输出并建立历史
+	if t.last == nil || t.width != src.Width || t.height != src.Height {
+		t.width, t.height = src.Width, src.Height
+		t.last = make([]float32, len(src.Pix))
+		t.history = make([]uint16, len(src.Pix))
+		for i, v := range src.Pix {
+			t.last[i] = float32(v)
+			if v != 0 {
+				t.history[i] = 1
+			}
+		}
+		copy(dst.Pix, src.Pix)
+		return dst, nil
+	}
+
+	alpha, delta := t.opts.Alpha, t.opts.Delta
+	for i, v := range src.Pix {
+		cur := float32(v)
+		prev := t.last[i]
+		valid := v != 0
+		t.history[i] <<= 1
+		if valid {
+			t.history[i] |= 1
+		}
+
+		var out float32
+		switch {
+		case valid && prev > 0:
+			diff := cur - prev
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff < delta {
+				out = alpha*cur + (1-alpha)*prev
+			} else {
+				out = cur
+			}
+		case valid:
+			out = cur
+		case prev > 0 && t.persist(t.history[i]):
+			out = prev
+		}
+
+		t.last[i] = out
+		dst.Pix[i] = uint16(math.Round(float64(out)))
+	}
+
+	return dst, nil
+}
+
+// persist 判断当前帧无效时是否沿用历史值
+// history 的最低位是当前帧（无效，为 0），判断基于之前的 window 帧
+func (t *TemporalFilter) persist(history uint16) bool {
+	switch t.opts.PersistencyMode {
+	case PersistencyDisabled:
+		return false
+	case PersistencyIndefinitely:
+		return true
+	}
+
+	rule := persistencyRules[t.opts.PersistencyMode]
+	need, window := rule[0], rule[1]
+	mask := uint16(1<<uint(window)) - 1
+	return bits.OnesCount16((history>>1)&mask) >= need
+}
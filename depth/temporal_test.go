@@ -0,0 +1,101 @@
+package depth
+
+import (
+	"fmt"
+	"testing"
+)
+
+// runTemporal 依次处理单像素序列，返回每帧的输出
+func runTemporal(t *testing.T, opts TemporalOptions, seq []uint16) []uint16 {
+	t.Helper()
+	f, err := NewTemporalFilter(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]uint16, len(seq))
+	for i, v := range seq {
+		img, err := f.Process(imageOf([]uint16{v}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = img.Pix[0]
+	}
+	return out
+}
+
+func TestTemporalValues(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TemporalOptions
+		seq  []uint16
+		want []uint16
+	}{
+		{
+			// 第一帧原样输出；之后 out = alpha*cur + (1-alpha)*prev
+			name: "smoothing",
+			opts: TemporalOptions{Alpha: 0.5, Delta: 20, PersistencyMode: PersistencyDisabled},
+			seq:  []uint16{100, 110, 110},
+			want: []uint16{100, 105, 108}, // 107.5 四舍五入
+		},
+		{
+			// 深度差不小于 Delta 视为运动，直接采用当前值
+			name: "motion",
+			opts: TemporalOptions{Alpha: 0.5, Delta: 20, PersistencyMode: PersistencyDisabled},
+			seq:  []uint16{100, 300, 0},
+			want: []uint16{100, 300, 0},
+		},
+		{
+			// 2/4：之前 4 帧中至少 2 帧有效才沿用历史值
+			name: "valid 2 of 4",
+			opts: TemporalOptions{Alpha: 1, Delta: 20, PersistencyMode: PersistencyValid2of4},
+			seq:  []uint16{100, 0, 100, 0, 0, 0},
+			want: []uint16{100, 0, 100, 100, 100, 0},
+		},
+		{
+			name: "valid 1 of 2",
+			opts: TemporalOptions{Alpha: 1, Delta: 20, PersistencyMode: PersistencyValid1of2},
+			seq:  []uint16{100, 0, 0, 0},
+			want: []uint16{100, 100, 100, 0},
+		},
+		{
+			name: "indefinitely",
+			opts: TemporalOptions{Alpha: 1, Delta: 20, PersistencyMode: PersistencyIndefinitely},
+			seq:  []uint16{100, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			want: []uint16{100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := runTemporal(t, tt.opts, tt.seq)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("outputs %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemporalResize(t *testing.T) {
+	f, _ := NewTemporalFilter(DefaultTemporalOptions())
+	f.Process(imageOf([]uint16{100, 100}))
+	out, err := f.Process(imageOf([]uint16{300}, []uint16{300}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPix(t, out, imageOf([]uint16{300}, []uint16{300})) // 分辨率变化后重新开始
+}
+
+func TestTemporalGolden(t *testing.T) {
+	f, err := NewTemporalFilter(DefaultTemporalOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < sceneFrames; i++ {
+		out, err := f.Process(loadScene(t, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, fmt.Sprintf("temporal_default_%d.png", i), out)
+	}
+}
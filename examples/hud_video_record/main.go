@@ -3,8 +3,6 @@ package main
 import (
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
 	"image/png"
 	"log"
 	"os"
@@ -12,10 +10,10 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/overlay"
+	"github.com/tianfei212/jetson-rs-middleware/record"
 	"github.com/tianfei212/jetson-rs-middleware/rs"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
-	"golang.org/x/image/math/fixed"
 )
 
 // Config
@@ -58,7 +56,13 @@ func main() {
 	defer pipeline.Stop()
 
 	// 初始化 Colorizer (深度 -> 伪彩色)
-	colorizer, err := rs.NewColorizer()
+	// 使用固定距离范围（关闭直方图均衡），使 HUD 色标与颜色一一对应
+	legendOpts := depth.ColorizeOptions{Scheme: depth.ColorSchemeJet, MinDistance: 0.3, MaxDistance: 4.0}
+	colorizer, err := rs.NewColorizer(rs.WithFilterOptions(rs.ColorizerOptions{
+		Scheme:      rs.ColorScheme(legendOpts.Scheme),
+		MinDistance: legendOpts.MinDistance,
+		MaxDistance: legendOpts.MaxDistance,
+	}))
 	if err != nil {
 		log.Fatalf("Failed to create colorizer: %v", err)
 	}
@@ -69,7 +73,15 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create align: %v", err)
 	}
-	defer align.Close() // Assuming Align has Close method (checked previously)
+	defer align.Close()
+
+	// 设备句柄用于读取温度遥测
+	dev, err := pipeline.GetDevice()
+	if err != nil {
+		log.Printf("Warning: Failed to get device: %v", err)
+	} else {
+		defer dev.Close()
+	}
 
 	// 准备输出目录
 	outputDir := "examples/output"
@@ -77,40 +89,48 @@ func main() {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	// 2. 准备 FFmpeg 录制
-	// 录制 output.mp4, 30fps, RGB24 输入
-	outputVideoPath := filepath.Join(outputDir, "output.mp4")
-	ffmpegCmd := exec.Command("ffmpeg",
-		"-y", // 覆盖输出文件
-		"-f", "rawvideo",
-		"-vcodec", "rawvideo",
-		"-s", fmt.Sprintf("%dx%d", Width, Height),
-		"-pix_fmt", "rgba", // 使用 RGBA 输入方便处理
-		"-r", fmt.Sprintf("%d", FPS),
-		"-i", "-", // 从 stdin 读取
-		"-c:v", "libx264",
-		"-preset", "ultrafast",
-		"-pix_fmt", "yuv420p",
-		outputVideoPath,
-	)
-
-	ffmpegIn, err := ffmpegCmd.StdinPipe()
-	if err != nil {
-		log.Fatalf("Failed to get ffmpeg stdin: %v", err)
+	// 2. 准备录制
+	// 优先使用 ffmpeg (H.264)，未安装时退回纯 Go 的 MJPEG-AVI
+	encoder := record.EncoderFFmpeg
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		log.Printf("ffmpeg not found, falling back to MJPEG-AVI")
+		encoder = record.EncoderMJPEG
 	}
-
-	// 启动 ffmpeg
-	if err := ffmpegCmd.Start(); err != nil {
-		log.Fatalf("Failed to start ffmpeg: %v", err)
+	recorder, err := record.NewRecorder(Width, Height, record.Options{
+		Dir:     outputDir,
+		Prefix:  "output",
+		Encoder: encoder,
+		FPS:     FPS,
+		FFmpeg:  record.FFmpegOptions{Codec: "libx264", Preset: "ultrafast"},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create recorder: %v", err)
 	}
 	defer func() {
-		ffmpegIn.Close()
-		ffmpegCmd.Wait()
+		if err := recorder.Close(); err != nil {
+			log.Printf("Failed to finish recording: %v", err)
+		}
+		for _, seg := range recorder.Segments() {
+			fmt.Printf("Saved %s (%d frames, %d bytes)\n", seg.Path, seg.Frames, seg.Size)
+		}
 	}()
 
 	fmt.Println("Recording started... (4 seconds total: 2s RGB + 2s Depth)")
 
-	// 3. 循环采集
+	// 3. 构建 HUD
+	info := overlay.NewTextBlock(overlay.Placement{Anchor: overlay.TopLeft, Offset: image.Pt(8, 8)})
+	tsInfo := overlay.NewTimestampInfo(overlay.Placement{Anchor: overlay.BottomLeft, Offset: image.Pt(8, 8)})
+	fps := overlay.NewFPSCounter(overlay.Placement{Anchor: overlay.TopRight, Offset: image.Pt(8, 8)})
+	gauges := overlay.NewGauges(overlay.Placement{Anchor: overlay.BottomRight, Offset: image.Pt(8, 8)})
+	legend := overlay.NewDepthLegend(overlay.Placement{Anchor: overlay.TopRight, Offset: image.Pt(8, 40)}, legendOpts)
+	cursor := overlay.NewDepthCursor()
+
+	scale := depthScale(dev)
+
+	rgbHUD := overlay.NewHUD(info, tsInfo, fps, gauges)
+	depthHUD := overlay.NewHUD(info, tsInfo, fps, gauges, legend, cursor)
+
+	// 4. 循环采集
 	// 前 60 帧 (2s) 录制 RGB
 	// 后 60 帧 (2s) 录制 Depth (Colorized)
 	totalFrames := FPS * 4
@@ -140,9 +160,11 @@ func main() {
 		}
 
 		var currentImg *image.RGBA
+		var hud *overlay.HUD
 		var modeStr string
 		var ts float64
 		var tsDomain int
+		var frameNum uint64
 
 		// 前 2 秒 (60 帧) -> RGB
 		if frameCount < FPS*2 {
@@ -151,9 +173,11 @@ func main() {
 				data := colorFrame.GetRawData()
 				ts, _ = colorFrame.GetTimestamp()
 				tsDomain, _ = colorFrame.GetTimestampDomain()
+				frameNum, _ = colorFrame.GetFrameNumber()
 
 				// 转换为 image.RGBA
 				currentImg = rgb8ToRGBA(data, Width, Height)
+				hud = rgbHUD
 				modeStr = "RGB"
 				colorFrame.Close()
 			}
@@ -161,6 +185,12 @@ func main() {
 			// 后 2 秒 (60 帧) -> Depth (Colorized)
 			depthFrame, err := alignedFrames.GetFrame(rs.StreamDepth)
 			if err == nil {
+				// 拷贝深度数据供光标读数使用（帧释放后 C 内存失效）
+				img, imgErr := depth.FromBuffer(depthFrame.GetDepthData(), depthFrame.GetWidth(), depthFrame.GetHeight())
+				if imgErr == nil {
+					cursor.Update(image.Pt(Width/2, Height/2), img.Clone(), scale)
+				}
+
 				// 生成伪彩色
 				colorizedFrame, err := colorizer.Process(depthFrame)
 				depthFrame.Close()
@@ -168,9 +198,11 @@ func main() {
 					data := colorizedFrame.GetRawData()
 					ts, _ = colorizedFrame.GetTimestamp()
 					tsDomain, _ = colorizedFrame.GetTimestampDomain()
+					frameNum, _ = colorizedFrame.GetFrameNumber()
 
 					// Colorizer 输出通常是 RGB8
 					currentImg = rgb8ToRGBA(data, Width, Height)
+					hud = depthHUD
 					modeStr = "Depth (Colorized)"
 					colorizedFrame.Close()
 				}
@@ -179,10 +211,25 @@ func main() {
 		alignedFrames.Close()
 
 		if currentImg != nil {
-			// 4. 叠加 HUD 信息
-			drawHUD(currentImg, frameCount, ts, tsDomain, modeStr)
+			// 5. 叠加 HUD 信息
+			fps.Tick(time.Now())
+			tsInfo.Update(frameNum, ts, tsDomain)
+			info.SetLines(
+				fmt.Sprintf("Mode: %s", modeStr),
+				fmt.Sprintf("Res: %dx%d | Fmt: RGB8/Z16", Width, Height),
+				fmt.Sprintf("System: %s", time.Now().Format("15:04:05.000")),
+			)
+			if dev != nil && frameCount%FPS == 0 {
+				if t, err := dev.GetTelemetry(); err == nil {
+					gauges.Items = []overlay.Gauge{
+						overlay.TemperatureGauge("ASIC", float64(t.AsicTemperature)),
+						overlay.TemperatureGauge("Projector", float64(t.ProjectorTemperature)),
+					}
+				}
+			}
+			hud.Draw(currentImg)
 
-			// 5. 保存截图 (RGB 第一帧 和 Depth 第一帧)
+			// 6. 保存截图 (RGB 第一帧 和 Depth 第一帧)
 			if frameCount == 0 {
 				saveImage(filepath.Join(outputDir, "rgb_snapshot.png"), currentImg)
 				fmt.Println("Saved rgb_snapshot.png")
@@ -191,11 +238,9 @@ func main() {
 				fmt.Println("Saved depth_snapshot.png")
 			}
 
-			// 6. 写入 FFmpeg
-			// image.RGBA 的 Pix 是 []uint8 (R, G, B, A)
-			_, err := ffmpegIn.Write(currentImg.Pix)
-			if err != nil {
-				log.Printf("Error writing to ffmpeg: %v", err)
+			// 7. 写入录制器（编码进程退出时返回错误）
+			if err := recorder.WriteFrame(currentImg); err != nil {
+				log.Printf("Error writing frame: %v", err)
 				break
 			}
 
@@ -206,7 +251,7 @@ func main() {
 		}
 	}
 
-	fmt.Println("Recording finished. Saved to output.mp4")
+	fmt.Println("Recording finished.")
 }
 
 // rgb8ToRGBA 将 RGB8 数据转换为 image.RGBA
@@ -230,37 +275,17 @@ func rgb8ToRGBA(data []byte, w, h int) *image.RGBA {
 	return img
 }
 
-// drawHUD 在图像上绘制 HUD 信息
-func drawHUD(img *image.RGBA, frameIdx int, ts float64, domain int, mode string) {
-	// 绘制半透明背景条
-	bgRect := image.Rect(0, 0, Width, 80)
-	// image.NewUniform 创建纯色背景
-	bg := image.NewUniform(color.RGBA{0, 0, 0, 150})
-	draw.Draw(img, bgRect, bg, image.Point{}, draw.Over)
-
-	// 准备文字绘制
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(color.White),
-		Face: basicfont.Face7x13,
-	}
-
-	lines := []string{
-		fmt.Sprintf("Mode: %s", mode),
-		fmt.Sprintf("Frame: %d | Time: %.2f ms (Domain: %d [1=HW, 2=Sys])", frameIdx, ts, domain),
-		fmt.Sprintf("Res: %dx%d | Fmt: RGB8/Z16", Width, Height),
-		fmt.Sprintf("System: %s", time.Now().Format("15:04:05.000")),
-	}
-
-	y := 20
-	for _, line := range lines {
-		d.Dot = fixed.Point26_6{
-			X: fixed.I(10),
-			Y: fixed.I(y),
+// depthScale 返回设备的深度比例，获取失败时使用 D455 的默认值
+func depthScale(dev *rs.Device) float32 {
+	if dev != nil {
+		if s, err := dev.GetDepthSensor(); err == nil {
+			defer s.Close()
+			if scale, err := s.GetDepthScale(); err == nil {
+				return scale
+			}
 		}
-		d.DrawString(line)
-		y += 18
 	}
+	return depth.DefaultDepthScale
 }
 
 // saveImage 保存图片为 PNG
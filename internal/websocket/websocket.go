@@ -0,0 +1,293 @@
+// Package websocket 是 RFC 6455 的最小实现，只包含本仓库需要的部分：
+// 服务端升级、客户端拨号、文本/二进制消息、分片重组、ping/pong 与关闭握手，
+// 不支持扩展 (permessage-deflate) 和子协议协商
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Opcode 是消息类型
+type Opcode byte
+
+const (
+	opContinuation Opcode = 0x0
+	OpText         Opcode = 0x1
+	OpBinary       Opcode = 0x2
+	opClose        Opcode = 0x8
+	opPing         Opcode = 0x9
+	opPong         Opcode = 0xA
+)
+
+// 关闭码 (RFC 6455 7.4.1)
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseUnsupported   = 1003
+	ClosePolicy        = 1008
+	CloseTooBig        = 1009
+)
+
+// DefaultReadLimit 是单条消息的默认最大长度
+const DefaultReadLimit = 64 << 20
+
+// acceptGUID 用于计算 Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed 表示本端已发送关闭帧
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError 表示对端发来了关闭帧
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed by peer (%d)", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed by peer (%d): %s", e.Code, e.Reason)
+}
+
+// Conn 是一个 WebSocket 连接
+// ReadMessage 只能在一个 goroutine 中调用，写入方法可以并发调用
+type Conn struct {
+	nc        net.Conn
+	br        *bufio.Reader
+	client    bool // 客户端发送的帧必须加掩码，服务端收到的帧必须带掩码
+	readLimit int64
+
+	wmu       sync.Mutex
+	bw        *bufio.Writer
+	closeSent bool
+}
+
+func newConn(nc net.Conn, br *bufio.Reader, bw *bufio.Writer, client bool) *Conn {
+	return &Conn{nc: nc, br: br, bw: bw, client: client, readLimit: DefaultReadLimit}
+}
+
+// SetReadLimit 设置单条消息的最大长度，超出时以 CloseTooBig 关闭连接
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit = n
+}
+
+// SetReadDeadline 设置读超时
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.nc.SetReadDeadline(t)
+}
+
+// SetWriteDeadline 设置写超时，用于及时发现卡住的对端
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.nc.SetWriteDeadline(t)
+}
+
+// RemoteAddr 返回对端地址
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.nc.RemoteAddr()
+}
+
+// ReadMessage 读取下一条完整的文本或二进制消息
+// ping 自动回复 pong；收到关闭帧时回复关闭帧并返回 *CloseError
+func (c *Conn) ReadMessage() (Opcode, []byte, error) {
+	var op Opcode
+	var msg []byte
+	for {
+		fin, frameOp, payload, err := c.readFrame(int64(len(msg)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch frameOp {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+			}
+			c.CloseWithCode(ce.Code, "")
+			return 0, nil, ce
+		case opContinuation:
+			if op == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			msg = append(msg, payload...)
+		case OpText, OpBinary:
+			if op != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			op, msg = frameOp, payload
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", frameOp))
+		}
+		if fin {
+			return op, msg, nil
+		}
+	}
+}
+
+// readFrame 读取一帧，buffered 是当前消息已读取的长度，用于检查 readLimit
+func (c *Conn) readFrame(buffered int64) (bool, Opcode, []byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := h[0]&0x80 != 0
+	op := Opcode(h[0] & 0x0f)
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	masked := h[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid frame masking")
+	}
+
+	n := int64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = int64(binary.BigEndian.Uint64(b[:]))
+		if n < 0 {
+			return false, 0, nil, c.fail(CloseProtocolError, "invalid frame length")
+		}
+	}
+	if op >= opClose && (!fin || n > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if op < opClose && buffered+n > c.readLimit {
+		return false, 0, nil, c.fail(CloseTooBig, fmt.Sprintf("message exceeds %d bytes", c.readLimit))
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(payload, key)
+	}
+	return fin, op, payload, nil
+}
+
+// fail 以指定关闭码关闭连接，并返回对应的错误
+func (c *Conn) fail(code int, reason string) error {
+	c.CloseWithCode(code, reason)
+	return fmt.Errorf("websocket: %s", reason)
+}
+
+// WriteMessage 发送一条完整的消息（不分片）
+func (c *Conn) WriteMessage(op Opcode, data []byte) error {
+	if op != OpText && op != OpBinary {
+		return fmt.Errorf("websocket: invalid message opcode %d", op)
+	}
+	return c.writeFrame(op, data)
+}
+
+// writeFrame 发送一帧，客户端加掩码
+func (c *Conn) writeFrame(op Opcode, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+
+	var h [14]byte
+	h[0] = 0x80 | byte(op)
+	n := 2
+	switch {
+	case len(data) < 126:
+		h[1] = byte(len(data))
+	case len(data) <= 0xffff:
+		h[1] = 126
+		binary.BigEndian.PutUint16(h[2:], uint16(len(data)))
+		n = 4
+	default:
+		h[1] = 127
+		binary.BigEndian.PutUint64(h[2:], uint64(len(data)))
+		n = 10
+	}
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		h[1] |= 0x80
+		copy(h[n:], key[:])
+		n += 4
+		masked := append([]byte(nil), data...)
+		maskBytes(masked, key)
+		data = masked
+	}
+	if _, err := c.bw.Write(h[:n]); err != nil {
+		return err
+	}
+	if _, err := c.bw.Write(data); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
+
+// CloseWithCode 发送关闭帧（尽力而为）并关闭底层连接
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	c.nc.SetWriteDeadline(time.Now().Add(time.Second))
+	c.writeFrame(opClose, payload)
+	return c.nc.Close()
+}
+
+// Close 以 CloseNormal 关闭连接
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormal, "")
+}
+
+// maskBytes 使用掩码异或数据（加掩码与去掩码相同）
+func maskBytes(b []byte, key [4]byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
+
+// acceptKey 计算 Sec-WebSocket-Accept
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,295 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pair 返回被测连接和通过本机 TCP 相连的对端原始连接
+// 使用 TCP 而不是 net.Pipe，双方同时写入（例如回复 pong）时不会互相阻塞
+func pair(t *testing.T, client bool) (*Conn, net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		nc, _ := ln.Accept()
+		accepted <- nc
+	}()
+	peer, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc := <-accepted
+	if nc == nil {
+		t.Fatal("accept failed")
+	}
+	t.Cleanup(func() {
+		nc.Close()
+		peer.Close()
+	})
+	return newConn(nc, bufio.NewReader(nc), bufio.NewWriter(nc), client), peer
+}
+
+// rawFrame 按 RFC 6455 编码一帧，mask 为真时使用固定掩码
+func rawFrame(fin bool, op Opcode, payload []byte, mask bool) []byte {
+	var b bytes.Buffer
+	h0 := byte(op)
+	if fin {
+		h0 |= 0x80
+	}
+	b.WriteByte(h0)
+	var m byte
+	if mask {
+		m = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		b.WriteByte(m | byte(n))
+	case n <= 0xffff:
+		b.WriteByte(m | 126)
+		binary.Write(&b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(m | 127)
+		binary.Write(&b, binary.BigEndian, uint64(n))
+	}
+	data := append([]byte(nil), payload...)
+	if mask {
+		key := [4]byte{0x12, 0x34, 0x56, 0x78}
+		b.Write(key[:])
+		maskBytes(data, key)
+	}
+	b.Write(data)
+	return b.Bytes()
+}
+
+// rawRead 从对端读取一帧，返回去掩码后的内容
+func rawRead(t *testing.T, r io.Reader) (fin bool, op Opcode, payload []byte, masked bool) {
+	t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		io.ReadFull(r, b[:])
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		io.ReadFull(r, b[:])
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	masked = h[1]&0x80 != 0
+	var key [4]byte
+	if masked {
+		io.ReadFull(r, key[:])
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	if masked {
+		maskBytes(payload, key)
+	}
+	return h[0]&0x80 != 0, Opcode(h[0] & 0x0f), payload, masked
+}
+
+// closeCode 从对端读取关闭帧并返回关闭码
+func closeCode(t *testing.T, r io.Reader) int {
+	t.Helper()
+	_, op, payload, _ := rawRead(t, r)
+	if op != opClose || len(payload) < 2 {
+		t.Fatalf("got opcode %d payload %q, want close frame", op, payload)
+	}
+	return int(binary.BigEndian.Uint16(payload))
+}
+
+func TestReadFragmentedMessage(t *testing.T) {
+	c, peer := pair(t, false)
+	var in bytes.Buffer
+	in.Write(rawFrame(false, OpText, []byte("hel"), true))
+	in.Write(rawFrame(true, opPing, []byte("p"), true)) // 控制帧可以插在分片之间
+	in.Write(rawFrame(false, opContinuation, []byte("lo "), true))
+	in.Write(rawFrame(true, opContinuation, []byte("world"), true))
+	in.Write(rawFrame(true, OpBinary, []byte{1, 2, 3}, true))
+	peer.Write(in.Bytes())
+
+	op, msg, err := c.ReadMessage()
+	if err != nil || op != OpText || string(msg) != "hello world" {
+		t.Fatalf("got %d %q %v", op, msg, err)
+	}
+	// ping 被自动回复，服务端发出的帧不带掩码
+	if fin, op, payload, masked := rawRead(t, peer); !fin || op != opPong || string(payload) != "p" || masked {
+		t.Errorf("reply fin=%v op=%d payload=%q masked=%v, want unmasked pong", fin, op, payload, masked)
+	}
+	op, msg, err = c.ReadMessage()
+	if err != nil || op != OpBinary || !bytes.Equal(msg, []byte{1, 2, 3}) {
+		t.Fatalf("got %d %v %v", op, msg, err)
+	}
+}
+
+func TestReadProtocolErrors(t *testing.T) {
+	long := bytes.Repeat([]byte("x"), 126)
+	tests := []struct {
+		name   string
+		client bool   // 被测连接是否为客户端
+		limit  int64  // 0 表示默认
+		frames []byte // 对端发送的原始数据
+		err    string
+		code   int
+	}{
+		{"unmasked frame to server", false, 0, rawFrame(true, OpText, []byte("a"), false), "masking", CloseProtocolError},
+		{"masked frame to client", true, 0, rawFrame(true, OpText, []byte("a"), true), "masking", CloseProtocolError},
+		{"ping over 125 bytes", false, 0, rawFrame(true, opPing, long, true), "invalid control frame", CloseProtocolError},
+		{"close over 125 bytes", false, 0, rawFrame(true, opClose, long, true), "invalid control frame", CloseProtocolError},
+		{"fragmented ping", false, 0, rawFrame(false, opPing, []byte("p"), true), "invalid control frame", CloseProtocolError},
+		{"continuation first", false, 0, rawFrame(true, opContinuation, []byte("a"), true),
+			"unexpected continuation", CloseProtocolError},
+		{"new message inside fragments", false, 0, append(rawFrame(false, OpText, []byte("a"), true),
+			rawFrame(true, OpBinary, []byte("b"), true)...), "expected continuation", CloseProtocolError},
+		{"reserved bits", false, 0, append([]byte{0xc1}, rawFrame(true, OpText, nil, true)[1:]...),
+			"reserved bits", CloseProtocolError},
+		{"unknown opcode", false, 0, rawFrame(true, Opcode(3), nil, true), "unknown opcode", CloseProtocolError},
+		{"single frame over limit", false, 10, rawFrame(true, OpBinary, make([]byte, 11), true), "exceeds 10 bytes", CloseTooBig},
+		{"fragments over limit", false, 10, append(rawFrame(false, OpBinary, make([]byte, 6), true),
+			rawFrame(true, opContinuation, make([]byte, 6), true)...), "exceeds 10 bytes", CloseTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, peer := pair(t, tt.client)
+			if tt.limit > 0 {
+				c.SetReadLimit(tt.limit)
+			}
+			peer.Write(tt.frames)
+			_, _, err := c.ReadMessage()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, want %q", err, tt.err)
+			}
+			if code := closeCode(t, peer); code != tt.code {
+				t.Errorf("close code %d, want %d", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestReadLimitAllowsExactSize(t *testing.T) {
+	c, peer := pair(t, false)
+	c.SetReadLimit(10)
+	peer.Write(rawFrame(false, OpBinary, make([]byte, 4), true))
+	peer.Write(rawFrame(true, opContinuation, make([]byte, 6), true))
+	if _, msg, err := c.ReadMessage(); err != nil || len(msg) != 10 {
+		t.Fatalf("got %d bytes, %v", len(msg), err)
+	}
+}
+
+func TestWriteFraming(t *testing.T) {
+	for _, client := range []bool{false, true} {
+		// 覆盖 7 位、16 位和 64 位三种长度编码
+		for _, n := range []int{0, 125, 126, 0xffff, 0x10000} {
+			c, peer := pair(t, client)
+			data := bytes.Repeat([]byte{0xa5}, n)
+			go c.WriteMessage(OpBinary, data)
+			fin, op, payload, masked := rawRead(t, peer)
+			if !fin || op != OpBinary || !bytes.Equal(payload, data) {
+				t.Errorf("client=%v n=%d: fin=%v op=%d len=%d", client, n, fin, op, len(payload))
+			}
+			// 只有客户端发送的帧加掩码
+			if masked != client {
+				t.Errorf("client=%v n=%d: masked=%v", client, n, masked)
+			}
+		}
+	}
+
+	c, _ := pair(t, false)
+	if err := c.WriteMessage(opPing, nil); err == nil {
+		t.Error("WriteMessage accepted a control opcode")
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	c, peer := pair(t, false)
+	payload := []byte{0x03, 0xe9} // 1001
+	peer.Write(rawFrame(true, opClose, append(payload, "bye"...), true))
+
+	_, _, err := c.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Reason != "bye" {
+		t.Fatalf("error %v, want close 1001 bye", err)
+	}
+	// 回复相同的关闭码，之后不能再写
+	if code := closeCode(t, peer); code != CloseGoingAway {
+		t.Errorf("reply code %d", code)
+	}
+	if err := c.WriteMessage(OpText, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("write after close: %v", err)
+	}
+}
+
+func TestCloseReasonTruncated(t *testing.T) {
+	c, peer := pair(t, false)
+	go c.CloseWithCode(ClosePolicy, strings.Repeat("r", 200))
+	_, op, payload, _ := rawRead(t, peer)
+	if op != opClose || len(payload) != 125 || binary.BigEndian.Uint16(payload) != ClosePolicy {
+		t.Errorf("op %d, %d bytes", op, len(payload))
+	}
+}
+
+func TestDialUpgradeRoundTrip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			op, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(op, msg) // 回显
+		}
+	}))
+	defer ts.Close()
+
+	conn, err := Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/echo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	big := bytes.Repeat([]byte("0123456789"), 10000)
+	for _, msg := range []struct {
+		op   Opcode
+		data []byte
+	}{{OpText, []byte("hello")}, {OpBinary, big}} {
+		if err := conn.WriteMessage(msg.op, msg.data); err != nil {
+			t.Fatal(err)
+		}
+		op, data, err := conn.ReadMessage()
+		if err != nil || op != msg.op || !bytes.Equal(data, msg.data) {
+			t.Fatalf("echo op %d len %d err %v", op, len(data), err)
+		}
+	}
+
+	// 普通 HTTP 请求不能升级
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,27 @@
+// Package z16 是 Z16 深度数据的行内差分编码，archive 的归档文件和 stream 的帧协议共用，
+// 两者的字节格式必须一致：小端 uint16，每行第一个像素为原值，其余为与左侧像素的差值（模 65536）
+// 深度图相邻像素通常接近，差分后的数据更容易被 deflate 压缩
+package z16
+
+import "encoding/binary"
+
+// DeltaEncode 将 data 按行差分编码写入 out，out 至少为 len(data)*2 字节
+func DeltaEncode(out []byte, data []uint16, width int) {
+	for i, v := range data {
+		if i%width != 0 {
+			v -= data[i-1]
+		}
+		binary.LittleEndian.PutUint16(out[i*2:], v)
+	}
+}
+
+// DeltaDecode 是 DeltaEncode 的逆过程，in 至少为 len(data)*2 字节
+func DeltaDecode(data []uint16, in []byte, width int) {
+	for i := range data {
+		v := binary.LittleEndian.Uint16(in[i*2:])
+		if i%width != 0 {
+			v += data[i-1]
+		}
+		data[i] = v
+	}
+}
@@ -0,0 +1,27 @@
+package z16
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	const width = 3
+	data := []uint16{100, 101, 99, 0, 65535, 1}
+	out := make([]byte, len(data)*2)
+	DeltaEncode(out, data, width)
+
+	// 每行第一个像素保留原值，其余为差值，下溢/上溢按模 65536 回绕
+	want := []byte{100, 0, 1, 0, 0xfe, 0xff, 0, 0, 0xff, 0xff, 2, 0}
+	if !bytes.Equal(out, want) {
+		t.Fatalf("encoded % x, want % x", out, want)
+	}
+
+	got := make([]uint16, len(data))
+	DeltaDecode(got, out, width)
+	for i := range data {
+		if got[i] != data[i] {
+			t.Fatalf("decoded %v, want %v", got, data)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// FillRect 以 Over 模式填充矩形，支持半透明颜色
+func FillRect(dst *image.RGBA, r image.Rectangle, c color.RGBA) {
+	if c.A == 0 {
+		return
+	}
+	draw.Draw(dst, r.Intersect(dst.Bounds()), image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// StrokeRect 绘制矩形边框，thickness 向矩形内部延伸
+func StrokeRect(dst *image.RGBA, r image.Rectangle, c color.RGBA, thickness int) {
+	if thickness < 1 {
+		thickness = 1
+	}
+	FillRect(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness), c)
+	FillRect(dst, image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y), c)
+	FillRect(dst, image.Rect(r.Min.X, r.Min.Y+thickness, r.Min.X+thickness, r.Max.Y-thickness), c)
+	FillRect(dst, image.Rect(r.Max.X-thickness, r.Min.Y+thickness, r.Max.X, r.Max.Y-thickness), c)
+}
+
+// Line 使用 Bresenham 算法绘制直线，thickness 为方形笔刷边长
+func Line(dst *image.RGBA, a, b image.Point, c color.RGBA, thickness int) {
+	if thickness < 1 {
+		thickness = 1
+	}
+	half := thickness / 2
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	e := dx + dy
+	x, y := a.X, a.Y
+	for {
+		if thickness == 1 {
+			if (image.Point{x, y}).In(dst.Bounds()) {
+				dst.SetRGBA(x, y, blend(dst.RGBAAt(x, y), c))
+			}
+		} else {
+			FillRect(dst, image.Rect(x-half, y-half, x-half+thickness, y-half+thickness), c)
+		}
+		if x == b.X && y == b.Y {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+// blend 将 src 以 Over 模式混合到 dst，颜色均为预乘 alpha
+func blend(dst, src color.RGBA) color.RGBA {
+	if src.A == 255 {
+		return src
+	}
+	inv := uint32(255 - src.A)
+	mix := func(d, s uint8) uint8 { return uint8(uint32(s) + (uint32(d)*inv+127)/255) }
+	return color.RGBA{mix(dst.R, src.R), mix(dst.G, src.G), mix(dst.B, src.B), mix(dst.A, src.A)}
+}
+
+// WithAlpha 返回不透明颜色 c 在透明度 a 下的预乘 alpha 颜色
+// color.RGBA 是预乘格式，半透明颜色必须通过它构造，例如 WithAlpha(red, 180)
+func WithAlpha(c color.RGBA, a uint8) color.RGBA {
+	f := func(v uint8) uint8 { return uint8((uint32(v)*uint32(a) + 127) / 255) }
+	return color.RGBA{f(c.R), f(c.G), f(c.B), a}
+}
+
+// MeasureText 返回多行文本的像素尺寸（不含内边距）
+func MeasureText(lines []string, s Style) image.Point {
+	face := s.face()
+	m := face.Metrics()
+	lineH := (m.Ascent + m.Descent).Ceil()
+
+	var w int
+	for _, l := range lines {
+		if adv := font.MeasureString(face, l).Ceil(); adv > w {
+			w = adv
+		}
+	}
+	h := len(lines)*lineH + max(len(lines)-1, 0)*s.LineSpacing
+	return image.Pt(w, h)
+}
+
+// DrawText 在 at（左上角）处绘制多行文本，不绘制背景
+func DrawText(dst *image.RGBA, at image.Point, lines []string, s Style) {
+	face := s.face()
+	m := face.Metrics()
+	lineH := (m.Ascent + m.Descent).Ceil()
+
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(s.Color), Face: face}
+	y := at.Y + m.Ascent.Ceil()
+	for _, l := range lines {
+		d.Dot = fixed.P(at.X, y)
+		d.DrawString(l)
+		y += lineH + s.LineSpacing
+	}
+}
+
+// DrawTextBox 按样式绘制带背景的文本框，返回文本框所占矩形
+func DrawTextBox(dst *image.RGBA, p Placement, lines []string, s Style) image.Rectangle {
+	size := MeasureText(lines, s).Add(image.Pt(2*s.Padding, 2*s.Padding))
+	r := p.place(dst.Bounds(), size)
+	FillRect(dst, r, s.Background)
+	DrawText(dst, r.Min.Add(image.Pt(s.Padding, s.Padding)), lines, s)
+	return r
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,173 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// Gauge 是一个水平条形仪表的数据
+type Gauge struct {
+	Label    string
+	Value    float64
+	Min      float64
+	Max      float64
+	Warn     float64 // 达到该值显示警告色
+	Critical float64 // 达到该值显示危险色
+	Unit     string
+}
+
+// TemperatureGauge 返回温度仪表（摄氏度），范围 0-100，60 警告，75 危险
+func TemperatureGauge(label string, celsius float64) Gauge {
+	return Gauge{Label: label, Value: celsius, Min: 0, Max: 100, Warn: 60, Critical: 75, Unit: "C"}
+}
+
+// Gauges 绘制一组条形仪表，例如 ASIC 和投影模组温度
+type Gauges struct {
+	Placement
+	Style Style
+	Items []Gauge
+
+	BarWidth      int
+	BarHeight     int
+	NormalColor   color.RGBA
+	WarnColor     color.RGBA
+	CriticalColor color.RGBA
+	TrackColor    color.RGBA // 条形底色
+}
+
+// NewGauges 创建仪表组件
+func NewGauges(p Placement, items ...Gauge) *Gauges {
+	return &Gauges{
+		Placement:     p,
+		Style:         DefaultStyle(),
+		Items:         items,
+		BarWidth:      80,
+		BarHeight:     9,
+		NormalColor:   color.RGBA{0, 200, 0, 255},
+		WarnColor:     color.RGBA{255, 190, 0, 255},
+		CriticalColor: color.RGBA{255, 40, 40, 255},
+		TrackColor:    color.RGBA{80, 80, 80, 255},
+	}
+}
+
+// color 返回数值对应的颜色
+func (g *Gauges) color(it Gauge) color.RGBA {
+	switch {
+	case it.Critical > it.Min && it.Value >= it.Critical:
+		return g.CriticalColor
+	case it.Warn > it.Min && it.Value >= it.Warn:
+		return g.WarnColor
+	}
+	return g.NormalColor
+}
+
+// Draw 绘制仪表，每行为“标签 条形 数值”
+func (g *Gauges) Draw(dst *image.RGBA) {
+	if len(g.Items) == 0 {
+		return
+	}
+	s := g.Style
+	labels := make([]string, len(g.Items))
+	values := make([]string, len(g.Items))
+	for i, it := range g.Items {
+		labels[i] = it.Label
+		values[i] = fmt.Sprintf("%.1f %s", it.Value, it.Unit)
+	}
+	labelW := MeasureText(labels, s).X
+	valueW := MeasureText(values, s).X
+	lineH := MeasureText([]string{"0"}, s).Y
+	rowH := max(lineH, g.BarHeight)
+	gap := 6
+
+	w := labelW + gap + g.BarWidth + gap + valueW + 2*s.Padding
+	h := len(g.Items)*rowH + (len(g.Items)-1)*s.LineSpacing + 2*s.Padding
+	r := g.place(dst.Bounds(), image.Pt(w, h))
+	FillRect(dst, r, s.Background)
+
+	y := r.Min.Y + s.Padding
+	for i, it := range g.Items {
+		x := r.Min.X + s.Padding
+		DrawText(dst, image.Pt(x, y+(rowH-lineH)/2), []string{labels[i]}, s)
+		x += labelW + gap
+
+		barY := y + (rowH-g.BarHeight)/2
+		FillRect(dst, image.Rect(x, barY, x+g.BarWidth, barY+g.BarHeight), g.TrackColor)
+		frac := 0.0
+		if it.Max > it.Min {
+			frac = (it.Value - it.Min) / (it.Max - it.Min)
+		}
+		frac = min(max(frac, 0), 1)
+		FillRect(dst, image.Rect(x, barY, x+int(frac*float64(g.BarWidth)), barY+g.BarHeight), g.color(it))
+		x += g.BarWidth + gap
+
+		DrawText(dst, image.Pt(x, y+(rowH-lineH)/2), []string{values[i]}, s)
+		y += rowH + s.LineSpacing
+	}
+}
+
+// DepthLegend 绘制深度色标，近处在下、远处在上
+// 色标对应固定距离范围，仅在关闭直方图均衡的着色结果上有意义
+type DepthLegend struct {
+	Placement
+	Style Style
+
+	Scheme      depth.ColorScheme
+	MinDistance float32 // 米
+	MaxDistance float32
+	Width       int // 色条宽度（像素）
+	Height      int // 色条高度（像素）
+	Ticks       int // 刻度数（含两端），至少 2
+}
+
+// NewDepthLegend 创建深度色标，参数应与着色时使用的 ColorizeOptions 一致
+func NewDepthLegend(p Placement, opts depth.ColorizeOptions) *DepthLegend {
+	return &DepthLegend{
+		Placement:   p,
+		Style:       DefaultStyle(),
+		Scheme:      opts.Scheme,
+		MinDistance: opts.MinDistance,
+		MaxDistance: opts.MaxDistance,
+		Width:       14,
+		Height:      160,
+		Ticks:       5,
+	}
+}
+
+// Draw 绘制色标和刻度
+func (l *DepthLegend) Draw(dst *image.RGBA) {
+	ticks := max(l.Ticks, 2)
+	s := l.Style
+	labels := make([]string, ticks)
+	for i := range labels {
+		d := l.MinDistance + (l.MaxDistance-l.MinDistance)*float32(i)/float32(ticks-1)
+		labels[i] = fmt.Sprintf("%.1f m", d)
+	}
+	labelW := MeasureText(labels, s).X
+	lineH := MeasureText([]string{"0"}, s).Y
+	gap := 4
+
+	w := l.Width + gap + labelW + 2*s.Padding
+	h := l.Height + lineH + 2*s.Padding // 上下各留半行给端点标签
+	r := l.place(dst.Bounds(), image.Pt(w, h))
+	FillRect(dst, r, s.Background)
+
+	barX := r.Min.X + s.Padding
+	barTop := r.Min.Y + s.Padding + lineH/2
+	for y := 0; y < l.Height; y++ {
+		t := 1 - float32(y)/float32(max(l.Height-1, 1))
+		c, err := depth.SchemeColor(l.Scheme, t)
+		if err != nil {
+			return
+		}
+		FillRect(dst, image.Rect(barX, barTop+y, barX+l.Width, barTop+y+1), color.RGBA{c[0], c[1], c[2], 255})
+	}
+
+	for i, label := range labels {
+		y := barTop + (l.Height - 1) - (l.Height-1)*i/(ticks-1)
+		FillRect(dst, image.Rect(barX+l.Width, y, barX+l.Width+gap/2, y+1), s.Color)
+		DrawText(dst, image.Pt(barX+l.Width+gap, y-lineH/2), []string{label}, s)
+	}
+}
@@ -0,0 +1,105 @@
+// Package overlay 在 *image.RGBA 上绘制 HUD 叠加层
+// 提供文本块、FPS、时间戳、ROI 框、光标深度、温度仪表和深度色标等组件，
+// 纯 Go 实现 (无 CGO)，相同输入总是得到相同像素，便于用参考图比对
+package overlay
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// Widget 是可绘制的 HUD 组件
+type Widget interface {
+	Draw(dst *image.RGBA)
+}
+
+// WidgetFunc 将函数适配为 Widget
+type WidgetFunc func(dst *image.RGBA)
+
+// Draw 调用函数本身
+func (f WidgetFunc) Draw(dst *image.RGBA) { f(dst) }
+
+// HUD 按添加顺序绘制一组组件
+type HUD struct {
+	widgets []Widget
+}
+
+// NewHUD 创建 HUD
+func NewHUD(widgets ...Widget) *HUD {
+	return &HUD{widgets: widgets}
+}
+
+// Add 追加组件
+func (h *HUD) Add(w ...Widget) {
+	h.widgets = append(h.widgets, w...)
+}
+
+// Draw 依次绘制所有组件
+func (h *HUD) Draw(dst *image.RGBA) {
+	for _, w := range h.widgets {
+		w.Draw(dst)
+	}
+}
+
+// Anchor 组件在画面中的停靠位置
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// Style 文本与背景样式
+type Style struct {
+	Face        font.Face  // 字体，nil 表示 basicfont.Face7x13
+	Color       color.RGBA // 文字颜色
+	Background  color.RGBA // 背景颜色（预乘 alpha，见 WithAlpha），A 为 0 时不绘制背景
+	Padding     int        // 背景内边距（像素）
+	LineSpacing int        // 行间距（像素）
+}
+
+// DefaultStyle 返回白字、半透明黑底的默认样式
+func DefaultStyle() Style {
+	return Style{
+		Face:        basicfont.Face7x13,
+		Color:       color.RGBA{255, 255, 255, 255},
+		Background:  color.RGBA{0, 0, 0, 150},
+		Padding:     6,
+		LineSpacing: 5,
+	}
+}
+
+// face 返回样式的字体
+func (s Style) face() font.Face {
+	if s.Face == nil {
+		return basicfont.Face7x13
+	}
+	return s.Face
+}
+
+// Placement 描述组件的停靠位置和相对边缘的偏移
+type Placement struct {
+	Anchor Anchor
+	Offset image.Point // 距离停靠边缘的距离（像素），始终向画面内部偏移
+}
+
+// place 计算 size 大小的组件在 bounds 中的矩形
+func (p Placement) place(bounds image.Rectangle, size image.Point) image.Rectangle {
+	var min image.Point
+	switch p.Anchor {
+	case TopLeft:
+		min = image.Pt(bounds.Min.X+p.Offset.X, bounds.Min.Y+p.Offset.Y)
+	case TopRight:
+		min = image.Pt(bounds.Max.X-p.Offset.X-size.X, bounds.Min.Y+p.Offset.Y)
+	case BottomLeft:
+		min = image.Pt(bounds.Min.X+p.Offset.X, bounds.Max.Y-p.Offset.Y-size.Y)
+	case BottomRight:
+		min = image.Pt(bounds.Max.X-p.Offset.X-size.X, bounds.Max.Y-p.Offset.Y-size.Y)
+	}
+	return image.Rectangle{Min: min, Max: min.Add(size)}
+}
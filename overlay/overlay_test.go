@@ -0,0 +1,188 @@
+package overlay
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata 中的期望图像")
+
+// canvas 返回灰色背景的画布，便于看出半透明背景的混合效果
+func canvas(w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	FillRect(dst, dst.Bounds(), color.RGBA{90, 90, 90, 255})
+	return dst
+}
+
+// checkGolden 将画面与 testdata/name 逐像素比较，-update 时写入
+func checkGolden(t *testing.T, name string, got *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v (run go test -update to create it)", path, err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	if !want.Bounds().Eq(got.Bounds()) {
+		t.Fatalf("%s: bounds %v, want %v", name, got.Bounds(), want.Bounds())
+	}
+	diff := 0
+	var first image.Point
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(want.At(x, y)) != got.RGBAAt(x, y) {
+				if diff == 0 {
+					first = image.Pt(x, y)
+				}
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%s: %d pixels differ, first at %v: got %v, want %v",
+			name, diff, first, got.RGBAAt(first.X, first.Y), want.At(first.X, first.Y))
+	}
+}
+
+func TestTextBlockGolden(t *testing.T) {
+	dst := canvas(200, 100)
+	NewHUD(
+		NewTextBlock(Placement{Anchor: TopLeft, Offset: image.Pt(4, 4)}, "FPS: 30.0", "Frame: 1234"),
+		NewTextBlock(Placement{Anchor: BottomRight, Offset: image.Pt(4, 4)}, "D435 #123456"),
+		NewTextBlock(Placement{Anchor: TopRight}), // 空文本不绘制
+	).Draw(dst)
+	checkGolden(t, "textblock.png", dst)
+}
+
+func TestROIOverlayGolden(t *testing.T) {
+	// 区域坐标按 320x240 定义，画面为 160x120，绘制时缩放一半
+	o := NewROIOverlay(320, 240)
+	o.Regions = []ROIState{
+		{
+			Region: roi.Region{Name: "idle", Rect: &roi.Rect{X: 20, Y: 60, W: 100, H: 80}},
+			Stats:  roi.Stats{Points: 12, Threshold: 500},
+		},
+		{
+			Region: roi.Region{Name: "active", Rect: &roi.Rect{X: 180, Y: 100, W: 120, H: 120}},
+			Active: true,
+			Stats:  roi.Stats{Points: 900, Threshold: 500},
+		},
+		{
+			// 贴着上边缘，标签放到框内
+			Region: roi.Region{Name: "poly", Polygon: []roi.Point{{X: 150, Y: 0}, {X: 260, Y: 0}, {X: 300, Y: 60}, {X: 170, Y: 80}}},
+		},
+	}
+	dst := canvas(160, 120)
+	o.Draw(dst)
+	checkGolden(t, "roi_states.png", dst)
+
+	o.ShowStats = false
+	dst = canvas(160, 120)
+	o.Draw(dst)
+	checkGolden(t, "roi_states_nostats.png", dst)
+}
+
+func TestDepthLegendGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		anchor Anchor
+		opts   depth.ColorizeOptions
+	}{
+		{"legend_jet.png", TopRight, depth.ColorizeOptions{Scheme: depth.ColorSchemeJet, MinDistance: 0.3, MaxDistance: 4}},
+		{"legend_white_to_black.png", BottomLeft, depth.ColorizeOptions{Scheme: depth.ColorSchemeWhiteToBlack, MinDistance: 0, MaxDistance: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewDepthLegend(Placement{Anchor: tt.anchor, Offset: image.Pt(8, 8)}, tt.opts)
+			l.Height = 80
+			dst := canvas(120, 120)
+			l.Draw(dst)
+			checkGolden(t, tt.name, dst)
+		})
+	}
+}
+
+func TestFPSCounterGolden(t *testing.T) {
+	f := NewFPSCounter(Placement{Anchor: TopLeft, Offset: image.Pt(4, 4)})
+	// 固定时间：先是 1 秒窗口外的旧帧，之后 31 帧间隔 1/30 秒
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.Tick(start.Add(-2 * time.Second))
+	for i := 0; i <= 30; i++ {
+		f.Tick(start.Add(time.Duration(i) * time.Second / 30))
+	}
+	if fps := f.FPS(); fps < 29.99 || fps > 30.01 {
+		t.Errorf("FPS = %g, want 30", fps)
+	}
+
+	dst := canvas(120, 40)
+	f.Draw(dst)
+	checkGolden(t, "fps.png", dst)
+}
+
+func TestGaugesGolden(t *testing.T) {
+	g := NewGauges(Placement{Anchor: BottomLeft, Offset: image.Pt(4, 4)},
+		TemperatureGauge("ASIC", 45),      // 正常
+		TemperatureGauge("Projector", 65), // 警告
+		TemperatureGauge("CPU", 80),       // 危险
+		TemperatureGauge("GPU", 130),      // 超出范围，条形截断到满格
+	)
+	dst := canvas(220, 100)
+	g.Draw(dst)
+	checkGolden(t, "gauges.png", dst)
+}
+
+func TestDepthCursorGolden(t *testing.T) {
+	// 深度图为画面的一半分辨率：左半边 1.5 米，右半边无效
+	img := depth.NewImage(80, 60)
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width/2; x++ {
+			img.Pix[y*img.Width+x] = 1500
+		}
+	}
+	dst := canvas(160, 120)
+	tests := []struct {
+		cursor image.Point
+		want   float32
+		ok     bool
+	}{
+		{image.Pt(30, 30), 1.5, true},
+		{image.Pt(150, 30), 0, false},  // 无效区域显示 "-- m"
+		{image.Pt(60, 110), 1.5, true}, // 靠近下边缘，标签翻转到上方
+	}
+	for _, tt := range tests {
+		c := NewDepthCursor()
+		c.Update(tt.cursor, img, depth.DefaultDepthScale)
+		d, ok := c.Distance(dst.Bounds())
+		if ok != tt.ok || (ok && (d < tt.want-1e-4 || d > tt.want+1e-4)) {
+			t.Errorf("Distance at %v = %g, %v, want %g, %v", tt.cursor, d, ok, tt.want, tt.ok)
+		}
+		c.Draw(dst)
+	}
+	checkGolden(t, "cursor.png", dst)
+}
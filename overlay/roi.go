@@ -0,0 +1,186 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+)
+
+// ROIState 是一个区域及其当前触发状态
+type ROIState struct {
+	Region roi.Region
+	Active bool      // 已触发且尚未解除
+	Stats  roi.Stats // 最近一帧的统计
+}
+
+// ROIOverlay 绘制 ROI 矩形/多边形及其触发状态
+type ROIOverlay struct {
+	RefWidth  int // 区域坐标的参考分辨率，0 表示与画面分辨率相同
+	RefHeight int
+
+	Regions     []ROIState
+	IdleColor   color.RGBA
+	ActiveColor color.RGBA
+	Thickness   int
+	ShowStats   bool // 在标签中显示命中点数
+	Style       Style
+}
+
+// NewROIOverlay 创建 ROI 组件，未触发为绿色，触发为红色
+func NewROIOverlay(refWidth, refHeight int) *ROIOverlay {
+	s := DefaultStyle()
+	s.Padding = 2
+	return &ROIOverlay{
+		RefWidth:    refWidth,
+		RefHeight:   refHeight,
+		IdleColor:   color.RGBA{0, 220, 0, 255},
+		ActiveColor: color.RGBA{255, 40, 40, 255},
+		Thickness:   2,
+		ShowStats:   true,
+		Style:       s,
+	}
+}
+
+// Update 从触发引擎读取区域定义、触发状态和统计
+func (o *ROIOverlay) Update(e *roi.Engine) {
+	regions := e.Regions()
+	o.Regions = o.Regions[:0]
+	for _, r := range regions {
+		st, _ := e.Stats(r.Name)
+		o.Regions = append(o.Regions, ROIState{Region: r, Active: e.Active(r.Name), Stats: st})
+	}
+	if w, h := e.ReferenceSize(); w > 0 && h > 0 {
+		o.RefWidth, o.RefHeight = w, h
+	}
+}
+
+// Draw 绘制所有区域
+func (o *ROIOverlay) Draw(dst *image.RGBA) {
+	b := dst.Bounds()
+	sx, sy := 1.0, 1.0
+	if o.RefWidth > 0 && o.RefHeight > 0 {
+		sx = float64(b.Dx()) / float64(o.RefWidth)
+		sy = float64(b.Dy()) / float64(o.RefHeight)
+	}
+	toDst := func(x, y float64) image.Point {
+		return image.Pt(b.Min.X+int(math.Round(x*sx)), b.Min.Y+int(math.Round(y*sy)))
+	}
+
+	for _, st := range o.Regions {
+		c := o.IdleColor
+		if st.Active {
+			c = o.ActiveColor
+		}
+
+		r := st.Region
+		var labelAt image.Point
+		if r.Rect != nil {
+			rect := image.Rectangle{
+				Min: toDst(float64(r.Rect.X), float64(r.Rect.Y)),
+				Max: toDst(float64(r.Rect.X+r.Rect.W), float64(r.Rect.Y+r.Rect.H)),
+			}
+			StrokeRect(dst, rect, c, o.Thickness)
+			labelAt = rect.Min
+		} else {
+			for i := range r.Polygon {
+				p, q := r.Polygon[i], r.Polygon[(i+1)%len(r.Polygon)]
+				Line(dst, toDst(p.X, p.Y), toDst(q.X, q.Y), c, o.Thickness)
+			}
+			bounds := r.Bounds()
+			labelAt = toDst(float64(bounds.X), float64(bounds.Y))
+		}
+
+		label := r.Name
+		if o.ShowStats {
+			label = fmt.Sprintf("%s %d/%d", r.Name, st.Stats.Points, st.Stats.Threshold)
+		}
+		style := o.Style
+		style.Background = WithAlpha(c, 180)
+		size := MeasureText([]string{label}, style).Add(image.Pt(2*style.Padding, 2*style.Padding))
+		// 标签放在框的上方，超出画面时放到框内
+		y := labelAt.Y - size.Y
+		if y < b.Min.Y {
+			y = labelAt.Y
+		}
+		DrawTextBox(dst, Placement{Anchor: TopLeft, Offset: image.Pt(labelAt.X-b.Min.X, y-b.Min.Y)}, []string{label}, style)
+	}
+}
+
+// DepthCursor 在光标处绘制十字线并显示该处距离
+type DepthCursor struct {
+	Cursor image.Point // 画面坐标
+	Depth  *depth.Image
+	Scale  float32 // 深度比例（米/单位）
+	Radius int     // 取光标周围 (2r+1)^2 窗口内有效深度的中值，0 表示只取单点
+
+	Color color.RGBA
+	Size  int // 十字线半长（像素）
+	Style Style
+}
+
+// NewDepthCursor 创建光标深度组件
+func NewDepthCursor() *DepthCursor {
+	s := DefaultStyle()
+	s.Padding = 3
+	return &DepthCursor{Radius: 2, Color: color.RGBA{255, 255, 0, 255}, Size: 8, Style: s}
+}
+
+// Update 更新光标位置和深度数据
+func (c *DepthCursor) Update(cursor image.Point, img *depth.Image, scale float32) {
+	c.Cursor, c.Depth, c.Scale = cursor, img, scale
+}
+
+// Distance 返回光标处的距离（米），dst 为画面尺寸，深度图分辨率不同时按比例换算
+func (c *DepthCursor) Distance(dst image.Rectangle) (float32, bool) {
+	img := c.Depth
+	if img == nil || img.Width <= 0 || img.Height <= 0 || dst.Dx() <= 0 || dst.Dy() <= 0 {
+		return 0, false
+	}
+	x := (c.Cursor.X - dst.Min.X) * img.Width / dst.Dx()
+	y := (c.Cursor.Y - dst.Min.Y) * img.Height / dst.Dy()
+
+	var vals []uint16
+	for yy := y - c.Radius; yy <= y+c.Radius; yy++ {
+		for xx := x - c.Radius; xx <= x+c.Radius; xx++ {
+			if v := img.At(xx, yy); v != 0 {
+				vals = append(vals, v)
+			}
+		}
+	}
+	if len(vals) == 0 {
+		return 0, false
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+	return float32(vals[len(vals)/2]) * c.Scale, true
+}
+
+// Draw 绘制十字线和距离
+func (c *DepthCursor) Draw(dst *image.RGBA) {
+	b := dst.Bounds()
+	p := c.Cursor
+	if !p.In(b) {
+		return
+	}
+	Line(dst, image.Pt(p.X-c.Size, p.Y), image.Pt(p.X+c.Size, p.Y), c.Color, 1)
+	Line(dst, image.Pt(p.X, p.Y-c.Size), image.Pt(p.X, p.Y+c.Size), c.Color, 1)
+
+	text := "-- m"
+	if d, ok := c.Distance(b); ok {
+		text = fmt.Sprintf("%.3f m", d)
+	}
+	size := MeasureText([]string{text}, c.Style).Add(image.Pt(2*c.Style.Padding, 2*c.Style.Padding))
+	// 标签放在光标右下方，靠近边缘时翻转到另一侧
+	x, y := p.X+c.Size+2, p.Y+c.Size+2
+	if x+size.X > b.Max.X {
+		x = p.X - c.Size - 2 - size.X
+	}
+	if y+size.Y > b.Max.Y {
+		y = p.Y - c.Size - 2 - size.Y
+	}
+	DrawTextBox(dst, Placement{Anchor: TopLeft, Offset: image.Pt(x-b.Min.X, y-b.Min.Y)}, []string{text}, c.Style)
+}
@@ -0,0 +1,126 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"sync"
+	"time"
+)
+
+// TextBlock 是多行文本组件
+type TextBlock struct {
+	Placement
+	Style Style
+	Lines []string
+}
+
+// NewTextBlock 创建使用默认样式的文本块
+func NewTextBlock(p Placement, lines ...string) *TextBlock {
+	return &TextBlock{Placement: p, Style: DefaultStyle(), Lines: lines}
+}
+
+// SetLines 替换文本内容
+func (t *TextBlock) SetLines(lines ...string) {
+	t.Lines = lines
+}
+
+// Draw 绘制文本块
+func (t *TextBlock) Draw(dst *image.RGBA) {
+	if len(t.Lines) == 0 {
+		return
+	}
+	DrawTextBox(dst, t.Placement, t.Lines, t.Style)
+}
+
+// FPSCounter 统计滑动窗口内的帧率，每帧调用 Tick
+// 可以与渲染在不同 goroutine 中调用
+type FPSCounter struct {
+	Placement
+	Style  Style
+	Label  string        // 显示前缀，默认 "FPS"
+	Window time.Duration // 统计窗口，默认 1 秒
+
+	mu    sync.Mutex
+	ticks []time.Time
+}
+
+// NewFPSCounter 创建帧率组件
+func NewFPSCounter(p Placement) *FPSCounter {
+	return &FPSCounter{Placement: p, Style: DefaultStyle(), Label: "FPS", Window: time.Second}
+}
+
+// Tick 记录一帧，now 通常为 time.Now()；传入固定时间可得到确定的结果
+func (f *FPSCounter) Tick(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ticks = append(f.ticks, now)
+	window := f.Window
+	if window <= 0 {
+		window = time.Second
+	}
+	i := 0
+	for i < len(f.ticks) && now.Sub(f.ticks[i]) > window {
+		i++
+	}
+	f.ticks = f.ticks[i:]
+}
+
+// FPS 返回当前帧率，少于两帧时为 0
+func (f *FPSCounter) FPS() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.ticks) < 2 {
+		return 0
+	}
+	span := f.ticks[len(f.ticks)-1].Sub(f.ticks[0]).Seconds()
+	if span <= 0 {
+		return 0
+	}
+	return float64(len(f.ticks)-1) / span
+}
+
+// Draw 绘制帧率
+func (f *FPSCounter) Draw(dst *image.RGBA) {
+	DrawTextBox(dst, f.Placement, []string{fmt.Sprintf("%s: %.1f", f.Label, f.FPS())}, f.Style)
+}
+
+// TimestampDomainName 返回时间戳域名称，取值与 rs.TimestampDomain 一致
+func TimestampDomainName(domain int) string {
+	switch domain {
+	case 0:
+		return "Hardware Clock"
+	case 1:
+		return "System Time"
+	case 2:
+		return "Global Time"
+	}
+	return fmt.Sprintf("Unknown (%d)", domain)
+}
+
+// TimestampInfo 显示帧号、帧时间戳和时间戳域
+type TimestampInfo struct {
+	Placement
+	Style Style
+
+	FrameNumber uint64
+	Timestamp   float64 // 毫秒
+	Domain      int     // rs.TimestampDomain
+}
+
+// NewTimestampInfo 创建时间戳组件
+func NewTimestampInfo(p Placement) *TimestampInfo {
+	return &TimestampInfo{Placement: p, Style: DefaultStyle()}
+}
+
+// Update 更新显示的帧信息
+func (t *TimestampInfo) Update(frameNumber uint64, timestamp float64, domain int) {
+	t.FrameNumber, t.Timestamp, t.Domain = frameNumber, timestamp, domain
+}
+
+// Draw 绘制时间戳信息
+func (t *TimestampInfo) Draw(dst *image.RGBA) {
+	DrawTextBox(dst, t.Placement, []string{
+		fmt.Sprintf("Frame: %d", t.FrameNumber),
+		fmt.Sprintf("TS: %.2f ms (%s)", t.Timestamp, TimestampDomainName(t.Domain)),
+	}, t.Style)
+}
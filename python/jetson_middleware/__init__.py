@@ -0,0 +1,57 @@
+"""jetson-rs-middleware 共享库 (libjetson_middleware.so) 的 Python 绑定。
+
+基于 ctypes，帧数据以 numpy 数组返回；流配置、处理链、对齐和 ROI 触发由 Go 中间件完成，
+与 C 接口保持一致。
+"""
+
+from ._native import (
+    JM_FEATURE_ERROR_INFO,
+    JM_FEATURE_FRAME_INFO,
+    JM_FEATURE_MULTI_CAMERA,
+    JM_FEATURE_ROI,
+    JM_FEATURE_STREAM_CONFIG,
+    JM_FEATURE_VERSION_QUERY,
+    JM_FEATURE_ZERO_COPY,
+    JM_LOG_DEBUG,
+    JM_LOG_ERROR,
+    JM_LOG_INFO,
+    JM_LOG_NONE,
+    JM_LOG_WARN,
+)
+from .camera import (
+    Camera,
+    Frame,
+    LeasedFrame,
+    MiddlewareError,
+    devices,
+    features,
+    librealsense_version,
+    library,
+    set_log_callback,
+    version,
+)
+
+__all__ = [
+    "Camera",
+    "Frame",
+    "LeasedFrame",
+    "MiddlewareError",
+    "devices",
+    "features",
+    "librealsense_version",
+    "library",
+    "set_log_callback",
+    "version",
+    "JM_LOG_DEBUG",
+    "JM_LOG_INFO",
+    "JM_LOG_WARN",
+    "JM_LOG_ERROR",
+    "JM_LOG_NONE",
+    "JM_FEATURE_MULTI_CAMERA",
+    "JM_FEATURE_ERROR_INFO",
+    "JM_FEATURE_STREAM_CONFIG",
+    "JM_FEATURE_FRAME_INFO",
+    "JM_FEATURE_ZERO_COPY",
+    "JM_FEATURE_VERSION_QUERY",
+    "JM_FEATURE_ROI",
+]
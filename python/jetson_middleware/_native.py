@@ -0,0 +1,162 @@
+"""libjetson_middleware.so 的 ctypes 声明，与 cmd/lib-main 生成的头文件一一对应。
+
+导出函数的整型参数和返回值在头文件中为 GoInt (64 位)，这里统一声明为 c_int64。
+"""
+
+import ctypes
+import os
+
+GoInt = ctypes.c_int64
+
+# 错误码，与 JM_ErrorCode 一致
+JM_OK = 0
+JM_ERR_INVALID_HANDLE = -1
+JM_ERR_REALSENSE = -2
+JM_ERR_STATE = -3
+JM_ERR_INVALID_ARG = -4
+JM_ERR_NO_DEVICE = -5
+JM_ERR_DEVICE_BUSY = -6
+JM_ERR_TIMEOUT = -7
+JM_ERR_BUFFER_TOO_SMALL = -8
+JM_ERR_NO_FRAME = -9
+
+# 绑定对应的接口主版本，与 JM_API_VERSION_MAJOR 一致
+API_VERSION_MAJOR = 2
+
+# 功能位，与 JM_Feature 一致
+JM_FEATURE_MULTI_CAMERA = 1 << 0
+JM_FEATURE_ERROR_INFO = 1 << 1
+JM_FEATURE_STREAM_CONFIG = 1 << 2
+JM_FEATURE_FRAME_INFO = 1 << 3
+JM_FEATURE_ZERO_COPY = 1 << 4
+JM_FEATURE_VERSION_QUERY = 1 << 5
+JM_FEATURE_ROI = 1 << 6
+
+# 日志级别，与 JM_LogLevel 一致
+JM_LOG_DEBUG = 0
+JM_LOG_INFO = 1
+JM_LOG_WARN = 2
+JM_LOG_ERROR = 3
+JM_LOG_FATAL = 4
+JM_LOG_NONE = 5
+
+
+class TelemetryInfo(ctypes.Structure):
+    _fields_ = [
+        ("asic_temp", ctypes.c_float),
+        ("projector_temp", ctypes.c_float),
+        ("sync_mode", ctypes.c_float),
+        ("usb_type", ctypes.c_char * 32),
+    ]
+
+
+class FrameInfo(ctypes.Structure):
+    """对应 JM_FrameInfo。"""
+
+    _fields_ = [
+        ("stream", ctypes.c_int),
+        ("index", ctypes.c_int),
+        ("width", ctypes.c_int),
+        ("height", ctypes.c_int),
+        ("stride", ctypes.c_int),
+        ("bytes_per_pixel", ctypes.c_int),
+        ("format", ctypes.c_int),
+        ("format_name", ctypes.c_char * 16),
+        ("timestamp", ctypes.c_double),
+        ("timestamp_domain", ctypes.c_int),
+        ("frame_number", ctypes.c_ulonglong),
+        ("depth_scale", ctypes.c_float),
+        ("data_size", ctypes.c_int),
+    ]
+
+
+LogCallback = ctypes.CFUNCTYPE(None, ctypes.c_int, ctypes.c_char_p, ctypes.c_char_p, ctypes.c_void_p)
+FrameCallback = ctypes.CFUNCTYPE(
+    ctypes.c_int, ctypes.c_int, ctypes.c_int, ctypes.POINTER(FrameInfo), ctypes.c_void_p, ctypes.c_void_p
+)
+
+_c_char_p = ctypes.c_char_p
+_c_void_p = ctypes.c_void_p
+
+# 函数原型: 名称 -> (参数类型, 返回类型)
+_PROTOTYPES = {
+    "JM_GetVersion": ((), _c_char_p),
+    "JM_GetApiVersion": ((), GoInt),
+    "JM_GetLibrealsenseVersion": ((), _c_char_p),
+    "JM_GetFeatures": ((), GoInt),
+    "JM_GetLastError": ((_c_char_p, GoInt), GoInt),
+    "JM_GetErrorName": ((GoInt,), _c_char_p),
+    "JM_SetLogCallback": ((LogCallback, _c_void_p, GoInt), GoInt),
+    "JM_GetDeviceCount": ((), GoInt),
+    "JM_GetDeviceSerial": ((GoInt, _c_char_p, GoInt), GoInt),
+    "JM_Open": ((_c_char_p,), GoInt),
+    "JM_StartStream": ((GoInt, GoInt, GoInt, GoInt), GoInt),
+    "JM_StopStream": ((GoInt,), GoInt),
+    "JM_WaitForFrames": ((GoInt, _c_void_p, _c_void_p, GoInt), GoInt),
+    "JM_GetTelemetry": ((GoInt, ctypes.POINTER(TelemetryInfo)), GoInt),
+    "JM_Close": ((GoInt,), GoInt),
+    "JM_EnableStream": ((GoInt, _c_char_p, GoInt, GoInt, GoInt, GoInt, _c_char_p), GoInt),
+    "JM_DisableAllStreams": ((GoInt,), GoInt),
+    "JM_Start": ((GoInt,), GoInt),
+    "JM_ConfigureStreams": ((GoInt, _c_char_p), GoInt),
+    "JM_GetCapabilities": ((GoInt, _c_char_p, GoInt), GoInt),
+    "JM_SetVisualPreset": ((GoInt, _c_char_p), GoInt),
+    "JM_SetFilters": ((GoInt, _c_char_p), GoInt),
+    "JM_FetchFrames": ((GoInt, GoInt), GoInt),
+    "JM_FetchAlignedFrames": ((GoInt, _c_char_p, GoInt), GoInt),
+    "JM_GetFrameInfo": ((GoInt, _c_char_p, GoInt, ctypes.POINTER(FrameInfo)), GoInt),
+    "JM_CopyFrame": ((GoInt, _c_char_p, GoInt, _c_void_p, GoInt), GoInt),
+    "JM_AcquireFrame": (
+        (GoInt, _c_char_p, GoInt, ctypes.POINTER(FrameInfo), ctypes.POINTER(_c_void_p)),
+        GoInt,
+    ),
+    "JM_ReleaseFrame": ((GoInt,), GoInt),
+    "JM_SetFrameCallback": ((GoInt, FrameCallback, _c_void_p), GoInt),
+    "JM_SetROIs": ((GoInt, _c_char_p), GoInt),
+    "JM_EvaluateROIs": ((GoInt,), GoInt),
+    "JM_GetROIResult": ((GoInt, _c_char_p, GoInt), GoInt),
+}
+
+LIBRARY_NAME = "libjetson_middleware.so"
+
+
+def _candidates():
+    """按优先级返回共享库的候选路径。"""
+    env = os.environ.get("JM_LIBRARY")
+    if env:
+        yield env
+    here = os.path.dirname(os.path.abspath(__file__))
+    yield os.path.join(here, LIBRARY_NAME)
+    # 仓库内使用 make build-so 的输出
+    yield os.path.join(here, "..", "..", "build", LIBRARY_NAME)
+    # 交给动态链接器按 LD_LIBRARY_PATH 查找
+    yield LIBRARY_NAME
+
+
+def load(path=None):
+    """加载共享库并声明函数原型；path 为空时依次尝试 $JM_LIBRARY、包目录、build/ 和系统路径。
+
+    共享库缺少导出函数或接口主版本不一致时抛出 OSError。
+    """
+    paths = [path] if path else list(_candidates())
+    errors = []
+    for p in paths:
+        if os.sep in p and not os.path.exists(p):
+            continue
+        try:
+            lib = ctypes.CDLL(p)
+        except OSError as e:
+            errors.append("%s: %s" % (p, e))
+            continue
+        try:
+            for name, (argtypes, restype) in _PROTOTYPES.items():
+                fn = getattr(lib, name)
+                fn.argtypes = argtypes
+                fn.restype = restype
+        except AttributeError as e:
+            raise OSError("%s is older than these bindings: %s" % (p, e)) from None
+        major = lib.JM_GetApiVersion() // 100
+        if major != API_VERSION_MAJOR:
+            raise OSError("%s has API version %d, bindings require %d" % (p, major, API_VERSION_MAJOR))
+        return lib
+    raise OSError("cannot load %s (set JM_LIBRARY): %s" % (LIBRARY_NAME, "; ".join(errors) or "not found"))
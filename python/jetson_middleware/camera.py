@@ -0,0 +1,336 @@
+"""相机句柄和帧访问的 Python 封装，帧以 numpy 数组返回。"""
+
+import ctypes
+import json
+import threading
+import traceback
+
+import numpy as np
+
+from . import _native
+
+# 像素格式名称 (JM_FrameInfo.format_name) -> (dtype, 通道数)
+_FORMATS = {
+    "Z16": (np.uint16, 1),
+    "Y8": (np.uint8, 1),
+    "Y16": (np.uint16, 1),
+    "RGB8": (np.uint8, 3),
+    "BGR8": (np.uint8, 3),
+    "RGBA8": (np.uint8, 4),
+    "BGRA8": (np.uint8, 4),
+}
+
+_lib = None
+_lib_lock = threading.Lock()
+
+
+def library(path=None):
+    """返回已加载的共享库，首次调用时加载。"""
+    global _lib
+    with _lib_lock:
+        if _lib is None:
+            _lib = _native.load(path)
+        return _lib
+
+
+class MiddlewareError(RuntimeError):
+    """JM_* 函数失败，code 为 JM_ErrorCode，message 为调用线程的最后错误。"""
+
+    def __init__(self, code, name, message):
+        super().__init__("%s (%d): %s" % (name, code, message))
+        self.code = code
+        self.name = name
+        self.message = message
+
+
+def _error(code):
+    lib = library()
+    n = lib.JM_GetLastError(None, 0)
+    buf = ctypes.create_string_buffer(n + 1)
+    lib.JM_GetLastError(buf, len(buf))
+    name = lib.JM_GetErrorName(code).decode()
+    return MiddlewareError(code, name, buf.value.decode(errors="replace"))
+
+
+def _check(code):
+    """负值转换为 MiddlewareError，否则原样返回。"""
+    if code < 0:
+        raise _error(code)
+    return code
+
+
+def _encode(s):
+    return None if s is None else s.encode()
+
+
+def version():
+    """返回中间件版本字符串，例如 "v2.1.0-20261019"。"""
+    return library().JM_GetVersion().decode()
+
+
+def librealsense_version():
+    """返回运行时链接的 librealsense 版本，获取失败时为空字符串。"""
+    return library().JM_GetLibrealsenseVersion().decode()
+
+
+def features():
+    """返回共享库支持的功能位 (JM_FEATURE_* 的组合)。"""
+    return library().JM_GetFeatures()
+
+
+def devices():
+    """返回当前连接的设备序列号列表。"""
+    lib = library()
+    count = _check(lib.JM_GetDeviceCount())
+    buf = ctypes.create_string_buffer(64)
+    serials = []
+    for i in range(count):
+        _check(lib.JM_GetDeviceSerial(i, buf, len(buf)))
+        serials.append(buf.value.decode())
+    return serials
+
+
+# 保存日志回调，避免 ctypes 回调对象被回收
+_log_callback = None
+
+
+def set_log_callback(fn, level=_native.JM_LOG_INFO):
+    """设置日志回调 fn(level, source, message)，fn 为 None 时关闭日志。"""
+    global _log_callback
+    lib = library()
+    if fn is None:
+        _check(lib.JM_SetLogCallback(_native.LogCallback(), None, _native.JM_LOG_NONE))
+        _log_callback = None
+        return
+
+    def trampoline(lvl, source, message, user):
+        fn(lvl, source.decode(errors="replace"), message.decode(errors="replace"))
+
+    cb = _native.LogCallback(trampoline)
+    _check(lib.JM_SetLogCallback(cb, None, level))
+    _log_callback = cb
+
+
+def _to_array(info, data):
+    """将帧数据 (bytes-like 或 C 指针) 转换为 numpy 数组，去除行尾填充。"""
+    name = info.format_name.decode()
+    if name not in _FORMATS:
+        raise ValueError("unsupported frame format %s" % name)
+    dtype, channels = _FORMATS[name]
+    h, w, stride = info.height, info.width, info.stride
+    if isinstance(data, int):
+        data = (ctypes.c_uint8 * (stride * h)).from_address(data)
+    rows = np.frombuffer(data, dtype=np.uint8, count=stride * h).reshape(h, stride)
+    row_bytes = w * channels * np.dtype(dtype).itemsize
+    arr = rows[:, :row_bytes].view(dtype)
+    return arr.reshape(h, w, channels) if channels > 1 else arr.reshape(h, w)
+
+
+class Frame:
+    """一帧数据，data 为 numpy 数组 (深度为 uint16 的 HxW，彩色为 uint8 的 HxWx3)。"""
+
+    def __init__(self, info, data):
+        self.stream = info.stream
+        self.index = info.index
+        self.width = info.width
+        self.height = info.height
+        self.format = info.format_name.decode()
+        self.timestamp = info.timestamp
+        self.timestamp_domain = info.timestamp_domain
+        self.frame_number = info.frame_number
+        self.depth_scale = info.depth_scale
+        self.data = data
+
+    def meters(self):
+        """深度帧转换为以米为单位的 float32 数组。"""
+        if self.format != "Z16":
+            raise ValueError("not a depth frame: %s" % self.format)
+        return self.data.astype(np.float32) * self.depth_scale
+
+
+class LeasedFrame(Frame):
+    """借出的帧，data 直接引用 librealsense 的帧缓冲区（只读），release 后不可再访问。
+
+    可作为上下文管理器使用，退出时自动归还。
+    """
+
+    def __init__(self, lease, info, data):
+        super().__init__(info, data)
+        self.lease = lease
+        self.data.flags.writeable = False
+
+    def release(self):
+        if self.lease > 0:
+            lease, self.lease = self.lease, 0
+            self.data = None
+            _check(library().JM_ReleaseFrame(lease))
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc):
+        self.release()
+
+
+class Camera:
+    """一台相机，对应 JM_Open 返回的句柄。可作为上下文管理器使用。"""
+
+    def __init__(self, serial=None):
+        self._lib = library()
+        self.handle = _check(self._lib.JM_Open(_encode(serial)))
+        self._frame_callback = None
+
+    def close(self):
+        if self.handle > 0:
+            handle, self.handle = self.handle, 0
+            _check(self._lib.JM_Close(handle))
+            self._frame_callback = None
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *exc):
+        self.close()
+
+    # 流配置
+
+    def enable_stream(self, stream, width=0, height=0, fps=0, format=None, index=0):
+        """启用一个流，参数含义同 JM_EnableStream。"""
+        _check(self._lib.JM_EnableStream(self.handle, stream.encode(), index, width, height, fps, _encode(format)))
+
+    def disable_all_streams(self):
+        _check(self._lib.JM_DisableAllStreams(self.handle))
+
+    def configure(self, streams, preset=None, filters=None):
+        """以 JM_ConfigureStreams 的 JSON 格式设置流、视觉预设和处理链。
+
+        streams 为 dict 列表，例如 [{"stream": "depth", "width": 848, "height": 480, "fps": 30, "format": "z16"}]，
+        filters 为 rs.ChainConfig 格式的 dict，例如 {"stages": [{"type": "spatial"}]}。
+        """
+        cfg = {"streams": list(streams)}
+        if preset is not None:
+            cfg["preset"] = preset
+        if filters is not None:
+            cfg["filters"] = filters
+        _check(self._lib.JM_ConfigureStreams(self.handle, json.dumps(cfg).encode()))
+
+    def capabilities(self):
+        """返回设备支持的流配置列表。"""
+        need = _check(self._lib.JM_GetCapabilities(self.handle, None, 0))
+        buf = ctypes.create_string_buffer(need)
+        _check(self._lib.JM_GetCapabilities(self.handle, buf, len(buf)))
+        return json.loads(buf.value.decode())
+
+    def set_visual_preset(self, name):
+        _check(self._lib.JM_SetVisualPreset(self.handle, name.encode()))
+
+    def set_filters(self, filters):
+        """设置处理链 (rs.ChainConfig 格式的 dict)，None 表示清除。"""
+        cfg = None if filters is None else json.dumps(filters).encode()
+        _check(self._lib.JM_SetFilters(self.handle, cfg))
+
+    # 取流
+
+    def start(self, width=None, height=None, fps=None):
+        """开始取流；给出 width/height/fps 时等同 JM_StartStream (深度 Z16 + 彩色 RGB8)。"""
+        if width is None:
+            _check(self._lib.JM_Start(self.handle))
+        else:
+            _check(self._lib.JM_StartStream(self.handle, width, height, fps))
+
+    def stop(self):
+        _check(self._lib.JM_StopStream(self.handle))
+
+    def fetch(self, timeout_ms=1000, align_to=None):
+        """等待一组帧并保存为当前帧集，align_to 为 "color"/"depth"/"infrared" 时执行对齐。"""
+        if align_to is None:
+            _check(self._lib.JM_FetchFrames(self.handle, timeout_ms))
+        else:
+            _check(self._lib.JM_FetchAlignedFrames(self.handle, align_to.encode(), timeout_ms))
+
+    def frame(self, stream, index=0):
+        """从当前帧集中拷贝一帧，返回 Frame。"""
+        info = _native.FrameInfo()
+        _check(self._lib.JM_GetFrameInfo(self.handle, stream.encode(), index, ctypes.byref(info)))
+        buf = np.empty(info.data_size, dtype=np.uint8)
+        _check(self._lib.JM_CopyFrame(self.handle, stream.encode(), index, buf.ctypes.data, buf.nbytes))
+        return Frame(info, _to_array(info, buf))
+
+    def acquire(self, stream, index=0):
+        """从当前帧集中借出一帧（零拷贝），返回 LeasedFrame，用完需 release。"""
+        info = _native.FrameInfo()
+        data = ctypes.c_void_p()
+        lease = _check(
+            self._lib.JM_AcquireFrame(self.handle, stream.encode(), index, ctypes.byref(info), ctypes.byref(data))
+        )
+        return LeasedFrame(lease, info, _to_array(info, data.value))
+
+    def wait(self, timeout_ms=1000, align_to=None, streams=("depth", "color")):
+        """等待一组帧并拷贝指定的流，返回 {流名称: Frame}。"""
+        self.fetch(timeout_ms, align_to)
+        return {s: self.frame(s) for s in streams}
+
+    # ROI 触发
+
+    def set_rois(self, config):
+        """设置 ROI 区域和体积 (roi.Config 格式的 dict)，None 表示清除。
+
+        例如 {"reference_width": 640, "reference_height": 480,
+        "regions": [{"name": "door", "rect": {"x": 270, "y": 190, "w": 100, "h": 100},
+        "min_distance": 0.1, "max_distance": 1.5, "min_points": 500}]}。
+        """
+        cfg = None if config is None else json.dumps(config).encode()
+        _check(self._lib.JM_SetROIs(self.handle, cfg))
+
+    def evaluate_rois(self):
+        """对当前帧集（见 fetch，区域按彩色坐标定义时应 align_to="color"）判定一次 ROI。
+
+        返回 JM_GetROIResult 的 dict: frame_number、timestamp、events (本帧的触发事件)、
+        stats ({名称: 统计}) 和 active ({名称: 是否处于已触发状态})。
+        """
+        _check(self._lib.JM_EvaluateROIs(self.handle))
+        return self.roi_result()
+
+    def roi_result(self):
+        """返回最近一次 evaluate_rois 的结果 dict。"""
+        need = _check(self._lib.JM_GetROIResult(self.handle, None, 0))
+        buf = ctypes.create_string_buffer(need)
+        _check(self._lib.JM_GetROIResult(self.handle, buf, len(buf)))
+        return json.loads(buf.value.decode())
+
+    def set_frame_callback(self, fn):
+        """设置帧回调 fn(frame)，在推送线程中调用，fn 为 None 时取消；须在 start 之前调用。
+
+        frame 为 LeasedFrame，fn 返回 True 时保留该帧（之后自行 release），否则回调返回后自动归还。
+        """
+        if fn is None:
+            _check(self._lib.JM_SetFrameCallback(self.handle, _native.FrameCallback(), None))
+            self._frame_callback = None
+            return
+
+        def trampoline(handle, lease, info, data, user):
+            frame = LeasedFrame(lease, info.contents, _to_array(info.contents, data))
+            try:
+                keep = bool(fn(frame))
+            except Exception:
+                traceback.print_exc()
+                keep = False
+            if not keep:
+                # 回调返回后由库归还，数据不再可用
+                frame.lease, frame.data = 0, None
+            return 1 if keep else 0
+
+        cb = _native.FrameCallback(trampoline)
+        _check(self._lib.JM_SetFrameCallback(self.handle, cb, None))
+        self._frame_callback = cb
+
+    def telemetry(self):
+        """返回遥测数据 dict。"""
+        info = _native.TelemetryInfo()
+        _check(self._lib.JM_GetTelemetry(self.handle, ctypes.byref(info)))
+        return {
+            "asic_temp": info.asic_temp,
+            "projector_temp": info.projector_temp,
+            "sync_mode": info.sync_mode,
+            "usb_type": info.usb_type.decode(),
+        }
@@ -0,0 +1,47 @@
+"""测试夹具：编译 fake_jm.c 作为共享库的替身，不需要相机、librealsense 或 Go 工具链。"""
+
+import os
+import shutil
+import subprocess
+import sys
+
+import pytest
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+sys.path.insert(0, os.path.dirname(HERE))
+
+
+def _build(tmp_dir, name, *defines):
+    cc = os.environ.get("CC") or shutil.which("cc") or shutil.which("gcc")
+    if cc is None:
+        pytest.skip("no C compiler to build the fake library")
+    out = os.path.join(str(tmp_dir), name)
+    subprocess.check_call([cc, "-shared", "-fPIC", "-o", out, os.path.join(HERE, "fake_jm.c")] + list(defines))
+    return out
+
+
+@pytest.fixture(scope="session")
+def fake_lib_path(tmp_path_factory):
+    """与当前绑定版本一致的替身库路径。"""
+    return _build(tmp_path_factory.mktemp("fake"), "libjm_fake.so")
+
+
+@pytest.fixture(scope="session")
+def build_fake(tmp_path_factory):
+    """按编译选项构建替身库，用于版本不匹配、缺少导出函数等场景。"""
+
+    def build(name, *defines):
+        return _build(tmp_path_factory.mktemp("fake"), name, *defines)
+
+    return build
+
+
+@pytest.fixture
+def lib(fake_lib_path, monkeypatch):
+    """让 camera.library() 返回替身库。"""
+    pytest.importorskip("numpy")
+    from jetson_middleware import _native, camera
+
+    fake = _native.load(fake_lib_path)
+    monkeypatch.setattr(camera, "_lib", fake)
+    return fake
@@ -0,0 +1,284 @@
+/*
+ * libjetson_middleware.so 的测试替身，导出与绑定相同的 JM_* 函数，不需要相机和 librealsense。
+ * 由 conftest.py 在测试时用 cc 编译；行为只覆盖绑定测试需要的部分：
+ *   - 两帧带行尾填充的数据: "depth" (Z16 3x2, stride 8) 和 "color" (RGB8 2x2, stride 8)
+ *   - JM_Open("missing") 失败并设置 JM_GetLastError
+ *   - 借出/归还计数，fake_* 辅助函数供测试检查和触发帧回调
+ *   - JM_SetROIs 保存配置（不含 "regions" 的非空配置视为无效），JM_EvaluateROIs 产生一个固定的 "door" 事件
+ * 编译选项: -DFAKE_API_VERSION=<n> 修改接口版本，-DFAKE_OMIT_CALLBACK 去掉 JM_SetFrameCallback，
+ *           -DFAKE_OMIT_ROI 去掉 ROI 接口（模拟接口版本 2.1 的库）
+ */
+#include <stdio.h>
+#include <string.h>
+
+#ifndef FAKE_API_VERSION
+#define FAKE_API_VERSION 202
+#endif
+
+typedef long long GoInt;
+
+typedef struct {
+    int stream;
+    int index;
+    int width;
+    int height;
+    int stride;
+    int bytes_per_pixel;
+    int format;
+    char format_name[16];
+    double timestamp;
+    int timestamp_domain;
+    unsigned long long frame_number;
+    float depth_scale;
+    int data_size;
+} JM_FrameInfo;
+
+typedef int (*JM_FrameCallback)(int handle, int lease, const JM_FrameInfo* info, const void* data, void* user);
+
+static __thread char last_error[256];
+
+static GoInt fail(GoInt code, const char* msg) {
+    snprintf(last_error, sizeof(last_error), "%s", msg);
+    return code;
+}
+
+static GoInt ok(void) {
+    last_error[0] = '\0';
+    return 0;
+}
+
+/* depth: 3x2 Z16，每行 6 字节数据 + 2 字节填充 (0xEE) */
+static const unsigned char depth_data[16] = {
+    0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0xEE, 0xEE,
+    0x04, 0x00, 0x05, 0x00, 0x06, 0x01, 0xEE, 0xEE,
+};
+
+/* color: 2x2 RGB8，每行 6 字节数据 + 2 字节填充 (0xEE) */
+static const unsigned char color_data[16] = {
+    10, 11, 12, 20, 21, 22, 0xEE, 0xEE,
+    30, 31, 32, 40, 41, 42, 0xEE, 0xEE,
+};
+
+static int frame_info(const char* stream, JM_FrameInfo* info, const void** data) {
+    memset(info, 0, sizeof(*info));
+    info->stride = 8;
+    info->height = 2;
+    info->data_size = 16;
+    info->timestamp = 1234.5;
+    info->frame_number = 42;
+    if (stream != NULL && strcmp(stream, "depth") == 0) {
+        info->stream = 1;
+        info->width = 3;
+        info->bytes_per_pixel = 2;
+        info->format = 1;
+        strcpy(info->format_name, "Z16");
+        info->depth_scale = 0.001f;
+        *data = depth_data;
+        return 1;
+    }
+    if (stream != NULL && strcmp(stream, "color") == 0) {
+        info->stream = 2;
+        info->width = 2;
+        info->bytes_per_pixel = 3;
+        info->format = 5;
+        strcpy(info->format_name, "RGB8");
+        *data = color_data;
+        return 1;
+    }
+    return 0;
+}
+
+/* 借出计数 */
+static int next_lease = 1;
+static int outstanding = 0;
+static int released = 0;
+
+static JM_FrameCallback frame_cb = NULL;
+static void* frame_user = NULL;
+
+const char* JM_GetVersion(void) { return "v2.1.0-fake"; }
+GoInt JM_GetApiVersion(void) { return FAKE_API_VERSION; }
+const char* JM_GetLibrealsenseVersion(void) { return "0.0.0"; }
+GoInt JM_GetFeatures(void) { return 0x7f; }
+
+GoInt JM_GetLastError(char* buf, GoInt len) {
+    GoInt n = (GoInt)strlen(last_error);
+    if (buf != NULL && len > 0) {
+        GoInt c = n < len - 1 ? n : len - 1;
+        memcpy(buf, last_error, (size_t)c);
+        buf[c] = '\0';
+    }
+    return n;
+}
+
+const char* JM_GetErrorName(GoInt code) {
+    switch (code) {
+    case 0: return "JM_OK";
+    case -1: return "JM_ERR_INVALID_HANDLE";
+    case -3: return "JM_ERR_STATE";
+    case -4: return "JM_ERR_INVALID_ARG";
+    case -5: return "JM_ERR_NO_DEVICE";
+    case -8: return "JM_ERR_BUFFER_TOO_SMALL";
+    }
+    return "JM_ERR_UNKNOWN";
+}
+
+GoInt JM_SetLogCallback(void* cb, void* user, GoInt level) { return ok(); }
+GoInt JM_GetDeviceCount(void) { return 0; }
+GoInt JM_GetDeviceSerial(GoInt i, char* buf, GoInt len) { return fail(-4, "JM_GetDeviceSerial: index out of range"); }
+
+GoInt JM_Open(const char* serial) {
+    if (serial != NULL && strcmp(serial, "missing") == 0) {
+        return fail(-5, "JM_Open: no device with serial missing");
+    }
+    ok();
+    return 1;
+}
+
+GoInt JM_Close(GoInt h) {
+    if (h != 1) {
+        return fail(-1, "JM_Close: invalid handle");
+    }
+    return ok();
+}
+
+GoInt JM_StartStream(GoInt h, GoInt w, GoInt height, GoInt fps) { return ok(); }
+GoInt JM_StopStream(GoInt h) { return ok(); }
+GoInt JM_WaitForFrames(GoInt h, void* color, void* depth, GoInt timeout) { return ok(); }
+GoInt JM_GetTelemetry(GoInt h, void* info) { return ok(); }
+GoInt JM_EnableStream(GoInt h, const char* s, GoInt i, GoInt w, GoInt height, GoInt fps, const char* f) { return ok(); }
+GoInt JM_DisableAllStreams(GoInt h) { return ok(); }
+GoInt JM_Start(GoInt h) { return ok(); }
+GoInt JM_ConfigureStreams(GoInt h, const char* cfg) { return ok(); }
+GoInt JM_GetCapabilities(GoInt h, char* buf, GoInt len) {
+    if (buf == NULL) {
+        return 3;
+    }
+    strcpy(buf, "[]");
+    return 3;
+}
+GoInt JM_SetVisualPreset(GoInt h, const char* name) { return ok(); }
+GoInt JM_SetFilters(GoInt h, const char* cfg) { return ok(); }
+GoInt JM_FetchFrames(GoInt h, GoInt timeout) { return ok(); }
+GoInt JM_FetchAlignedFrames(GoInt h, const char* to, GoInt timeout) { return ok(); }
+
+GoInt JM_GetFrameInfo(GoInt h, const char* stream, GoInt index, JM_FrameInfo* info) {
+    const void* data;
+    if (!frame_info(stream, info, &data)) {
+        return fail(-9, "JM_GetFrameInfo: no such frame");
+    }
+    return ok();
+}
+
+GoInt JM_CopyFrame(GoInt h, const char* stream, GoInt index, void* buf, GoInt len) {
+    JM_FrameInfo info;
+    const void* data;
+    if (!frame_info(stream, &info, &data)) {
+        return fail(-9, "JM_CopyFrame: no such frame");
+    }
+    if (len < info.data_size) {
+        return fail(-8, "JM_CopyFrame: buffer too small");
+    }
+    memcpy(buf, data, (size_t)info.data_size);
+    ok();
+    return info.data_size;
+}
+
+GoInt JM_AcquireFrame(GoInt h, const char* stream, GoInt index, JM_FrameInfo* info, const void** data) {
+    if (!frame_info(stream, info, data)) {
+        return fail(-9, "JM_AcquireFrame: no such frame");
+    }
+    outstanding++;
+    ok();
+    return next_lease++;
+}
+
+GoInt JM_ReleaseFrame(GoInt lease) {
+    if (lease <= 0 || lease >= next_lease || outstanding == 0) {
+        return fail(-1, "JM_ReleaseFrame: invalid frame lease");
+    }
+    outstanding--;
+    released++;
+    return ok();
+}
+
+#ifndef FAKE_OMIT_CALLBACK
+GoInt JM_SetFrameCallback(GoInt h, JM_FrameCallback cb, void* user) {
+    frame_cb = cb;
+    frame_user = user;
+    return ok();
+}
+#endif
+
+#ifndef FAKE_OMIT_ROI
+static char roi_config[256];
+static int roi_evaluated = 0;
+
+static const char roi_result[] =
+    "{\"frame_number\":42,\"timestamp\":1234.5,"
+    "\"events\":[{\"region\":\"door\",\"frame_number\":42,\"stats\":{\"points\":3}}],"
+    "\"stats\":{\"door\":{\"pixels\":6,\"points\":3}},\"active\":{\"door\":true}}";
+
+GoInt JM_SetROIs(GoInt h, const char* cfg) {
+    if (cfg != NULL && strstr(cfg, "\"regions\"") == NULL && cfg[0] != '\0') {
+        return fail(-4, "JM_SetROIs: roi: parse config: no regions");
+    }
+    snprintf(roi_config, sizeof(roi_config), "%s", cfg != NULL ? cfg : "");
+    roi_evaluated = 0;
+    return ok();
+}
+
+GoInt JM_EvaluateROIs(GoInt h) {
+    if (roi_config[0] == '\0') {
+        return fail(-3, "JM_EvaluateROIs: no ROIs set, call JM_SetROIs first");
+    }
+    roi_evaluated = 1;
+    ok();
+    return 1;
+}
+
+GoInt JM_GetROIResult(GoInt h, char* buf, GoInt len) {
+    GoInt need = (GoInt)sizeof(roi_result);
+    if (!roi_evaluated) {
+        return fail(-3, "JM_GetROIResult: no ROI result, call JM_EvaluateROIs first");
+    }
+    if (buf == NULL) {
+        ok();
+        return need;
+    }
+    if (len < need) {
+        return fail(-8, "JM_GetROIResult: buffer too small");
+    }
+    memcpy(buf, roi_result, (size_t)need);
+    ok();
+    return need;
+}
+#endif
+
+/* 以下为测试辅助函数 */
+
+#ifndef FAKE_OMIT_ROI
+/* 返回最近一次 JM_SetROIs 收到的配置 */
+const char* fake_roi_config(void) { return roi_config; }
+#endif
+
+
+int fake_outstanding(void) { return outstanding; }
+int fake_released(void) { return released; }
+
+/* 模拟推送线程投递一帧，回调返回 0 时立即归还 */
+int fake_push_frame(const char* stream) {
+    JM_FrameInfo info;
+    const void* data;
+    if (frame_cb == NULL || !frame_info(stream, &info, &data)) {
+        return -1;
+    }
+    int lease = next_lease++;
+    outstanding++;
+    int keep = frame_cb(1, lease, &info, data, frame_user);
+    if (!keep) {
+        outstanding--;
+        released++;
+    }
+    return lease;
+}
@@ -0,0 +1,199 @@
+"""camera 模块的帧转换、错误传递和借出归还，基于 fake_jm.c 替身库。"""
+
+import ctypes
+import json
+
+import pytest
+
+np = pytest.importorskip("numpy")
+
+from jetson_middleware import _native, camera  # noqa: E402
+
+
+def make_info(name, width, height, stride):
+    info = _native.FrameInfo()
+    info.format_name = name.encode()
+    info.width, info.height, info.stride = width, height, stride
+    return info
+
+
+@pytest.mark.parametrize(
+    "name,width,height,stride,dtype,shape",
+    [
+        ("Z16", 3, 2, 8, np.uint16, (2, 3)),
+        ("Y8", 5, 2, 8, np.uint8, (2, 5)),
+        ("RGB8", 2, 2, 8, np.uint8, (2, 2, 3)),
+        ("BGRA8", 2, 2, 8, np.uint8, (2, 2, 4)),
+        ("Z16", 4, 2, 8, np.uint16, (2, 4)),  # 无填充
+    ],
+)
+def test_to_array_strips_row_padding(name, width, height, stride, dtype, shape):
+    channels = shape[2] if len(shape) == 3 else 1
+    row_bytes = width * channels * np.dtype(dtype).itemsize
+    data = bytearray(b"\xee" * (stride * height))
+    for y in range(height):
+        for x in range(row_bytes):
+            data[y * stride + x] = (y * 16 + x) & 0xFF
+
+    arr = camera._to_array(make_info(name, width, height, stride), bytes(data))
+    assert arr.dtype == dtype
+    assert arr.shape == shape
+    raw = np.ascontiguousarray(arr).view(np.uint8).reshape(height, row_bytes)
+    for y in range(height):
+        assert bytes(raw[y]) == bytes(data[y * stride : y * stride + row_bytes])
+
+
+def test_to_array_from_pointer():
+    buf = (ctypes.c_uint8 * 16)(*([1, 0, 2, 0, 3, 0, 0xEE, 0xEE] * 2))
+    arr = camera._to_array(make_info("Z16", 3, 2, 8), ctypes.addressof(buf))
+    assert arr.tolist() == [[1, 2, 3], [1, 2, 3]]
+
+
+def test_to_array_rejects_unknown_format():
+    with pytest.raises(ValueError, match="unsupported frame format YUYV"):
+        camera._to_array(make_info("YUYV", 2, 2, 4), bytes(8))
+
+
+def test_error_propagates_last_error(lib):
+    with pytest.raises(camera.MiddlewareError) as exc:
+        camera.Camera("missing")
+    err = exc.value
+    assert err.code == _native.JM_ERR_NO_DEVICE
+    assert err.name == "JM_ERR_NO_DEVICE"
+    assert err.message == "JM_Open: no device with serial missing"
+    assert "JM_ERR_NO_DEVICE (-5)" in str(err)
+
+
+def test_check_passes_through_non_negative(lib):
+    assert camera._check(7) == 7
+    with pytest.raises(camera.MiddlewareError) as exc:
+        camera._check(lib.JM_Close(99))
+    assert exc.value.code == _native.JM_ERR_INVALID_HANDLE
+    assert exc.value.message == "JM_Close: invalid handle"
+
+
+def test_frame_copy(lib):
+    with camera.Camera() as cam:
+        depth = cam.frame("depth")
+        color = cam.frame("color")
+    assert depth.data.tolist() == [[1, 2, 3], [4, 5, 0x106]]
+    assert depth.frame_number == 42 and depth.format == "Z16"
+    assert depth.meters()[1, 2] == pytest.approx(0.262)
+    assert color.data.shape == (2, 2, 3)
+    assert color.data[1, 1].tolist() == [40, 41, 42]
+
+
+def test_lease_release(lib):
+    start = lib.fake_outstanding()
+    with camera.Camera() as cam:
+        frame = cam.acquire("depth")
+        assert lib.fake_outstanding() == start + 1
+        assert frame.lease > 0
+        assert not frame.data.flags.writeable
+        assert frame.data.tolist() == [[1, 2, 3], [4, 5, 0x106]]
+
+        frame.release()
+        assert lib.fake_outstanding() == start
+        assert frame.lease == 0 and frame.data is None
+        frame.release()  # 重复归还不再调用 JM_ReleaseFrame
+        assert lib.fake_outstanding() == start
+
+        with cam.acquire("color") as f:
+            assert lib.fake_outstanding() == start + 1
+            assert f.data.shape == (2, 2, 3)
+        assert lib.fake_outstanding() == start
+
+
+def test_release_invalid_lease_raises(lib):
+    frame = camera.LeasedFrame(10**6, make_info("Z16", 1, 1, 2), np.zeros((1, 1), np.uint16))
+    with pytest.raises(camera.MiddlewareError) as exc:
+        frame.release()
+    assert exc.value.code == _native.JM_ERR_INVALID_HANDLE
+
+
+def test_frame_callback_release(lib):
+    lib.fake_push_frame.argtypes = [ctypes.c_char_p]
+    kept = []
+
+    def on_frame(frame):
+        kept.append(frame)
+        return frame.format == "RGB8"  # 保留彩色帧，深度帧自动归还
+
+    start = lib.fake_outstanding()
+    with camera.Camera() as cam:
+        cam.set_frame_callback(on_frame)
+        assert lib.fake_push_frame(b"depth") > 0
+        assert lib.fake_outstanding() == start
+        assert kept[0].lease == 0 and kept[0].data is None
+
+        assert lib.fake_push_frame(b"color") > 0
+        assert lib.fake_outstanding() == start + 1
+        assert kept[1].data[0, 0].tolist() == [10, 11, 12]
+        kept[1].release()
+        assert lib.fake_outstanding() == start
+        cam.set_frame_callback(None)
+
+
+def test_frame_callback_exception_releases(lib, capsys):
+    lib.fake_push_frame.argtypes = [ctypes.c_char_p]
+
+    def on_frame(frame):
+        raise RuntimeError("boom")
+
+    start = lib.fake_outstanding()
+    with camera.Camera() as cam:
+        cam.set_frame_callback(on_frame)
+        lib.fake_push_frame(b"depth")
+        cam.set_frame_callback(None)
+    assert lib.fake_outstanding() == start
+    assert "boom" in capsys.readouterr().err
+
+
+DOOR = {
+    "reference_width": 640,
+    "reference_height": 480,
+    "regions": [
+        {
+            "name": "door",
+            "rect": {"x": 270, "y": 190, "w": 100, "h": 100},
+            "min_distance": 0.1,
+            "max_distance": 1.5,
+            "min_points": 500,
+        }
+    ],
+}
+
+
+def test_rois(lib):
+    lib.fake_roi_config.restype = ctypes.c_char_p
+    with camera.Camera() as cam:
+        cam.set_rois(DOOR)
+        assert json.loads(lib.fake_roi_config()) == DOOR
+
+        result = cam.evaluate_rois()
+        assert result["frame_number"] == 42
+        assert [e["region"] for e in result["events"]] == ["door"]
+        assert result["stats"]["door"]["points"] == 3
+        assert result["active"] == {"door": True}
+        assert cam.roi_result() == result
+
+        cam.set_rois(None)
+        assert lib.fake_roi_config() == b""
+
+
+def test_roi_errors(lib):
+    with camera.Camera() as cam:
+        cam.set_rois(None)
+        with pytest.raises(camera.MiddlewareError) as exc:
+            cam.evaluate_rois()
+        assert exc.value.code == _native.JM_ERR_STATE
+        assert exc.value.message == "JM_EvaluateROIs: no ROIs set, call JM_SetROIs first"
+
+        # 替换配置后需要重新判定
+        with pytest.raises(camera.MiddlewareError) as exc:
+            cam.roi_result()
+        assert exc.value.name == "JM_ERR_STATE"
+
+        with pytest.raises(camera.MiddlewareError) as exc:
+            cam.set_rois({"volumes": []})
+        assert exc.value.code == _native.JM_ERR_INVALID_ARG
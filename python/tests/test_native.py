@@ -0,0 +1,53 @@
+"""_native.load 的加载和版本检查。"""
+
+import ctypes
+
+import pytest
+
+pytest.importorskip("numpy")  # 包的 __init__ 会导入 camera
+
+from jetson_middleware import _native  # noqa: E402
+
+
+def test_load_declares_prototypes(fake_lib_path):
+    lib = _native.load(fake_lib_path)
+    assert lib.JM_GetApiVersion() // 100 == _native.API_VERSION_MAJOR
+    assert lib.JM_GetVersion() == b"v2.1.0-fake"
+    assert lib.JM_GetApiVersion.restype is _native.GoInt
+    assert lib.JM_AcquireFrame.argtypes[3] is ctypes.POINTER(_native.FrameInfo)
+
+
+def test_load_rejects_other_major_version(build_fake):
+    path = build_fake("libjm_v3.so", "-DFAKE_API_VERSION=300")
+    with pytest.raises(OSError, match="API version 3, bindings require 2"):
+        _native.load(path)
+
+
+def test_load_accepts_newer_minor_version(build_fake):
+    path = build_fake("libjm_v299.so", "-DFAKE_API_VERSION=299")
+    assert _native.load(path).JM_GetApiVersion() == 299
+
+
+def test_load_rejects_missing_symbol(build_fake):
+    path = build_fake("libjm_old.so", "-DFAKE_OMIT_CALLBACK")
+    with pytest.raises(OSError, match="older than these bindings.*JM_SetFrameCallback"):
+        _native.load(path)
+
+
+def test_load_reports_missing_library(tmp_path, monkeypatch):
+    monkeypatch.delenv("JM_LIBRARY", raising=False)
+    with pytest.raises(OSError, match="cannot load"):
+        _native.load(str(tmp_path / "nope" / _native.LIBRARY_NAME))
+
+
+def test_frame_info_layout():
+    # 与 JM_FrameInfo 一致：format_name 之后按 double 对齐
+    assert _native.FrameInfo.timestamp.offset == 48
+    assert _native.FrameInfo.frame_number.offset == 64
+    assert ctypes.sizeof(_native.FrameInfo) == 80
+
+
+def test_load_rejects_library_without_roi(build_fake):
+    path = build_fake("libjm_v201.so", "-DFAKE_API_VERSION=201", "-DFAKE_OMIT_ROI")
+    with pytest.raises(OSError, match="older than these bindings.*JM_SetROIs"):
+        _native.load(path)
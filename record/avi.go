@@ -0,0 +1,224 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+)
+
+// maxAVISize 是 AVI 1.0 RIFF 文件的大小上限，超过后需要分段（测试中调小）
+var maxAVISize int64 = 1<<31 - 1
+
+// ErrFileSizeLimit 表示再写入一帧会超过 AVI 文件大小上限，该帧未写入，文件仍可正常关闭
+var ErrFileSizeLimit = errors.New("record: avi file size limit reached")
+
+// aviIndexEntry 是 idx1 中的一条索引
+type aviIndexEntry struct {
+	offset uint32 // 相对 'movi' 标识的偏移
+	size   uint32
+}
+
+// MJPEGEncoder 将每帧编码为 JPEG，写入 AVI (MJPG) 文件
+// 纯 Go 实现，不依赖 ffmpeg，常见播放器和 ffmpeg 均可直接打开
+type MJPEGEncoder struct {
+	path    string
+	width   int
+	height  int
+	fps     int
+	quality int
+
+	f     *os.File
+	w     *bufio.Writer
+	size  int64 // 已写入的字节数
+	index []aviIndexEntry
+	buf   bytes.Buffer
+
+	// 文件头中需要在关闭时回填的位置
+	riffSizeAt    int64
+	totalFramesAt int64
+	lengthAt      int64
+	moviSizeAt    int64
+	moviAt        int64
+}
+
+// NewMJPEGEncoder 创建 MJPEG-AVI 文件，quality 为 JPEG 质量 (1-100)
+func NewMJPEGEncoder(path string, width, height, fps, quality int) (*MJPEGEncoder, error) {
+	if width <= 0 || height <= 0 || fps <= 0 {
+		return nil, fmt.Errorf("record: invalid video format %dx%d@%d", width, height, fps)
+	}
+	if quality < 1 || quality > 100 {
+		return nil, fmt.Errorf("record: invalid jpeg quality %d", quality)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &MJPEGEncoder{
+		path:    path,
+		width:   width,
+		height:  height,
+		fps:     fps,
+		quality: quality,
+		f:       f,
+		w:       bufio.NewWriterSize(f, 1<<20),
+	}
+	if err := e.writeHeader(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// Path 返回输出文件路径
+func (e *MJPEGEncoder) Path() string {
+	return e.path
+}
+
+// le 按小端序写入一组数值
+func (e *MJPEGEncoder) le(values ...any) {
+	for _, v := range values {
+		binary.Write(e.w, binary.LittleEndian, v)
+		e.size += int64(binary.Size(v))
+	}
+}
+
+// fourcc 写入四字符标识
+func (e *MJPEGEncoder) fourcc(s string) {
+	e.w.WriteString(s)
+	e.size += 4
+}
+
+// writeHeader 写入 hdrl 和 movi 列表头，帧数和大小字段先写 0
+func (e *MJPEGEncoder) writeHeader() error {
+	w, h := uint32(e.width), uint32(e.height)
+
+	e.fourcc("RIFF")
+	e.riffSizeAt = e.size
+	e.le(uint32(0))
+	e.fourcc("AVI ")
+
+	e.fourcc("LIST")
+	e.le(uint32(4 + (8 + 56) + (8 + 4 + (8 + 56) + (8 + 40))))
+	e.fourcc("hdrl")
+
+	// MainAVIHeader
+	e.fourcc("avih")
+	e.le(uint32(56))
+	e.le(uint32(1000000/e.fps), uint32(0), uint32(0), uint32(0x10)) // 帧间隔(us), 最大码率, 填充, AVIF_HASINDEX
+	e.totalFramesAt = e.size
+	e.le(uint32(0), uint32(0), uint32(1), w*h*3, w, h) // 总帧数, 初始帧, 流数, 建议缓冲, 宽, 高
+	e.le([4]uint32{})
+
+	e.fourcc("LIST")
+	e.le(uint32(4 + (8 + 56) + (8 + 40)))
+	e.fourcc("strl")
+
+	// AVIStreamHeader
+	e.fourcc("strh")
+	e.le(uint32(56))
+	e.fourcc("vids")
+	e.fourcc("MJPG")
+	e.le(uint32(0), uint16(0), uint16(0), uint32(0)) // 标志, 优先级, 语言, 初始帧
+	e.le(uint32(1), uint32(e.fps), uint32(0))        // scale, rate, start
+	e.lengthAt = e.size
+	e.le(uint32(0), w*h*3, int32(-1), uint32(0)) // 帧数, 建议缓冲, 质量, 采样大小
+	e.le([4]int16{0, 0, int16(w), int16(h)})
+
+	// BITMAPINFOHEADER
+	e.fourcc("strf")
+	e.le(uint32(40))
+	e.le(uint32(40), int32(w), int32(h), uint16(1), uint16(24))
+	e.fourcc("MJPG")
+	e.le(w*h*3, int32(0), int32(0), uint32(0), uint32(0))
+
+	e.fourcc("LIST")
+	e.moviSizeAt = e.size
+	e.le(uint32(0))
+	e.moviAt = e.size
+	e.fourcc("movi")
+	return e.w.Flush()
+}
+
+// WriteFrame 编码并写入一帧
+func (e *MJPEGEncoder) WriteFrame(img *image.RGBA) error {
+	if e.f == nil {
+		return fmt.Errorf("record: encoder closed")
+	}
+	b := img.Bounds()
+	if b.Dx() != e.width || b.Dy() != e.height {
+		return fmt.Errorf("record: frame size %dx%d does not match %dx%d", b.Dx(), b.Dy(), e.width, e.height)
+	}
+
+	e.buf.Reset()
+	if err := jpeg.Encode(&e.buf, img, &jpeg.Options{Quality: e.quality}); err != nil {
+		return err
+	}
+	n := e.buf.Len()
+	pad := n & 1
+	// 预留 idx1 的空间，保证关闭后文件不超过上限
+	if e.size+int64(8+n+pad)+int64(8+16*(len(e.index)+1)) > maxAVISize {
+		return ErrFileSizeLimit
+	}
+
+	e.index = append(e.index, aviIndexEntry{offset: uint32(e.size - e.moviAt), size: uint32(n)})
+	e.fourcc("00dc")
+	e.le(uint32(n))
+	e.w.Write(e.buf.Bytes())
+	e.size += int64(n)
+	if pad != 0 {
+		e.w.WriteByte(0)
+		e.size++
+	}
+	return nil
+}
+
+// Size 返回已写入的字节数（不含关闭时写入的索引）
+func (e *MJPEGEncoder) Size() int64 {
+	return e.size
+}
+
+// Close 写入 idx1 索引并回填文件头中的大小和帧数
+func (e *MJPEGEncoder) Close() error {
+	f := e.f
+	if f == nil {
+		return nil
+	}
+	e.f = nil
+
+	moviSize := uint32(e.size - e.moviAt)
+	e.fourcc("idx1")
+	e.le(uint32(16 * len(e.index)))
+	for _, ent := range e.index {
+		e.fourcc("00dc")
+		e.le(uint32(0x10), ent.offset, ent.size) // AVIIF_KEYFRAME
+	}
+	if err := e.w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	frames := uint32(len(e.index))
+	patches := []struct {
+		at    int64
+		value uint32
+	}{
+		{e.riffSizeAt, uint32(e.size - 8)},
+		{e.totalFramesAt, frames},
+		{e.lengthAt, frames},
+		{e.moviSizeAt, moviSize},
+	}
+	var v [4]byte
+	for _, p := range patches {
+		binary.LittleEndian.PutUint32(v[:], p.value)
+		if _, err := f.WriteAt(v[:], p.at); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
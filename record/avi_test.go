@@ -0,0 +1,404 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// aviFile 是 parseAVI 从文件中读出的结构
+type aviFile struct {
+	frames      uint32 // avih 中的总帧数
+	length      uint32 // strh 中的帧数
+	width       uint32
+	height      uint32
+	rate        uint32
+	chunks      [][]byte // movi 中按顺序出现的 00dc 数据
+	indexOffset []uint32 // idx1 中的偏移（相对 'movi'）
+	indexSize   []uint32
+}
+
+// parseAVI 按 RIFF 结构解析 MJPEGEncoder 写出的文件，并校验各级大小字段和 idx1 与 movi 一致
+func parseAVI(t *testing.T, path string) aviFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u32 := func(at int) uint32 { return binary.LittleEndian.Uint32(data[at:]) }
+
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "AVI " {
+		t.Fatalf("%s: not an AVI file", path)
+	}
+	if int(u32(4)) != len(data)-8 {
+		t.Fatalf("RIFF size %d, file size %d", u32(4), len(data))
+	}
+
+	var avi aviFile
+	moviAt := -1
+	pos := 12
+	for pos < len(data) {
+		id, size := string(data[pos:pos+4]), int(u32(pos+4))
+		body := pos + 8
+		if body+size > len(data) {
+			t.Fatalf("chunk %q at %d: size %d exceeds file", id, pos, size)
+		}
+		switch {
+		case id == "LIST" && string(data[body:body+4]) == "hdrl":
+			avih := body + 4
+			if string(data[avih:avih+4]) != "avih" || u32(avih+4) != 56 {
+				t.Fatalf("bad avih")
+			}
+			avi.frames = u32(avih + 8 + 16)
+			avi.width, avi.height = u32(avih+8+32), u32(avih+8+36)
+			strl := avih + 8 + 56
+			strh := strl + 12
+			if string(data[strl+8:strl+12]) != "strl" || string(data[strh:strh+4]) != "strh" {
+				t.Fatalf("bad strl")
+			}
+			if string(data[strh+8:strh+16]) != "vidsMJPG" {
+				t.Errorf("stream type %q", data[strh+8:strh+16])
+			}
+			avi.rate = u32(strh + 8 + 24)
+			avi.length = u32(strh + 8 + 32)
+		case id == "LIST" && string(data[body:body+4]) == "movi":
+			moviAt = body
+			for c := body + 4; c < body+size; {
+				n := int(u32(c + 4))
+				if string(data[c:c+4]) != "00dc" {
+					t.Fatalf("movi chunk %q at %d", data[c:c+4], c)
+				}
+				avi.chunks = append(avi.chunks, data[c+8:c+8+n])
+				c += 8 + n + n&1
+			}
+		case id == "idx1":
+			if size%16 != 0 {
+				t.Fatalf("idx1 size %d", size)
+			}
+			for e := body; e < body+size; e += 16 {
+				if string(data[e:e+4]) != "00dc" || u32(e+4) != 0x10 {
+					t.Errorf("idx1 entry %q flags %#x", data[e:e+4], u32(e+4))
+				}
+				avi.indexOffset = append(avi.indexOffset, u32(e+8))
+				avi.indexSize = append(avi.indexSize, u32(e+12))
+			}
+		default:
+			t.Fatalf("unexpected chunk %q at %d", id, pos)
+		}
+		pos = body + size + size&1
+	}
+	if moviAt < 0 {
+		t.Fatal("no movi list")
+	}
+
+	// idx1 的每一项指向 movi 中对应的数据块
+	if len(avi.indexOffset) != len(avi.chunks) {
+		t.Fatalf("idx1 has %d entries, movi has %d chunks", len(avi.indexOffset), len(avi.chunks))
+	}
+	for i, off := range avi.indexOffset {
+		at := moviAt + int(off)
+		if string(data[at:at+4]) != "00dc" || u32(at+4) != avi.indexSize[i] || int(avi.indexSize[i]) != len(avi.chunks[i]) {
+			t.Errorf("idx1 entry %d: offset %d size %d does not match movi", i, off, avi.indexSize[i])
+		}
+	}
+	return avi
+}
+
+// solidImage 返回纯色图像
+func solidImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+var testColors = []color.RGBA{{200, 0, 0, 255}, {0, 200, 0, 255}, {0, 0, 200, 255}}
+
+// checkChunkColor 解码一帧 JPEG 并检查中心像素接近 want
+func checkChunkColor(t *testing.T, chunk []byte, w, h int, want color.RGBA) {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(chunk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != w || b.Dy() != h {
+		t.Fatalf("jpeg size %v, want %dx%d", b, w, h)
+	}
+	r, g, b, _ := img.At(w/2, h/2).RGBA()
+	got := [3]int{int(r >> 8), int(g >> 8), int(b >> 8)}
+	for i, v := range [3]uint8{want.R, want.G, want.B} {
+		if d := got[i] - int(v); d > 8 || d < -8 {
+			t.Errorf("pixel %v, want about %v", got, want)
+			return
+		}
+	}
+}
+
+func TestMJPEGEncoderWritesValidAVI(t *testing.T) {
+	// 奇数大小的 JPEG 需要补齐字节，多写几种尺寸覆盖两种情况
+	for _, size := range []image.Point{{16, 8}, {17, 9}} {
+		path := filepath.Join(t.TempDir(), "out.avi")
+		e, err := NewMJPEGEncoder(path, size.X, size.Y, 15, 90)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range testColors {
+			if err := e.WriteFrame(solidImage(size.X, size.Y, c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.WriteFrame(solidImage(size.X+1, size.Y, testColors[0])); err == nil {
+			t.Error("frame of wrong size accepted")
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.WriteFrame(solidImage(size.X, size.Y, testColors[0])); err == nil {
+			t.Error("write after close accepted")
+		}
+
+		avi := parseAVI(t, path)
+		if avi.frames != 3 || avi.length != 3 || avi.rate != 15 ||
+			avi.width != uint32(size.X) || avi.height != uint32(size.Y) {
+			t.Errorf("%v: header frames %d/%d rate %d size %dx%d", size, avi.frames, avi.length, avi.rate, avi.width, avi.height)
+		}
+		for i, chunk := range avi.chunks {
+			checkChunkColor(t, chunk, size.X, size.Y, testColors[i])
+		}
+	}
+}
+
+func TestMJPEGEncoderSizeLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.avi")
+	e, err := NewMJPEGEncoder(path, 16, 8, 30, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := solidImage(16, 8, testColors[0])
+	if err := e.WriteFrame(img); err != nil {
+		t.Fatal(err)
+	}
+	// 上限只够再写一帧（含 idx1 预留）
+	perFrame := e.Size()
+	if err := e.WriteFrame(img); err != nil {
+		t.Fatal(err)
+	}
+	perFrame = e.Size() - perFrame
+	defer func(old int64) { maxAVISize = old }(maxAVISize)
+	maxAVISize = e.Size() + perFrame + 8 + 16*3
+
+	if err := e.WriteFrame(img); err != nil {
+		t.Fatal(err)
+	}
+	before := e.Size()
+	if err := e.WriteFrame(img); !errors.Is(err, ErrFileSizeLimit) {
+		t.Fatalf("error %v, want ErrFileSizeLimit", err)
+	}
+	if e.Size() != before {
+		t.Errorf("rejected frame changed size %d -> %d", before, e.Size())
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() > maxAVISize {
+		t.Errorf("file size %d exceeds limit %d", fi.Size(), maxAVISize)
+	}
+	if avi := parseAVI(t, path); avi.frames != 3 {
+		t.Errorf("%d frames, want 3", avi.frames)
+	}
+}
+
+func newMJPEGRecorder(t *testing.T, segmentSize int64) *Recorder {
+	t.Helper()
+	r, err := NewRecorder(16, 8, Options{Dir: t.TempDir(), Encoder: EncoderMJPEG, FPS: 10, JPEGQuality: 90, SegmentSize: segmentSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// checkSegments 校验每个分段都是完整的 AVI，帧按写入顺序分布，返回各分段帧数
+func checkSegments(t *testing.T, r *Recorder, total int) []int {
+	t.Helper()
+	var frames []int
+	n := 0
+	for _, seg := range r.Segments() {
+		avi := parseAVI(t, seg.Path)
+		if int(avi.frames) != seg.Frames || len(avi.chunks) != seg.Frames {
+			t.Errorf("%s: header %d frames, %d chunks, segment %d", seg.Path, avi.frames, len(avi.chunks), seg.Frames)
+		}
+		if fi, err := os.Stat(seg.Path); err != nil || fi.Size() != seg.Size {
+			t.Errorf("%s: segment size %d, file %v", seg.Path, seg.Size, fi)
+		}
+		for _, chunk := range avi.chunks {
+			checkChunkColor(t, chunk, 16, 8, testColors[n%len(testColors)])
+			n++
+		}
+		frames = append(frames, seg.Frames)
+	}
+	if n != total {
+		t.Errorf("%d frames in segments, wrote %d", n, total)
+	}
+	return frames
+}
+
+func TestRecorderRotatesOnSegmentSize(t *testing.T) {
+	// 先写一段测出每帧大小，再按 3 帧设置分段大小
+	probe := newMJPEGRecorder(t, 0)
+	if err := probe.WriteFrame(solidImage(16, 8, testColors[0])); err != nil {
+		t.Fatal(err)
+	}
+	header := probe.enc.(*MJPEGEncoder).moviAt + 4
+	perFrame := probe.Segments()[0].Size - header
+	probe.Close()
+
+	r := newMJPEGRecorder(t, header+3*perFrame)
+	const total = 8
+	for i := 0; i < total; i++ {
+		if err := r.WriteFrame(solidImage(16, 8, testColors[i%len(testColors)])); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := checkSegments(t, r, total)
+	if want := []int{3, 3, 2}; !slices.Equal(got, want) {
+		t.Errorf("segment frames %v, want %v", got, want)
+	}
+}
+
+func TestRecorderRotatesOnAVISizeLimit(t *testing.T) {
+	r := newMJPEGRecorder(t, 0)
+	if err := r.WriteFrame(solidImage(16, 8, testColors[0])); err != nil {
+		t.Fatal(err)
+	}
+	enc := r.enc.(*MJPEGEncoder)
+	perFrame := enc.Size() - (enc.moviAt + 4)
+
+	// 上限只够 2 帧和索引：未设置 SegmentSize 也应切换到新文件继续录制
+	defer func(old int64) { maxAVISize = old }(maxAVISize)
+	maxAVISize = enc.Size() + perFrame + 8 + 16*2
+
+	const total = 5
+	for i := 1; i < total; i++ {
+		if err := r.WriteFrame(solidImage(16, 8, testColors[i%len(testColors)])); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if r.Err() != nil {
+		t.Fatalf("recording stopped: %v", r.Err())
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := checkSegments(t, r, total); !slices.Equal(got, []int{2, 2, 1}) {
+		t.Errorf("segment frames %v, want [2 2 1]", got)
+	}
+}
+
+func TestRecorderFrameLargerThanAVILimit(t *testing.T) {
+	defer func(old int64) { maxAVISize = old }(maxAVISize)
+	maxAVISize = 256 // 文件头之后放不下任何一帧
+
+	r := newMJPEGRecorder(t, 0)
+	img := solidImage(16, 8, testColors[0])
+	if err := r.WriteFrame(img); !errors.Is(err, ErrFileSizeLimit) {
+		t.Fatalf("error %v, want ErrFileSizeLimit", err)
+	}
+	// 不会为同一帧反复创建新文件
+	if segs := r.Segments(); len(segs) != 1 {
+		t.Errorf("%d segments, want 1", len(segs))
+	}
+	if err := r.WriteFrame(img); !errors.Is(err, ErrFileSizeLimit) {
+		t.Errorf("second write %v, want the recorded error", err)
+	}
+	r.Close()
+}
+
+func TestSideBySide(t *testing.T) {
+	// 右图每个像素的 R/G 编码其坐标
+	coords := func(w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), 100, 255})
+			}
+		}
+		return img
+	}
+	left := solidImage(3, 2, color.RGBA{255, 255, 255, 255})
+
+	tests := []struct {
+		name  string
+		right *image.RGBA
+		width int
+		src   [][2]int // 各行右半部分像素对应的源坐标 (x, y)
+	}{
+		{"same height", coords(4, 2), 7, [][2]int{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {0, 1}, {1, 1}, {2, 1}, {3, 1}}},
+		// 4x4 按高度 2 缩放为 2x2，最近邻取偶数行列
+		{"scaled down", coords(4, 4), 5, [][2]int{{0, 0}, {2, 0}, {0, 2}, {2, 2}}},
+		// 2x1 放大为 4x2
+		{"scaled up", coords(2, 1), 7, [][2]int{{0, 0}, {0, 0}, {1, 0}, {1, 0}, {0, 0}, {0, 0}, {1, 0}, {1, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := SideBySide(nil, left, tt.right)
+			if b := out.Bounds(); b.Dx() != tt.width || b.Dy() != 2 {
+				t.Fatalf("size %v, want %dx2", b, tt.width)
+			}
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 3; x++ {
+					if c := out.RGBAAt(x, y); c != (color.RGBA{255, 255, 255, 255}) {
+						t.Errorf("left (%d,%d) = %v", x, y, c)
+					}
+				}
+			}
+			rw := tt.width - 3
+			for i, s := range tt.src {
+				x, y := 3+i%rw, i/rw
+				want := color.RGBA{uint8(s[0]), uint8(s[1]), 100, 255}
+				if c := out.RGBAAt(x, y); c != want {
+					t.Errorf("right (%d,%d) = %v, want source %v", x, y, c, s)
+				}
+			}
+
+			// 尺寸相同时复用 dst
+			if again := SideBySide(out, left, tt.right); again != out {
+				t.Error("dst not reused")
+			}
+		})
+	}
+}
+
+func TestComposeRGBDepthSize(t *testing.T) {
+	// 深度图分辨率不同时缩放到彩色图高度
+	d := depth.NewImage(4, 2)
+	for i := range d.Pix {
+		d.Pix[i] = uint16(1000 + 100*i)
+	}
+	out, err := ComposeRGBDepth(nil, solidImage(8, 4, testColors[0]), d, depth.DefaultDepthScale, depth.ColorizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := out.Bounds(); b.Dx() != 16 || b.Dy() != 4 {
+		t.Errorf("size %v, want 16x4", b)
+	}
+	if c := out.RGBAAt(0, 0); c != testColors[0] {
+		t.Errorf("color half %v", c)
+	}
+}
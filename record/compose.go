@@ -0,0 +1,48 @@
+package record
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// SideBySide 将两幅图左右拼接，右图按左图高度等比例缩放（最近邻）
+// dst 尺寸匹配时复用，否则分配新图像
+func SideBySide(dst, left, right *image.RGBA) *image.RGBA {
+	lb, rb := left.Bounds(), right.Bounds()
+	h := lb.Dy()
+	rw := rb.Dx()
+	if rb.Dy() != h && rb.Dy() > 0 {
+		rw = rb.Dx() * h / rb.Dy()
+	}
+
+	size := image.Rect(0, 0, lb.Dx()+rw, h)
+	if dst == nil || dst.Bounds() != size {
+		dst = image.NewRGBA(size)
+	}
+	draw.Draw(dst, image.Rect(0, 0, lb.Dx(), h), left, lb.Min, draw.Src)
+
+	if rw == rb.Dx() {
+		draw.Draw(dst, image.Rect(lb.Dx(), 0, size.Max.X, h), right, rb.Min, draw.Src)
+		return dst
+	}
+	for y := 0; y < h; y++ {
+		sy := rb.Min.Y + y*rb.Dy()/h
+		for x := 0; x < rw; x++ {
+			sx := rb.Min.X + x*rb.Dx()/rw
+			dst.SetRGBA(lb.Dx()+x, y, right.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
+
+// ComposeRGBDepth 将彩色图和伪彩色深度图左右拼接
+// scale 为深度比例（米/单位），opts 为着色参数；深度图分辨率不同时缩放到彩色图高度
+func ComposeRGBDepth(dst, color *image.RGBA, d *depth.Image, scale float32, opts depth.ColorizeOptions) (*image.RGBA, error) {
+	colorized, err := depth.Colorize(d, scale, opts)
+	if err != nil {
+		return nil, err
+	}
+	return SideBySide(dst, color, colorized), nil
+}
@@ -0,0 +1,292 @@
+// Package record 将 *image.RGBA 帧序列录制为视频文件
+// 支持外部 ffmpeg 进程编码（编码器/preset/CRF 可配置）和纯 Go 的 MJPEG-AVI 编码，
+// 按时长或文件大小分段，关闭时刷新编码器，并检测编码进程异常退出
+package record
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEncoderExited 表示编码进程已退出，无法继续写入
+var ErrEncoderExited = errors.New("record: encoder exited")
+
+// Encoder 是单个输出文件的编码器
+type Encoder interface {
+	// WriteFrame 写入一帧，尺寸必须与创建时一致
+	WriteFrame(img *image.RGBA) error
+	// Size 返回已写入输出文件的字节数
+	Size() int64
+	// Close 刷新并关闭输出文件
+	Close() error
+}
+
+// FFmpegOptions ffmpeg 编码参数
+type FFmpegOptions struct {
+	Path         string        `json:"path"`          // 可执行文件，默认 "ffmpeg"
+	Codec        string        `json:"codec"`         // 默认 libx264
+	Preset       string        `json:"preset"`        // 编码器 preset，Codec 为空时默认 ultrafast
+	CRF          int           `json:"crf"`           // 小于等于 0 时不传 -crf，使用编码器默认值
+	PixelFormat  string        `json:"pixel_format"`  // 输出像素格式，默认 yuv420p
+	Extension    string        `json:"extension"`     // 输出文件扩展名，默认 ".mp4"
+	ExtraArgs    []string      `json:"extra_args"`    // 追加在输出文件名之前的参数
+	CloseTimeout time.Duration `json:"close_timeout"` // 关闭时等待编码结束的时间，默认 10 秒，超时强制结束
+	WriteTimeout time.Duration `json:"write_timeout"` // 单帧写入 stdin 的最长等待时间，默认 5 秒，超时强制结束
+}
+
+// withDefaults 填充默认值
+func (o FFmpegOptions) withDefaults() FFmpegOptions {
+	if o.Path == "" {
+		o.Path = "ffmpeg"
+	}
+	if o.Codec == "" {
+		o.Codec = "libx264"
+		if o.Preset == "" {
+			o.Preset = "ultrafast"
+		}
+	}
+	if o.PixelFormat == "" {
+		o.PixelFormat = "yuv420p"
+	}
+	if o.Extension == "" {
+		o.Extension = ".mp4"
+	}
+	if o.CloseTimeout <= 0 {
+		o.CloseTimeout = 10 * time.Second
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 5 * time.Second
+	}
+	return o
+}
+
+// args 返回从 stdin 读取 RGBA 原始帧的 ffmpeg 命令行参数
+func (o FFmpegOptions) args(output string, width, height, fps int) []string {
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-y",
+		"-f", "rawvideo",
+		"-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%dx%d", width, height),
+		"-r", strconv.Itoa(fps),
+		"-i", "-",
+		"-c:v", o.Codec,
+	}
+	if o.Preset != "" {
+		args = append(args, "-preset", o.Preset)
+	}
+	if o.CRF > 0 {
+		args = append(args, "-crf", strconv.Itoa(o.CRF))
+	}
+	args = append(args, "-pix_fmt", o.PixelFormat)
+	args = append(args, o.ExtraArgs...)
+	return append(args, output)
+}
+
+// FFmpegEncoder 通过 stdin 向 ffmpeg 进程写入 RGBA 原始帧
+type FFmpegEncoder struct {
+	path   string
+	width  int
+	height int
+	opts   FFmpegOptions
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+	buf    []byte
+
+	exited  chan struct{} // 进程退出后关闭
+	waitErr error         // exited 关闭后可读
+	failed  error         // 写入超时后的错误，之后的写入直接返回
+	closed  bool
+}
+
+// NewFFmpegEncoder 启动 ffmpeg 进程，输出到 path
+func NewFFmpegEncoder(path string, width, height, fps int, opts FFmpegOptions) (*FFmpegEncoder, error) {
+	opts = opts.withDefaults()
+	if width <= 0 || height <= 0 || fps <= 0 {
+		return nil, fmt.Errorf("record: invalid video format %dx%d@%d", width, height, fps)
+	}
+	if opts.PixelFormat == "yuv420p" && (width%2 != 0 || height%2 != 0) {
+		return nil, fmt.Errorf("record: yuv420p requires even frame size, got %dx%d", width, height)
+	}
+
+	cmd := exec.Command(opts.Path, opts.args(path, width, height, fps)...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &tailBuffer{limit: 4096}
+	cmd.Stderr = stderr
+	// 进程被结束后，其子进程可能仍持有 stderr，不再等待输出复制完成
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("record: failed to start %s: %w", opts.Path, err)
+	}
+
+	e := &FFmpegEncoder{
+		path:   path,
+		width:  width,
+		height: height,
+		opts:   opts,
+		cmd:    cmd,
+		stdin:  stdin,
+		stderr: stderr,
+		exited: make(chan struct{}),
+	}
+	go func() {
+		e.waitErr = cmd.Wait()
+		close(e.exited)
+	}()
+	return e, nil
+}
+
+// Path 返回输出文件路径
+func (e *FFmpegEncoder) Path() string {
+	return e.path
+}
+
+// exitError 返回进程退出的原因，附带 stderr 末尾的输出
+func (e *FFmpegEncoder) exitError() error {
+	msg := strings.TrimSpace(e.stderr.String())
+	if e.waitErr != nil {
+		return fmt.Errorf("%w: %v: %s", ErrEncoderExited, e.waitErr, msg)
+	}
+	return fmt.Errorf("%w: %s", ErrEncoderExited, msg)
+}
+
+// WriteFrame 写入一帧；编码进程已退出时返回 ErrEncoderExited
+// 写入超过 WriteTimeout 仍未完成（编码进程卡住）时结束进程，同样返回 ErrEncoderExited
+func (e *FFmpegEncoder) WriteFrame(img *image.RGBA) error {
+	if e.closed {
+		return fmt.Errorf("record: encoder closed")
+	}
+	if e.failed != nil {
+		return e.failed
+	}
+	select {
+	case <-e.exited:
+		return e.exitError()
+	default:
+	}
+
+	pix, err := packRGBA(img, e.width, e.height, &e.buf)
+	if err != nil {
+		return err
+	}
+
+	// 在单独的 goroutine 中写入，进程不再读取 stdin 时不会永久阻塞调用方
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.stdin.Write(pix)
+		done <- err
+	}()
+
+	timer := time.NewTimer(e.opts.WriteTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			// 管道断开通常意味着进程已退出，稍等以取得退出状态和错误输出
+			select {
+			case <-e.exited:
+				return e.exitError()
+			case <-time.After(time.Second):
+				return fmt.Errorf("%w: %v", ErrEncoderExited, err)
+			}
+		}
+		return nil
+	case <-timer.C:
+		// 结束进程并关闭 stdin，等写入 goroutine 返回后 pix 才能被复用
+		e.cmd.Process.Kill()
+		e.stdin.Close()
+		<-done
+		<-e.exited
+		e.failed = fmt.Errorf("%w: write blocked for more than %v, killed: %s",
+			ErrEncoderExited, e.opts.WriteTimeout, strings.TrimSpace(e.stderr.String()))
+		return e.failed
+	}
+}
+
+// Size 返回输出文件当前大小；ffmpeg 异步写文件，数值会略滞后于已写入的帧
+func (e *FFmpegEncoder) Size() int64 {
+	fi, err := os.Stat(e.path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// Close 关闭 stdin 让 ffmpeg 完成编码并写入文件尾，超时后强制结束进程
+func (e *FFmpegEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	e.stdin.Close()
+
+	select {
+	case <-e.exited:
+	case <-time.After(e.opts.CloseTimeout):
+		e.cmd.Process.Kill()
+		<-e.exited
+		return fmt.Errorf("record: encoder did not finish within %v, killed", e.opts.CloseTimeout)
+	}
+	if e.waitErr != nil {
+		return e.exitError()
+	}
+	return nil
+}
+
+// packRGBA 返回紧凑排列的像素数据，图像带有行填充时拷贝到 buf
+func packRGBA(img *image.RGBA, width, height int, buf *[]byte) ([]byte, error) {
+	b := img.Bounds()
+	if b.Dx() != width || b.Dy() != height {
+		return nil, fmt.Errorf("record: frame size %dx%d does not match %dx%d", b.Dx(), b.Dy(), width, height)
+	}
+	rowBytes := width * 4
+	if img.Stride == rowBytes {
+		start := img.PixOffset(b.Min.X, b.Min.Y)
+		return img.Pix[start : start+rowBytes*height], nil
+	}
+
+	if len(*buf) != rowBytes*height {
+		*buf = make([]byte, rowBytes*height)
+	}
+	for y := 0; y < height; y++ {
+		start := img.PixOffset(b.Min.X, b.Min.Y+y)
+		copy((*buf)[y*rowBytes:], img.Pix[start:start+rowBytes])
+	}
+	return *buf, nil
+}
+
+// tailBuffer 只保留最后 limit 字节的输出，用于错误信息
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data = append(t.data, p...)
+	if len(t.data) > t.limit {
+		t.data = t.data[len(t.data)-t.limit:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.data)
+}
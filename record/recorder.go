@@ -0,0 +1,223 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EncoderType 编码器类型
+type EncoderType string
+
+const (
+	EncoderFFmpeg EncoderType = "ffmpeg" // 外部 ffmpeg 进程
+	EncoderMJPEG  EncoderType = "mjpeg"  // 纯 Go MJPEG-AVI，无外部依赖
+)
+
+// Options 录制参数
+type Options struct {
+	Dir         string        `json:"dir"`          // 输出目录，不存在时自动创建
+	Prefix      string        `json:"prefix"`       // 文件名前缀，默认 "record"
+	Encoder     EncoderType   `json:"encoder"`      // 默认 ffmpeg
+	FPS         int           `json:"fps"`          // 默认 30
+	FFmpeg      FFmpegOptions `json:"ffmpeg"`       // Encoder 为 ffmpeg 时使用
+	JPEGQuality int           `json:"jpeg_quality"` // Encoder 为 mjpeg 时使用，默认 85
+
+	// 分段条件，满足任一条件时在下一帧前切换到新文件，均为 0 时只写一个文件
+	// （mjpeg 编码器的单个文件达到 AVI 上限 2GB 时总会切换）
+	SegmentDuration time.Duration `json:"segment_duration"` // 按帧数/FPS 计算的视频时长
+	SegmentSize     int64         `json:"segment_size"`     // 文件大小（字节）
+}
+
+// Segment 描述一个输出分段
+type Segment struct {
+	Path     string        `json:"path"`
+	Start    time.Time     `json:"start"` // 第一帧写入的时间
+	Frames   int           `json:"frames"`
+	Duration time.Duration `json:"duration"` // Frames / FPS
+	Size     int64         `json:"size"`     // 字节，关闭后为最终大小
+}
+
+// Recorder 管理编码器并按条件分段录制
+// 编码进程退出后 WriteFrame 持续返回同一个错误，需要调用方关闭后重新创建
+type Recorder struct {
+	opts   Options
+	width  int
+	height int
+
+	enc      Encoder
+	segments []Segment
+	err      error
+	closed   bool
+}
+
+// NewRecorder 校验参数并创建录制器，第一帧写入时打开第一个分段
+func NewRecorder(width, height int, opts Options) (*Recorder, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("record: invalid frame size %dx%d", width, height)
+	}
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("record: output dir is required")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "record"
+	}
+	if opts.Encoder == "" {
+		opts.Encoder = EncoderFFmpeg
+	}
+	if opts.Encoder != EncoderFFmpeg && opts.Encoder != EncoderMJPEG {
+		return nil, fmt.Errorf("record: unknown encoder %q", opts.Encoder)
+	}
+	if opts.FPS == 0 {
+		opts.FPS = 30
+	}
+	if opts.FPS < 0 {
+		return nil, fmt.Errorf("record: invalid fps %d", opts.FPS)
+	}
+	if opts.JPEGQuality == 0 {
+		opts.JPEGQuality = 85
+	}
+	if opts.JPEGQuality < 1 || opts.JPEGQuality > 100 {
+		return nil, fmt.Errorf("record: invalid jpeg quality %d", opts.JPEGQuality)
+	}
+	if opts.SegmentDuration < 0 || opts.SegmentSize < 0 {
+		return nil, fmt.Errorf("record: invalid segment limits")
+	}
+	opts.FFmpeg = opts.FFmpeg.withDefaults()
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder{opts: opts, width: width, height: height}, nil
+}
+
+// Options 返回生效的参数
+func (r *Recorder) Options() Options {
+	return r.opts
+}
+
+// ext 返回输出文件扩展名
+func (r *Recorder) ext() string {
+	if r.opts.Encoder == EncoderMJPEG {
+		return ".avi"
+	}
+	return r.opts.FFmpeg.Extension
+}
+
+// open 打开新的分段，文件名为 <prefix>_<时间>_<序号><扩展名>
+func (r *Recorder) open(now time.Time) error {
+	name := fmt.Sprintf("%s_%s_%03d%s", r.opts.Prefix, now.Format("20060102_150405"), len(r.segments), r.ext())
+	path := filepath.Join(r.opts.Dir, name)
+
+	var (
+		enc Encoder
+		err error
+	)
+	switch r.opts.Encoder {
+	case EncoderMJPEG:
+		enc, err = NewMJPEGEncoder(path, r.width, r.height, r.opts.FPS, r.opts.JPEGQuality)
+	default:
+		enc, err = NewFFmpegEncoder(path, r.width, r.height, r.opts.FPS, r.opts.FFmpeg)
+	}
+	if err != nil {
+		return err
+	}
+	r.enc = enc
+	r.segments = append(r.segments, Segment{Path: path, Start: now})
+	return nil
+}
+
+// finish 关闭当前分段并记录最终大小
+func (r *Recorder) finish() error {
+	if r.enc == nil {
+		return nil
+	}
+	err := r.enc.Close()
+	r.enc = nil
+	seg := &r.segments[len(r.segments)-1]
+	if fi, statErr := os.Stat(seg.Path); statErr == nil {
+		seg.Size = fi.Size()
+	}
+	return err
+}
+
+// shouldRotate 判断当前分段是否已达到分段条件
+func (r *Recorder) shouldRotate() bool {
+	if r.enc == nil {
+		return false
+	}
+	seg := r.segments[len(r.segments)-1]
+	if r.opts.SegmentDuration > 0 && seg.Duration >= r.opts.SegmentDuration {
+		return true
+	}
+	return r.opts.SegmentSize > 0 && r.enc.Size() >= r.opts.SegmentSize
+}
+
+// WriteFrame 写入一帧，必要时先切换到新分段
+func (r *Recorder) WriteFrame(img *image.RGBA) error {
+	if r.closed {
+		return fmt.Errorf("record: recorder closed")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if b := img.Bounds(); b.Dx() != r.width || b.Dy() != r.height {
+		return fmt.Errorf("record: frame size %dx%d does not match %dx%d", b.Dx(), b.Dy(), r.width, r.height)
+	}
+
+	if r.shouldRotate() {
+		if err := r.finish(); err != nil {
+			r.err = err
+			return err
+		}
+	}
+	if r.enc == nil {
+		if err := r.open(time.Now()); err != nil {
+			r.err = err
+			return err
+		}
+	}
+
+	err := r.enc.WriteFrame(img)
+	if errors.Is(err, ErrFileSizeLimit) && r.segments[len(r.segments)-1].Frames > 0 {
+		// 未设置 SegmentSize 时 AVI 达到大小上限也需要分段，该帧写入新文件
+		if err = r.finish(); err == nil {
+			if err = r.open(time.Now()); err == nil {
+				err = r.enc.WriteFrame(img)
+			}
+		}
+	}
+	if err != nil {
+		r.err = err
+		r.finish()
+		return err
+	}
+	seg := &r.segments[len(r.segments)-1]
+	seg.Frames++
+	seg.Duration = time.Duration(seg.Frames) * time.Second / time.Duration(r.opts.FPS)
+	seg.Size = r.enc.Size()
+	return nil
+}
+
+// Err 返回导致录制停止的错误，例如编码进程退出
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// Segments 返回已创建的分段，最后一项可能仍在写入
+func (r *Recorder) Segments() []Segment {
+	out := make([]Segment, len(r.segments))
+	copy(out, r.segments)
+	return out
+}
+
+// Close 刷新并关闭当前分段
+func (r *Recorder) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.finish()
+}
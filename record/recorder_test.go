@@ -0,0 +1,119 @@
+package record
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeEncoder 写出一个模拟 ffmpeg 的 shell 脚本，最后一个参数是输出文件路径
+func fakeEncoder(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake encoder needs /bin/sh")
+	}
+	path := filepath.Join(t.TempDir(), "fake-ffmpeg")
+	script := "#!/bin/sh\nfor out; do :; done\n" + body + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestRecorder(t *testing.T, w, h int, ff FFmpegOptions, segment time.Duration) *Recorder {
+	t.Helper()
+	ff.CloseTimeout = 2 * time.Second
+	r, err := NewRecorder(w, h, Options{
+		Dir:             t.TempDir(),
+		FPS:             10,
+		FFmpeg:          ff,
+		SegmentDuration: segment,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestRecorderRotatesSegments(t *testing.T) {
+	// 原样保存 stdin，文件大小即写入的字节数
+	ff := FFmpegOptions{Path: fakeEncoder(t, `exec cat > "$out"`)}
+	r := newTestRecorder(t, 8, 4, ff, time.Second) // 10 帧一段
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for i := 0; i < 25; i++ {
+		if err := r.WriteFrame(img); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segs := r.Segments()
+	want := []int{10, 10, 5}
+	if len(segs) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(segs), len(want))
+	}
+	for i, seg := range segs {
+		if seg.Frames != want[i] {
+			t.Errorf("segment %d: %d frames, want %d", i, seg.Frames, want[i])
+		}
+		if size := int64(want[i] * 8 * 4 * 4); seg.Size != size {
+			t.Errorf("segment %d: size %d, want %d", i, seg.Size, size)
+		}
+	}
+}
+
+func TestRecorderEncoderFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		size    int    // 帧边长，挂起用例需要超过管道缓冲
+		wantMsg string // 错误中应包含的内容
+	}{
+		{"exits early", `echo "unknown encoder" >&2; exit 3`, 8, "unknown encoder"},
+		{"hangs", `echo "stuck" >&2; exec sleep 30`, 256, "write blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff := FFmpegOptions{Path: fakeEncoder(t, tt.body), WriteTimeout: 200 * time.Millisecond}
+			r := newTestRecorder(t, tt.size, tt.size, ff, 0)
+			img := image.NewRGBA(image.Rect(0, 0, tt.size, tt.size))
+
+			start := time.Now()
+			var err error
+			for i := 0; i < 100 && err == nil; i++ {
+				err = r.WriteFrame(img)
+				if err == nil {
+					time.Sleep(10 * time.Millisecond) // 等进程退出
+				}
+			}
+			if !errors.Is(err, ErrEncoderExited) {
+				t.Fatalf("WriteFrame error %v, want ErrEncoderExited", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error %q does not contain %q", err, tt.wantMsg)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("failure detected after %v", elapsed)
+			}
+
+			// 错误保持，后续写入不再阻塞
+			if r.Err() != err {
+				t.Errorf("Err() = %v, want %v", r.Err(), err)
+			}
+			if err2 := r.WriteFrame(img); err2 != err {
+				t.Errorf("second WriteFrame error %v, want %v", err2, err)
+			}
+			if segs := r.Segments(); len(segs) != 1 {
+				t.Errorf("got %d segments, want 1", len(segs))
+			}
+			r.Close()
+		})
+	}
+}
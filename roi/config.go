@@ -0,0 +1,59 @@
+package roi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Config 是触发引擎的声明式配置，可从 JSON 文件加载
+//
+//	{
+//	  "reference_width": 640,
+//	  "reference_height": 480,
+//	  "regions": [
+//	    {"name": "door", "rect": {"x": 270, "y": 190, "w": 100, "h": 100},
+//	     "min_distance": 0.1, "max_distance": 1.5, "min_points": 500,
+//	     "debounce": 3, "cooldown_ms": 2000}
+//	  ],
+//	  "camera_pose": {"position": {"x": 0, "y": 0, "z": 0}, "rotation_deg": {"x": -30, "y": 0, "z": 0}},
+//	  "volumes": [
+//	    {"name": "shelf", "min": {"x": -0.3, "y": -0.2, "z": 0.8}, "max": {"x": 0.3, "y": 0.2, "z": 1.2},
+//	     "min_points": 200, "debounce": 2}
+//	  ]
+//	}
+type Config struct {
+	ReferenceWidth  int      `json:"reference_width,omitempty"`
+	ReferenceHeight int      `json:"reference_height,omitempty"`
+	Regions         []Region `json:"regions,omitempty"`
+	CameraPose      *Pose    `json:"camera_pose,omitempty"`
+	Volumes         []Volume `json:"volumes,omitempty"`
+}
+
+// ParseConfig 解析 JSON 配置，未知字段视为错误
+func ParseConfig(r io.Reader) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("roi: parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// NewEngineFromConfig 根据配置创建触发引擎
+func NewEngineFromConfig(cfg Config) (*Engine, error) {
+	e, err := NewEngine(cfg.ReferenceWidth, cfg.ReferenceHeight, cfg.Regions...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CameraPose != nil {
+		e.SetCameraPose(*cfg.CameraPose)
+	}
+	for _, v := range cfg.Volumes {
+		if err := e.AddVolume(v); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,162 @@
+package roi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegionValidate(t *testing.T) {
+	valid := Region{Name: "a", Rect: &Rect{0, 0, 10, 10}, MinDistance: 0.2, MaxDistance: 1, MinPoints: 10}
+	tests := []struct {
+		name    string
+		modify  func(*Region)
+		wantErr string // 空表示有效
+	}{
+		{"valid rect", nil, ""},
+		{"valid polygon", func(r *Region) { r.Rect = nil; r.Polygon = []Point{{0, 0}, {1, 0}, {0, 1}} }, ""},
+		{"hysteresis", func(r *Region) { r.ReleasePoints = 5 }, ""},
+		{"missing name", func(r *Region) { r.Name = "" }, "name is required"},
+		{"no shape", func(r *Region) { r.Rect = nil }, "rect or polygon is required"},
+		{"both shapes", func(r *Region) { r.Polygon = []Point{{0, 0}, {1, 0}, {0, 1}} }, "mutually exclusive"},
+		{"empty rect", func(r *Region) { r.Rect = &Rect{0, 0, 0, 10} }, "invalid rect size"},
+		{"two-point polygon", func(r *Region) { r.Rect = nil; r.Polygon = []Point{{0, 0}, {1, 1}} }, "at least 3 points"},
+		{"negative min distance", func(r *Region) { r.MinDistance = -1 }, "distance band"},
+		{"inverted band", func(r *Region) { r.MinDistance, r.MaxDistance = 2, 1 }, "distance band"},
+		{"empty band", func(r *Region) { r.MaxDistance = r.MinDistance }, "distance band"},
+		{"zero min points", func(r *Region) { r.MinPoints = 0 }, "min_points"},
+		{"release above min", func(r *Region) { r.ReleasePoints = 11 }, "release_points"},
+		{"negative debounce", func(r *Region) { r.Debounce = -1 }, "must not be negative"},
+		{"negative cooldown", func(r *Region) { r.CooldownMS = -1 }, "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			if tt.modify != nil {
+				tt.modify(&r)
+			}
+			checkErr(t, r.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestVolumeValidate(t *testing.T) {
+	aabb := Volume{Name: "v", Min: &Point3{-1, -1, 1}, Max: &Point3{1, 1, 2}, MinPoints: 10}
+	tests := []struct {
+		name    string
+		modify  func(*Volume)
+		wantErr string
+	}{
+		{"valid aabb", nil, ""},
+		{"valid obb", func(v *Volume) { v.Min, v.Max = nil, nil; v.Center, v.Size = &Point3{0, 0, 1}, &Point3{1, 1, 1} }, ""},
+		{"missing name", func(v *Volume) { v.Name = "" }, "name is required"},
+		{"no box", func(v *Volume) { v.Min, v.Max = nil, nil }, "is required"},
+		{"only min", func(v *Volume) { v.Max = nil }, "both min and max"},
+		{"mixed", func(v *Volume) { v.Center = &Point3{} }, "mutually exclusive"},
+		{"flat aabb", func(v *Volume) { v.Max = &Point3{1, 1, 1} }, "greater than min"},
+		{"only center", func(v *Volume) { v.Min, v.Max = nil, nil; v.Center = &Point3{} }, "both center and size"},
+		{"zero size", func(v *Volume) { v.Min, v.Max = nil, nil; v.Center, v.Size = &Point3{}, &Point3{1, 0, 1} }, "invalid box size"},
+		{"zero min points", func(v *Volume) { v.MinPoints = 0 }, "min_points"},
+		{"release above min", func(v *Volume) { v.ReleasePoints = 20 }, "release_points"},
+		{"negative release", func(v *Volume) { v.Release = -1 }, "must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := aabb
+			if tt.modify != nil {
+				tt.modify(&v)
+			}
+			checkErr(t, v.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"valid", `{
+			"reference_width": 640, "reference_height": 480,
+			"regions": [{"name": "door", "rect": {"x": 270, "y": 190, "w": 100, "h": 100},
+			             "min_distance": 0.1, "max_distance": 1.5, "min_points": 500, "debounce": 3}],
+			"camera_pose": {"position": {"x": 0, "y": 0, "z": 0}, "rotation_deg": {"x": -30, "y": 0, "z": 0}},
+			"volumes": [{"name": "shelf", "min": {"x": -0.3, "y": -0.2, "z": 0.8}, "max": {"x": 0.3, "y": 0.2, "z": 1.2},
+			             "min_points": 200}]
+		}`, ""},
+		{"unknown field", `{"regions": [{"name": "a", "rectangle": {}}]}`, "unknown field"},
+		{"syntax", `{"regions": [`, "parse config"},
+		{"invalid region", `{"reference_width": 10, "reference_height": 10,
+			"regions": [{"name": "a", "rect": {"x": 0, "y": 0, "w": 1, "h": 1}, "min_distance": 1, "max_distance": 0.5, "min_points": 1}]}`,
+			"distance band"},
+		{"region without reference size", `{"regions": [{"name": "a", "rect": {"x": 0, "y": 0, "w": 1, "h": 1},
+			"max_distance": 1, "min_points": 1}]}`, "no reference size"},
+		{"half reference size", `{"reference_width": 640}`, "invalid reference size"},
+		{"duplicate name", `{"reference_width": 10, "reference_height": 10,
+			"regions": [{"name": "a", "rect": {"x": 0, "y": 0, "w": 1, "h": 1}, "max_distance": 1, "min_points": 1}],
+			"volumes": [{"name": "a", "min": {"x": 0, "y": 0, "z": 0}, "max": {"x": 1, "y": 1, "z": 1}, "min_points": 1}]}`,
+			"duplicate"},
+		{"volumes only", `{"volumes": [{"name": "v", "center": {"x": 0, "y": 0, "z": 1}, "size": {"x": 1, "y": 1, "z": 1},
+			"min_points": 1}]}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(strings.NewReader(tt.json))
+			if err == nil {
+				_, err = NewEngineFromConfig(cfg)
+			}
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEngineFromConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{
+		"reference_width": 640, "reference_height": 480,
+		"regions": [{"name": "door", "polygon": [{"x": 0, "y": 0}, {"x": 10, "y": 0}, {"x": 0, "y": 10}],
+		             "min_distance": 0.1, "max_distance": 1.5, "min_points": 5}],
+		"camera_pose": {"position": {"x": 0, "y": 0, "z": 1}, "rotation_deg": {"x": -30, "y": 0, "z": 0}},
+		"volumes": [{"name": "shelf", "min": {"x": -0.3, "y": -0.2, "z": 0.8}, "max": {"x": 0.3, "y": 0.2, "z": 1.2},
+		             "min_points": 200}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEngineFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := e.ReferenceSize(); w != 640 || h != 480 {
+		t.Errorf("reference size %dx%d", w, h)
+	}
+	if r := e.Regions(); len(r) != 1 || r[0].Name != "door" || len(r[0].Polygon) != 3 {
+		t.Errorf("regions %+v", r)
+	}
+	if v := e.Volumes(); len(v) != 1 || v[0].Name != "shelf" || v[0].Max.Z != 1.2 {
+		t.Errorf("volumes %+v", v)
+	}
+	if p := e.CameraPose(); p.Position.Z != 1 || p.RotationDeg.X != -30 {
+		t.Errorf("pose %+v", p)
+	}
+	// 配置中的切片被拷贝，修改原配置不影响引擎
+	cfg.Regions[0].Polygon[0].X = 100
+	if e.Regions()[0].Polygon[0].X != 0 {
+		t.Error("engine shares polygon with config")
+	}
+	if !e.Remove("shelf") || e.Remove("shelf") || len(e.Volumes()) != 0 {
+		t.Error("Remove did not delete the volume exactly once")
+	}
+}
+
+// checkErr 检查错误是否包含 want，want 为空表示不应出错
+func checkErr(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Fatalf("no error, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Fatalf("error %q does not contain %q", err, want)
+	}
+}
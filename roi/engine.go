@@ -0,0 +1,465 @@
+package roi
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// Stats 是单个区域在一帧上的统计结果
+type Stats struct {
+	Pixels    int     `json:"pixels"`     // 区域覆盖的深度像素数
+	Valid     int     `json:"valid"`      // 其中深度有效 (非 0) 的像素数
+	Points    int     `json:"points"`     // 落在距离范围内的像素数
+	Threshold int     `json:"threshold"`  // 按当前分辨率换算后的触发点数
+	Ratio     float32 `json:"ratio"`      // Points / Pixels
+	MinDepth  float32 `json:"min_depth"`  // 命中点的最小距离（米）
+	MaxDepth  float32 `json:"max_depth"`  // 命中点的最大距离（米）
+	MeanDepth float32 `json:"mean_depth"` // 命中点的平均距离（米）
+
+	// 以下字段仅 3D 体积使用
+	Occluded  int     `json:"occluded,omitempty"`  // 被体积前方物体遮挡的像素数
+	Occupancy float32 `json:"occupancy,omitempty"` // 占用率 (0-100)
+}
+
+// Event 是一次触发事件
+type Event struct {
+	Region      string    `json:"region"`       // 区域或体积名称
+	Timestamp   float64   `json:"timestamp"`    // 触发帧的时间戳（毫秒）
+	FrameNumber uint64    `json:"frame_number"` // 触发帧的序号
+	Time        time.Time `json:"time"`         // 事件产生的系统时间
+	Stats       Stats     `json:"stats"`
+}
+
+// Frame 是一帧待判定的深度数据，深度图需要已对齐到彩色
+type Frame struct {
+	Depth       *depth.Image
+	Scale       float32 // 深度比例（米/单位）
+	Timestamp   float64 // 帧时间戳（毫秒），用于冷却计算
+	FrameNumber uint64
+
+	// Intrinsics 是深度图（对齐后即彩色流）的内参，分辨率需与 Depth 一致，评估 3D 体积时必须提供
+	Intrinsics *depth.Intrinsics
+}
+
+// triggerState 是去抖/迟滞/冷却状态机，区域和体积共用
+type triggerState struct {
+	hits     int     // 连续满足条件的帧数
+	misses   int     // 触发后连续低于解除阈值的帧数
+	active   bool    // 已触发且尚未解除
+	lastFire float64 // 上次触发的帧时间戳
+	fired    bool
+}
+
+// triggerParams 是状态机参数，点数已换算到当前分辨率
+type triggerParams struct {
+	threshold  int // 触发点数
+	releaseAt  int // 解除点数
+	debounce   int
+	release    int
+	cooldownMS float64
+}
+
+// regionState 是区域的运行状态
+type regionState struct {
+	triggerState
+	region Region
+	width  int // mask 对应的深度图尺寸
+	height int
+	mask   []int32
+	last   Stats
+}
+
+// Engine 是 ROI 触发引擎，可并发调用
+type Engine struct {
+	mu        sync.Mutex
+	refWidth  int
+	refHeight int
+	regions   []*regionState
+	volumes   []*volumeState
+	pose      Pose
+
+	subs    []chan Event
+	dropped uint64
+}
+
+// NewEngine 创建触发引擎
+// refWidth/refHeight 是区域坐标所在的参考分辨率（通常是彩色流分辨率）；
+// 只使用 3D 体积时可以传 0
+func NewEngine(refWidth, refHeight int, regions ...Region) (*Engine, error) {
+	if refWidth < 0 || refHeight < 0 || (refWidth == 0) != (refHeight == 0) {
+		return nil, fmt.Errorf("roi: invalid reference size %dx%d", refWidth, refHeight)
+	}
+	e := &Engine{refWidth: refWidth, refHeight: refHeight}
+	for _, r := range regions {
+		if err := e.Add(r); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Add 添加区域，名称不能与已有区域或体积重复
+func (e *Engine) Add(r Region) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if e.refWidth == 0 {
+		return fmt.Errorf("roi %q: engine has no reference size for pixel regions", r.Name)
+	}
+	if r.Polygon != nil {
+		r.Polygon = append([]Point(nil), r.Polygon...)
+	}
+	if r.Rect != nil {
+		rect := *r.Rect
+		r.Rect = &rect
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.exists(r.Name) {
+		return fmt.Errorf("roi: duplicate region %q", r.Name)
+	}
+	e.regions = append(e.regions, &regionState{region: r})
+	return nil
+}
+
+// AddVolume 添加 3D 体积，名称不能与已有区域或体积重复
+func (e *Engine) AddVolume(v Volume) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	for _, p := range []**Point3{&v.Min, &v.Max, &v.Center, &v.Size} {
+		if *p != nil {
+			c := **p
+			*p = &c
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.exists(v.Name) {
+		return fmt.Errorf("roi: duplicate region %q", v.Name)
+	}
+	e.volumes = append(e.volumes, &volumeState{volume: v})
+	return nil
+}
+
+// exists 判断名称是否已被区域或体积使用，调用方需持有锁
+func (e *Engine) exists(name string) bool {
+	for _, s := range e.regions {
+		if s.region.Name == name {
+			return true
+		}
+	}
+	for _, s := range e.volumes {
+		if s.volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove 删除区域或体积，不存在时返回 false
+func (e *Engine) Remove(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, s := range e.regions {
+		if s.region.Name == name {
+			e.regions = append(e.regions[:i], e.regions[i+1:]...)
+			return true
+		}
+	}
+	for i, s := range e.volumes {
+		if s.volume.Name == name {
+			e.volumes = append(e.volumes[:i], e.volumes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Volumes 返回当前所有体积定义
+func (e *Engine) Volumes() []Volume {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Volume, len(e.volumes))
+	for i, s := range e.volumes {
+		out[i] = s.volume
+	}
+	return out
+}
+
+// SetCameraPose 设置相机在世界坐标系中的位姿，之后体积坐标按世界坐标解释
+func (e *Engine) SetCameraPose(p Pose) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pose = p
+}
+
+// CameraPose 返回当前相机位姿，默认为单位位姿（体积在相机坐标系中）
+func (e *Engine) CameraPose() Pose {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.pose
+}
+
+// Regions 返回当前所有区域定义
+func (e *Engine) Regions() []Region {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Region, len(e.regions))
+	for i, s := range e.regions {
+		out[i] = s.region
+	}
+	return out
+}
+
+// ReferenceSize 返回参考分辨率
+func (e *Engine) ReferenceSize() (int, int) {
+	return e.refWidth, e.refHeight
+}
+
+// Stats 返回区域或体积最近一帧的统计结果
+func (e *Engine) Stats(name string) (Stats, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range e.regions {
+		if s.region.Name == name {
+			return s.last, true
+		}
+	}
+	for _, s := range e.volumes {
+		if s.volume.Name == name {
+			return s.last, true
+		}
+	}
+	return Stats{}, false
+}
+
+// Active 返回区域或体积是否处于已触发且尚未解除的状态
+func (e *Engine) Active(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range e.regions {
+		if s.region.Name == name {
+			return s.active
+		}
+	}
+	for _, s := range e.volumes {
+		if s.volume.Name == name {
+			return s.active
+		}
+	}
+	return false
+}
+
+// Reset 清空所有区域的去抖、迟滞和冷却状态
+func (e *Engine) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range e.regions {
+		s.triggerState = triggerState{}
+		s.last = Stats{}
+	}
+	for _, s := range e.volumes {
+		s.triggerState = triggerState{}
+		s.last = Stats{}
+	}
+}
+
+// Subscribe 订阅触发事件，bufSize 为通道缓冲大小
+// 事件以非阻塞方式发送，消费不及时的事件会被丢弃并计入 Dropped
+func (e *Engine) Subscribe(bufSize int) <-chan Event {
+	if bufSize < 1 {
+		bufSize = 1
+	}
+	ch := make(chan Event, bufSize)
+	e.mu.Lock()
+	e.subs = append(e.subs, ch)
+	e.mu.Unlock()
+	return ch
+}
+
+// Dropped 返回因订阅者消费不及时而丢弃的事件数
+func (e *Engine) Dropped() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Close 关闭所有订阅通道
+func (e *Engine) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, ch := range e.subs {
+		close(ch)
+	}
+	e.subs = nil
+}
+
+// Process 判定一帧，返回本帧产生的触发事件（同时发送给订阅者）
+func (e *Engine) Process(f Frame) ([]Event, error) {
+	img := f.Depth
+	if img == nil || img.Width <= 0 || img.Height <= 0 || len(img.Pix) < img.Width*img.Height {
+		return nil, fmt.Errorf("roi: invalid depth frame")
+	}
+	if f.Scale <= 0 {
+		return nil, fmt.Errorf("roi: invalid depth scale %g", f.Scale)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.volumes) > 0 {
+		if f.Intrinsics == nil {
+			return nil, fmt.Errorf("roi: intrinsics are required to evaluate volumes")
+		}
+		if f.Intrinsics.Width != img.Width || f.Intrinsics.Height != img.Height {
+			return nil, fmt.Errorf("roi: intrinsics %dx%d do not match depth frame %dx%d",
+				f.Intrinsics.Width, f.Intrinsics.Height, img.Width, img.Height)
+		}
+	}
+
+	var events []Event
+	for _, s := range e.regions {
+		st := e.measure(s, f)
+		s.last = st
+		if s.update(st.Points, s.params(st.Threshold), f.Timestamp) {
+			events = append(events, e.emit(s.region.Name, f, st))
+		}
+	}
+	for _, s := range e.volumes {
+		s.prepare(*f.Intrinsics, e.pose)
+		st := s.measure(f)
+		s.last = st
+		if s.update(st.Points, s.params(), f.Timestamp) {
+			events = append(events, e.emit(s.volume.Name, f, st))
+		}
+	}
+	return events, nil
+}
+
+// emit 创建事件并发送给订阅者，调用方需持有锁
+func (e *Engine) emit(name string, f Frame, st Stats) Event {
+	ev := Event{
+		Region:      name,
+		Timestamp:   f.Timestamp,
+		FrameNumber: f.FrameNumber,
+		Time:        time.Now(),
+		Stats:       st,
+	}
+	for _, ch := range e.subs {
+		select {
+		case ch <- ev:
+		default:
+			e.dropped++
+		}
+	}
+	return ev
+}
+
+// measure 统计区域在当前帧的命中情况
+func (e *Engine) measure(s *regionState, f Frame) Stats {
+	img := f.Depth
+	if s.mask == nil || s.width != img.Width || s.height != img.Height {
+		sx := float64(e.refWidth) / float64(img.Width)
+		sy := float64(e.refHeight) / float64(img.Height)
+		s.mask = s.region.mask(img.Width, img.Height, sx, sy)
+		s.width, s.height = img.Width, img.Height
+	}
+
+	area := float64(img.Width*img.Height) / float64(e.refWidth*e.refHeight)
+	st := Stats{
+		Pixels:    len(s.mask),
+		Threshold: int(math.Ceil(float64(s.region.MinPoints) * area)),
+	}
+	if st.Threshold < 1 {
+		st.Threshold = 1
+	}
+
+	var sum float64
+	minD, maxD := s.region.MinDistance, s.region.MaxDistance
+	for _, i := range s.mask {
+		v := img.Pix[i]
+		if v == 0 {
+			continue
+		}
+		st.Valid++
+		d := float32(v) * f.Scale
+		if d < minD || d > maxD {
+			continue
+		}
+		if st.Points == 0 || d < st.MinDepth {
+			st.MinDepth = d
+		}
+		if d > st.MaxDepth {
+			st.MaxDepth = d
+		}
+		sum += float64(d)
+		st.Points++
+	}
+	if st.Points > 0 {
+		st.MeanDepth = float32(sum / float64(st.Points))
+	}
+	if st.Pixels > 0 {
+		st.Ratio = float32(st.Points) / float32(st.Pixels)
+	}
+	return st
+}
+
+// params 返回区域的状态机参数，threshold 为换算后的触发点数
+func (s *regionState) params(threshold int) triggerParams {
+	r := s.region
+	p := triggerParams{
+		threshold:  threshold,
+		releaseAt:  threshold,
+		debounce:   r.Debounce,
+		release:    r.Release,
+		cooldownMS: r.CooldownMS,
+	}
+	if r.ReleasePoints > 0 {
+		p.releaseAt = int(math.Ceil(float64(r.ReleasePoints) * float64(threshold) / float64(r.MinPoints)))
+	}
+	return p
+}
+
+// update 推进去抖/迟滞/冷却状态机，返回本帧是否触发
+func (t *triggerState) update(points int, p triggerParams, ts float64) bool {
+	debounce, release := max(p.debounce, 1), max(p.release, 1)
+
+	if t.active {
+		// 已触发：点数持续低于解除阈值才重新布防
+		if points < p.releaseAt {
+			t.misses++
+			if t.misses >= release {
+				t.active = false
+				t.hits = 0
+			}
+		} else {
+			t.misses = 0
+		}
+		return false
+	}
+
+	if points < p.threshold {
+		t.hits = 0
+		return false
+	}
+	t.hits++
+	if t.hits < debounce {
+		return false
+	}
+	if t.fired && p.cooldownMS > 0 && ts-t.lastFire < p.cooldownMS {
+		return false
+	}
+
+	t.active = true
+	t.misses = 0
+	t.fired = true
+	t.lastFire = ts
+	return true
+}
@@ -0,0 +1,271 @@
+package roi
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+const testScale = 0.001 // 1 单位 = 1 毫米
+
+// fillFrame 生成 w x h 的深度帧，前 n 个像素为 value，其余为 0
+func fillFrame(w, h, n int, value uint16, ts float64) Frame {
+	img := depth.NewImage(w, h)
+	for i := 0; i < n && i < len(img.Pix); i++ {
+		img.Pix[i] = value
+	}
+	return Frame{Depth: img, Scale: testScale, Timestamp: ts, FrameNumber: uint64(ts)}
+}
+
+// runSequence 逐帧送入命中点数，返回每帧是否触发和触发后的 Active 状态
+func runSequence(t *testing.T, r Region, points []int, stepMS float64) (fired, active []bool) {
+	t.Helper()
+	e, err := NewEngine(10, 10, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range points {
+		events, err := e.Process(fillFrame(10, 10, n, 1000, float64(i)*stepMS))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fired = append(fired, len(events) == 1)
+		active = append(active, e.Active(r.Name))
+	}
+	return fired, active
+}
+
+func TestTriggerStateMachine(t *testing.T) {
+	base := Region{Name: "a", Rect: &Rect{0, 0, 10, 10}, MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 50}
+	tests := []struct {
+		name   string
+		modify func(*Region)
+		points []int
+		stepMS float64
+		fires  []int // 触发的帧序号
+	}{
+		{
+			name:   "debounce resets on miss",
+			modify: func(r *Region) { r.Debounce = 3 },
+			points: []int{100, 100, 0, 100, 100, 100, 100},
+			fires:  []int{5},
+		},
+		{
+			name:   "stays active without re-firing",
+			points: []int{100, 100, 100},
+			fires:  []int{0},
+		},
+		{
+			name:   "release needs consecutive misses",
+			modify: func(r *Region) { r.Release = 2 },
+			points: []int{100, 0, 100, 0, 0, 100},
+			fires:  []int{0, 5},
+		},
+		{
+			name:   "threshold is inclusive",
+			points: []int{49, 50},
+			fires:  []int{1},
+		},
+		{
+			// 触发后 30 点仍不低于解除阈值 20，保持触发；降到 10 才解除，之后 40 点不足 50 不再触发
+			name:   "hysteresis",
+			modify: func(r *Region) { r.ReleasePoints = 20 },
+			points: []int{60, 30, 20, 10, 40, 50},
+			fires:  []int{0, 5},
+		},
+		{
+			name:   "no hysteresis re-fires around threshold",
+			points: []int{60, 30, 60, 30, 60},
+			fires:  []int{0, 2, 4},
+		},
+		{
+			// 100ms 一帧，冷却 1000ms：解除后再次满足条件也要等到 t=1000 才触发
+			name:   "cooldown suppresses re-trigger",
+			modify: func(r *Region) { r.CooldownMS = 1000 },
+			points: []int{100, 0, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+			stepMS: 100,
+			fires:  []int{0, 10},
+		},
+		{
+			name:   "cooldown and debounce",
+			modify: func(r *Region) { r.CooldownMS = 250; r.Debounce = 2 },
+			points: []int{100, 100, 0, 100, 100, 0, 100, 100},
+			stepMS: 100,
+			fires:  []int{1, 4, 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := base
+			if tt.modify != nil {
+				tt.modify(&r)
+			}
+			step := tt.stepMS
+			if step == 0 {
+				step = 33
+			}
+			fired, _ := runSequence(t, r, tt.points, step)
+			var got []int
+			for i, f := range fired {
+				if f {
+					got = append(got, i)
+				}
+			}
+			if !slices.Equal(got, tt.fires) {
+				t.Errorf("fired at %v, want %v", got, tt.fires)
+			}
+		})
+	}
+}
+
+func TestActiveState(t *testing.T) {
+	r := Region{Name: "a", Rect: &Rect{0, 0, 10, 10}, MinDistance: 0.5, MaxDistance: 1.5,
+		MinPoints: 50, ReleasePoints: 20, Release: 2}
+	_, active := runSequence(t, r, []int{100, 10, 30, 10, 10, 100}, 33)
+	want := []bool{true, true, true, true, false, true}
+	for i := range want {
+		if active[i] != want[i] {
+			t.Errorf("frame %d: active %v, want %v", i, active[i], want[i])
+		}
+	}
+}
+
+func TestStatsDistanceBand(t *testing.T) {
+	e, err := NewEngine(10, 10, Region{Name: "a", Rect: &Rect{0, 0, 10, 10},
+		MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := depth.NewImage(10, 10)
+	// 比例取 1/1024 使边界距离可精确表示（0.001 的 float32 乘积会略大于 1.5）
+	// 第 0 行为 0（无效），第 1 行 3 米（超出范围），第 2-3 行 0.5 和 1.5 米（边界包含），其余 1 米
+	for i := range img.Pix {
+		switch i / 10 {
+		case 0:
+		case 1:
+			img.Pix[i] = 3072
+		case 2:
+			img.Pix[i] = 512
+		case 3:
+			img.Pix[i] = 1536
+		default:
+			img.Pix[i] = 1024
+		}
+	}
+	events, err := e.Process(Frame{Depth: img, Scale: 1.0 / 1024, Timestamp: 5, FrameNumber: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Region != "a" || events[0].FrameNumber != 7 || events[0].Timestamp != 5 {
+		t.Fatalf("events %+v", events)
+	}
+	st, _ := e.Stats("a")
+	if st.Pixels != 100 || st.Valid != 90 || st.Points != 80 || st.Threshold != 10 {
+		t.Errorf("stats %+v", st)
+	}
+	if st.MinDepth != 0.5 || st.MaxDepth != 1.5 || !near(st.MeanDepth, 1.0) || !near(st.Ratio, 0.8) {
+		t.Errorf("depth stats %+v", st)
+	}
+}
+
+func TestThresholdScalesWithResolution(t *testing.T) {
+	tests := []struct {
+		w, h      int
+		threshold int
+	}{
+		{40, 40, 100},
+		{20, 20, 25},
+		{10, 10, 7}, // ceil(100/16)
+		{1, 1, 1},   // 至少为 1
+	}
+	for _, tt := range tests {
+		e, err := NewEngine(40, 40, Region{Name: "a", Rect: &Rect{0, 0, 40, 40},
+			MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Process(fillFrame(tt.w, tt.h, tt.w*tt.h, 1000, 0)); err != nil {
+			t.Fatal(err)
+		}
+		st, _ := e.Stats("a")
+		if st.Threshold != tt.threshold || st.Pixels != tt.w*tt.h {
+			t.Errorf("%dx%d: threshold %d pixels %d, want %d %d", tt.w, tt.h, st.Threshold, st.Pixels, tt.threshold, tt.w*tt.h)
+		}
+	}
+}
+
+func TestSubscribeAndDropped(t *testing.T) {
+	e, err := NewEngine(10, 10, Region{Name: "a", Rect: &Rect{0, 0, 10, 10},
+		MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := e.Subscribe(1)
+	for i := 0; i < 4; i++ {
+		// 交替有物体/无物体，每两帧触发一次
+		n := 0
+		if i%2 == 0 {
+			n = 100
+		}
+		if _, err := e.Process(fillFrame(10, 10, n, 1000, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ev := <-ch; ev.Region != "a" || ev.FrameNumber != 0 {
+		t.Errorf("event %+v", ev)
+	}
+	if e.Dropped() != 1 {
+		t.Errorf("dropped %d, want 1", e.Dropped())
+	}
+	e.Close()
+	if _, ok := <-ch; ok {
+		t.Error("channel not closed")
+	}
+}
+
+func TestResetClearsState(t *testing.T) {
+	e, err := NewEngine(10, 10, Region{Name: "a", Rect: &Rect{0, 0, 10, 10},
+		MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 1, CooldownMS: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := fillFrame(10, 10, 100, 1000, 0)
+	if ev, _ := e.Process(full); len(ev) != 1 {
+		t.Fatal("first frame did not fire")
+	}
+	e.Reset()
+	if e.Active("a") {
+		t.Error("active after Reset")
+	}
+	// 冷却也被清除
+	if ev, _ := e.Process(full); len(ev) != 1 {
+		t.Error("did not fire after Reset")
+	}
+}
+
+func TestProcessRejectsInvalidFrames(t *testing.T) {
+	e, err := NewEngine(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := fillFrame(4, 4, 0, 0, 0)
+	tests := []struct {
+		name string
+		f    Frame
+	}{
+		{"nil depth", Frame{Scale: testScale}},
+		{"short buffer", Frame{Depth: &depth.Image{Width: 4, Height: 4, Pix: make([]uint16, 15)}, Scale: testScale}},
+		{"zero scale", Frame{Depth: good.Depth}},
+	}
+	for _, tt := range tests {
+		if _, err := e.Process(tt.f); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func near(a, b float32) bool {
+	d := a - b
+	return d < 1e-5 && d > -1e-5
+}
@@ -0,0 +1,146 @@
+// Package roi 实现基于深度的兴趣区域 (ROI) 触发
+// 区域定义在彩色图像素坐标系中（深度已对齐到彩色），按距离范围统计区域内的点数，
+// 连续满足条件若干帧后产生触发事件
+package roi
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point 是参考坐标系（彩色图像素）中的一个点
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Rect 是参考坐标系中的矩形区域
+type Rect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Region 是一个命名的触发区域
+// Rect 与 Polygon 二选一；距离单位为米，闭区间 [MinDistance, MaxDistance]，深度为 0 的像素始终忽略
+type Region struct {
+	Name    string  `json:"name"`
+	Rect    *Rect   `json:"rect,omitempty"`
+	Polygon []Point `json:"polygon,omitempty"`
+
+	MinDistance float32 `json:"min_distance"`
+	MaxDistance float32 `json:"max_distance"`
+
+	// MinPoints 是触发所需的最少命中点数，按参考分辨率计；
+	// 深度图分辨率不同（例如经过降采样）时按面积比例换算
+	MinPoints int `json:"min_points"`
+	// ReleasePoints 是解除触发状态的点数阈值，低于该值才会重新布防，0 表示等于 MinPoints
+	// 设置为小于 MinPoints 的值可形成迟滞，避免在阈值附近反复触发
+	ReleasePoints int `json:"release_points,omitempty"`
+
+	// Debounce 是连续满足条件的帧数，达到后才触发，0 按 1 处理
+	Debounce int `json:"debounce,omitempty"`
+	// Release 是连续低于 ReleasePoints 的帧数，达到后才重新布防，0 按 1 处理
+	Release int `json:"release,omitempty"`
+	// CooldownMS 是两次触发之间的最小间隔（毫秒，按帧时间戳计算）
+	CooldownMS float64 `json:"cooldown_ms,omitempty"`
+}
+
+// Validate 校验区域定义
+func (r Region) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("roi: region name is required")
+	}
+	switch {
+	case r.Rect != nil && len(r.Polygon) > 0:
+		return fmt.Errorf("roi %q: rect and polygon are mutually exclusive", r.Name)
+	case r.Rect != nil:
+		if r.Rect.W <= 0 || r.Rect.H <= 0 {
+			return fmt.Errorf("roi %q: invalid rect size %dx%d", r.Name, r.Rect.W, r.Rect.H)
+		}
+	case len(r.Polygon) > 0:
+		if len(r.Polygon) < 3 {
+			return fmt.Errorf("roi %q: polygon needs at least 3 points", r.Name)
+		}
+	default:
+		return fmt.Errorf("roi %q: rect or polygon is required", r.Name)
+	}
+	if r.MinDistance < 0 || r.MaxDistance <= r.MinDistance {
+		return fmt.Errorf("roi %q: invalid distance band [%g, %g]", r.Name, r.MinDistance, r.MaxDistance)
+	}
+	if r.MinPoints <= 0 {
+		return fmt.Errorf("roi %q: min_points must be positive", r.Name)
+	}
+	if r.ReleasePoints < 0 || r.ReleasePoints > r.MinPoints {
+		return fmt.Errorf("roi %q: release_points must be in [0, %d]", r.Name, r.MinPoints)
+	}
+	if r.Debounce < 0 || r.Release < 0 || r.CooldownMS < 0 {
+		return fmt.Errorf("roi %q: debounce, release and cooldown must not be negative", r.Name)
+	}
+	return nil
+}
+
+// Bounds 返回区域在参考坐标系中的外接矩形
+func (r Region) Bounds() Rect {
+	if r.Rect != nil {
+		return *r.Rect
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range r.Polygon {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	x, y := int(math.Floor(minX)), int(math.Floor(minY))
+	return Rect{X: x, Y: y, W: int(math.Ceil(maxX)) - x, H: int(math.Ceil(maxY)) - y}
+}
+
+// contains 判断参考坐标系中的点是否在区域内
+func (r Region) contains(x, y float64) bool {
+	if r.Rect != nil {
+		return x >= float64(r.Rect.X) && x < float64(r.Rect.X+r.Rect.W) &&
+			y >= float64(r.Rect.Y) && y < float64(r.Rect.Y+r.Rect.H)
+	}
+
+	// 射线法
+	inside := false
+	n := len(r.Polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := r.Polygon[i], r.Polygon[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// mask 计算区域在 width x height 深度图中覆盖的像素下标
+// sx, sy 为深度像素到参考坐标的缩放比例，按像素中心判断是否在区域内
+func (r Region) mask(width, height int, sx, sy float64) []int32 {
+	b := r.Bounds()
+	x0 := clamp(int(math.Floor(float64(b.X)/sx)), 0, width)
+	y0 := clamp(int(math.Floor(float64(b.Y)/sy)), 0, height)
+	x1 := clamp(int(math.Ceil(float64(b.X+b.W)/sx)), 0, width)
+	y1 := clamp(int(math.Ceil(float64(b.Y+b.H)/sy)), 0, height)
+
+	var idx []int32
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if r.contains((float64(x)+0.5)*sx, (float64(y)+0.5)*sy) {
+				idx = append(idx, int32(y*width+x))
+			}
+		}
+	}
+	return idx
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
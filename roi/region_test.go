@@ -0,0 +1,108 @@
+package roi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMaskScaling(t *testing.T) {
+	// 参考分辨率 8x8；直角三角形 (0,0)-(8,0)-(0,8) 按像素中心判断，
+	// 像素 (x, y) 在内当且仅当中心坐标之和 < 8
+	triangle := Region{Name: "tri", Polygon: []Point{{0, 0}, {8, 0}, {0, 8}}}
+	square := Region{Name: "sq", Polygon: []Point{{2, 2}, {6, 2}, {6, 6}, {2, 6}}}
+	rect := Region{Name: "rect", Rect: &Rect{X: 2, Y: 2, W: 4, H: 4}}
+	tests := []struct {
+		name   string
+		region Region
+		w, h   int
+		count  int
+	}{
+		{"triangle 8x8", triangle, 8, 8, 28},      // x+y <= 6
+		{"triangle 4x4", triangle, 4, 4, 6},       // 中心 (2x+1, 2y+1)：x+y <= 2
+		{"triangle 16x16", triangle, 16, 16, 120}, // x+y <= 14
+		{"triangle 16x4", triangle, 16, 4, 32},    // 非等比缩放：每行 14、10、6、2 个
+		{"square 8x8", square, 8, 8, 16},
+		{"square 4x4", square, 4, 4, 4},
+		{"square 16x16", square, 16, 16, 64},
+		{"rect 8x8", rect, 8, 8, 16},
+		{"rect 4x4", rect, 4, 4, 4},
+		{"rect 16x16", rect, 16, 16, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := tt.region.mask(tt.w, tt.h, 8/float64(tt.w), 8/float64(tt.h))
+			if len(idx) != tt.count {
+				t.Errorf("mask has %d pixels, want %d", len(idx), tt.count)
+			}
+		})
+	}
+}
+
+func TestMaskIndices(t *testing.T) {
+	rect := Region{Name: "rect", Rect: &Rect{X: 2, Y: 2, W: 4, H: 4}}
+	// 4x4 深度图中每个像素对应参考坐标中的 2x2
+	if got, want := rect.mask(4, 4, 2, 2), []int32{5, 6, 9, 10}; !slices.Equal(got, want) {
+		t.Errorf("rect mask %v, want %v", got, want)
+	}
+	// 矩形与多边形形式的同一区域得到相同的掩码
+	poly := Region{Name: "poly", Polygon: []Point{{2, 2}, {6, 2}, {6, 6}, {2, 6}}}
+	if a, b := rect.mask(16, 16, 0.5, 0.5), poly.mask(16, 16, 0.5, 0.5); !slices.Equal(a, b) {
+		t.Errorf("rect and polygon masks differ: %v vs %v", a, b)
+	}
+	// 超出图像的部分被裁掉
+	edge := Region{Name: "edge", Rect: &Rect{X: 6, Y: 6, W: 10, H: 10}}
+	if got, want := edge.mask(8, 8, 1, 1), []int32{54, 55, 62, 63}; !slices.Equal(got, want) {
+		t.Errorf("clipped mask %v, want %v", got, want)
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	// 凹多边形 (L 形)
+	l := Region{Name: "l", Polygon: []Point{{0, 0}, {4, 0}, {4, 2}, {2, 2}, {2, 4}, {0, 4}}}
+	tests := []struct {
+		x, y float64
+		in   bool
+	}{
+		{1, 1, true},
+		{3, 1, true},
+		{1, 3, true},
+		{3, 3, false}, // 缺角
+		{5, 1, false},
+		{-1, 1, false},
+	}
+	for _, tt := range tests {
+		if got := l.contains(tt.x, tt.y); got != tt.in {
+			t.Errorf("contains(%g, %g) = %v, want %v", tt.x, tt.y, got, tt.in)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	r := Region{Name: "p", Polygon: []Point{{1.5, 2.2}, {7.1, 3}, {4, 9.9}}}
+	if got, want := r.Bounds(), (Rect{X: 1, Y: 2, W: 7, H: 8}); got != want {
+		t.Errorf("Bounds() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEnginePolygonAtScaledResolution(t *testing.T) {
+	// 参考分辨率 640x480 的三角形，在降采样到 160x120 的深度图上评估
+	e, err := NewEngine(640, 480, Region{Name: "tri", Polygon: []Point{{0, 0}, {640, 0}, {0, 480}},
+		MinDistance: 0.5, MaxDistance: 1.5, MinPoints: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range [][2]int{{640, 480}, {160, 120}, {320, 240}} {
+		w, h := size[0], size[1]
+		if _, err := e.Process(fillFrame(w, h, w*h, 1000, 0)); err != nil {
+			t.Fatal(err)
+		}
+		st, _ := e.Stats("tri")
+		// 三角形约占一半面积
+		if half := w * h / 2; st.Pixels < half-w || st.Pixels > half+w {
+			t.Errorf("%dx%d: %d pixels, want about %d", w, h, st.Pixels, half)
+		}
+		if st.Points != st.Pixels {
+			t.Errorf("%dx%d: %d points, want %d", w, h, st.Points, st.Pixels)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package roi
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// Point3 是 3D 坐标（米），相机坐标系为 X 向右、Y 向下、Z 向前
+type Point3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Pose 描述相机在世界坐标系中的位姿（world = R * camera + Position）
+// 旋转使用欧拉角（度），按 Z(yaw) * Y(pitch) * X(roll) 顺序组合
+type Pose struct {
+	Position    Point3 `json:"position"`
+	RotationDeg Point3 `json:"rotation_deg"`
+}
+
+// Volume 是一个命名的 3D 触发体积
+// Min/Max 定义轴对齐盒 (AABB)；Center/Size/RotationDeg 定义有向盒 (OBB)，两者二选一。
+// 坐标默认在相机坐标系中，Engine 设置了 CameraPose 时在世界坐标系中
+type Volume struct {
+	Name string `json:"name"`
+
+	Min *Point3 `json:"min,omitempty"`
+	Max *Point3 `json:"max,omitempty"`
+
+	Center      *Point3 `json:"center,omitempty"`
+	Size        *Point3 `json:"size,omitempty"`         // 盒子的完整边长（米）
+	RotationDeg Point3  `json:"rotation_deg,omitempty"` // 盒子自身的旋转（度），仅 OBB 使用
+
+	// 触发参数与 Region 相同，点数按深度图实际像素计
+	MinPoints     int     `json:"min_points"`
+	ReleasePoints int     `json:"release_points,omitempty"`
+	Debounce      int     `json:"debounce,omitempty"`
+	Release       int     `json:"release,omitempty"`
+	CooldownMS    float64 `json:"cooldown_ms,omitempty"`
+}
+
+// Validate 校验体积定义
+func (v Volume) Validate() error {
+	if v.Name == "" {
+		return fmt.Errorf("roi: volume name is required")
+	}
+	aabb := v.Min != nil || v.Max != nil
+	obb := v.Center != nil || v.Size != nil
+	switch {
+	case aabb && obb:
+		return fmt.Errorf("roi %q: min/max and center/size are mutually exclusive", v.Name)
+	case aabb:
+		if v.Min == nil || v.Max == nil {
+			return fmt.Errorf("roi %q: both min and max are required", v.Name)
+		}
+		if v.Max.X <= v.Min.X || v.Max.Y <= v.Min.Y || v.Max.Z <= v.Min.Z {
+			return fmt.Errorf("roi %q: max must be greater than min on every axis", v.Name)
+		}
+	case obb:
+		if v.Center == nil || v.Size == nil {
+			return fmt.Errorf("roi %q: both center and size are required", v.Name)
+		}
+		if v.Size.X <= 0 || v.Size.Y <= 0 || v.Size.Z <= 0 {
+			return fmt.Errorf("roi %q: invalid box size", v.Name)
+		}
+	default:
+		return fmt.Errorf("roi %q: min/max or center/size is required", v.Name)
+	}
+	if v.MinPoints <= 0 {
+		return fmt.Errorf("roi %q: min_points must be positive", v.Name)
+	}
+	if v.ReleasePoints < 0 || v.ReleasePoints > v.MinPoints {
+		return fmt.Errorf("roi %q: release_points must be in [0, %d]", v.Name, v.MinPoints)
+	}
+	if v.Debounce < 0 || v.Release < 0 || v.CooldownMS < 0 {
+		return fmt.Errorf("roi %q: debounce, release and cooldown must not be negative", v.Name)
+	}
+	return nil
+}
+
+// box 返回盒子的中心、半边长和旋转矩阵（盒子坐标到外部坐标）
+func (v Volume) box() (center, half [3]float64, rot [3][3]float64) {
+	if v.Min != nil {
+		center = [3]float64{(v.Min.X + v.Max.X) / 2, (v.Min.Y + v.Max.Y) / 2, (v.Min.Z + v.Max.Z) / 2}
+		half = [3]float64{(v.Max.X - v.Min.X) / 2, (v.Max.Y - v.Min.Y) / 2, (v.Max.Z - v.Min.Z) / 2}
+		return center, half, rotation(Point3{})
+	}
+	center = [3]float64{v.Center.X, v.Center.Y, v.Center.Z}
+	half = [3]float64{v.Size.X / 2, v.Size.Y / 2, v.Size.Z / 2}
+	return center, half, rotation(v.RotationDeg)
+}
+
+// rotation 由欧拉角（度）计算旋转矩阵 Rz * Ry * Rx
+func rotation(deg Point3) [3][3]float64 {
+	rx, ry, rz := deg.X*math.Pi/180, deg.Y*math.Pi/180, deg.Z*math.Pi/180
+	cx, sx := math.Cos(rx), math.Sin(rx)
+	cy, sy := math.Cos(ry), math.Sin(ry)
+	cz, sz := math.Cos(rz), math.Sin(rz)
+	return [3][3]float64{
+		{cz * cy, cz*sy*sx - sz*cx, cz*sy*cx + sz*sx},
+		{sz * cy, sz*sy*sx + cz*cx, sz*sy*cx - cz*sx},
+		{-sy, cy * sx, cy * cx},
+	}
+}
+
+// mulT 计算 R^T * p
+func mulT(r [3][3]float64, p [3]float64) [3]float64 {
+	return [3]float64{
+		r[0][0]*p[0] + r[1][0]*p[1] + r[2][0]*p[2],
+		r[0][1]*p[0] + r[1][1]*p[1] + r[2][1]*p[2],
+		r[0][2]*p[0] + r[1][2]*p[1] + r[2][2]*p[2],
+	}
+}
+
+// mul 计算 R * p
+func mul(r [3][3]float64, p [3]float64) [3]float64 {
+	return [3]float64{
+		r[0][0]*p[0] + r[0][1]*p[1] + r[0][2]*p[2],
+		r[1][0]*p[0] + r[1][1]*p[1] + r[1][2]*p[2],
+		r[2][0]*p[0] + r[2][1]*p[1] + r[2][2]*p[2],
+	}
+}
+
+// volumeRay 是穿过体积的像素射线
+// 像素深度 d（米）落在 [enter, exit] 内表示该点在体积中，小于 enter 表示被前方物体遮挡
+type volumeRay struct {
+	index int32
+	enter float32
+	exit  float32
+}
+
+// volumeState 是体积的运行状态
+type volumeState struct {
+	triggerState
+	volume     Volume
+	intrinsics depth.Intrinsics // rays 对应的内参
+	pose       Pose             // rays 对应的相机位姿
+	rays       []volumeRay
+	last       Stats
+}
+
+// prepare 在内参或位姿变化时重新计算穿过体积的射线
+func (s *volumeState) prepare(in depth.Intrinsics, pose Pose) {
+	if s.rays != nil && s.intrinsics == in && s.pose == pose {
+		return
+	}
+	s.intrinsics, s.pose = in, pose
+
+	center, half, boxRot := s.volume.box()
+	camRot := rotation(pose.RotationDeg)
+
+	// 射线起点（相机原点）在盒子坐标系中的位置
+	origin := mulT(boxRot, [3]float64{
+		pose.Position.X - center[0],
+		pose.Position.Y - center[1],
+		pose.Position.Z - center[2],
+	})
+
+	s.rays = s.rays[:0]
+	for y := 0; y < in.Height; y++ {
+		for x := 0; x < in.Width; x++ {
+			r := in.Ray(float32(x), float32(y))
+			// 方向：相机 -> 世界 -> 盒子，参数 t 即为深度 (Z)
+			dir := mulT(boxRot, mul(camRot, [3]float64{float64(r[0]), float64(r[1]), float64(r[2])}))
+			enter, exit, ok := slab(origin, dir, half)
+			if !ok {
+				continue
+			}
+			s.rays = append(s.rays, volumeRay{index: int32(y*in.Width + x), enter: float32(enter), exit: float32(exit)})
+		}
+	}
+	if s.rays == nil {
+		s.rays = []volumeRay{}
+	}
+}
+
+// slab 计算射线 origin + t*dir 与以原点为中心、半边长 half 的盒子的交点参数区间 (t >= 0)
+func slab(origin, dir, half [3]float64) (float64, float64, bool) {
+	tmin, tmax := 0.0, math.Inf(1)
+	for k := 0; k < 3; k++ {
+		if math.Abs(dir[k]) < 1e-12 {
+			if math.Abs(origin[k]) > half[k] {
+				return 0, 0, false
+			}
+			continue
+		}
+		t1 := (-half[k] - origin[k]) / dir[k]
+		t2 := (half[k] - origin[k]) / dir[k]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tmin = math.Max(tmin, t1)
+		tmax = math.Min(tmax, t2)
+		if tmin > tmax {
+			return 0, 0, false
+		}
+	}
+	return tmin, tmax, true
+}
+
+// measure 统计体积在当前帧的占用情况
+// Pixels 为视线穿过体积的像素数，Points 为落在体积内的点数，Occluded 为被前方物体遮挡的点数；
+// Occupancy = Points / (Valid - Occluded) * 100，即未被遮挡的视线中被体积内物体挡住的比例
+func (s *volumeState) measure(f Frame) Stats {
+	img := f.Depth
+	st := Stats{Pixels: len(s.rays), Threshold: s.volume.MinPoints}
+
+	var sum float64
+	for _, r := range s.rays {
+		v := img.Pix[r.index]
+		if v == 0 {
+			continue
+		}
+		st.Valid++
+		d := float32(v) * f.Scale
+		if d < r.enter {
+			st.Occluded++
+			continue
+		}
+		if d > r.exit {
+			continue
+		}
+		if st.Points == 0 || d < st.MinDepth {
+			st.MinDepth = d
+		}
+		if d > st.MaxDepth {
+			st.MaxDepth = d
+		}
+		sum += float64(d)
+		st.Points++
+	}
+	if st.Points > 0 {
+		st.MeanDepth = float32(sum / float64(st.Points))
+	}
+	if st.Pixels > 0 {
+		st.Ratio = float32(st.Points) / float32(st.Pixels)
+	}
+	if visible := st.Valid - st.Occluded; visible > 0 {
+		st.Occupancy = float32(st.Points) / float32(visible) * 100
+	}
+	return st
+}
+
+// params 返回体积的状态机参数
+func (s *volumeState) params() triggerParams {
+	v := s.volume
+	p := triggerParams{
+		threshold:  v.MinPoints,
+		releaseAt:  v.MinPoints,
+		debounce:   v.Debounce,
+		release:    v.Release,
+		cooldownMS: v.CooldownMS,
+	}
+	if v.ReleasePoints > 0 {
+		p.releaseAt = v.ReleasePoints
+	}
+	return p
+}
@@ -0,0 +1,220 @@
+package roi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// volumeIntrinsics: 64x48，f=32，主点在 (32, 24)；像素 x 的射线方向为 ((x-32)/32, (y-24)/32, 1)
+var volumeIntrinsics = depth.Intrinsics{Width: 64, Height: 48, PPX: 32, PPY: 24, FX: 32, FY: 32}
+
+// unitsPerMeter 使测试中的距离可精确表示
+const unitsPerMeter = 1024
+
+// measurePixel 只在像素 (x, y) 放置距离 d（米）的点，返回体积的统计结果
+func measurePixel(t *testing.T, v Volume, pose Pose, x, y int, d float64) Stats {
+	t.Helper()
+	img := depth.NewImage(volumeIntrinsics.Width, volumeIntrinsics.Height)
+	img.Pix[y*img.Width+x] = uint16(math.Round(d * unitsPerMeter))
+	return measureImage(t, v, pose, img)
+}
+
+func measureImage(t *testing.T, v Volume, pose Pose, img *depth.Image) Stats {
+	t.Helper()
+	e, err := NewEngine(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetCameraPose(pose)
+	if err := e.AddVolume(v); err != nil {
+		t.Fatal(err)
+	}
+	in := volumeIntrinsics
+	if _, err := e.Process(Frame{Depth: img, Scale: 1.0 / unitsPerMeter, Intrinsics: &in}); err != nil {
+		t.Fatal(err)
+	}
+	st, _ := e.Stats(v.Name)
+	return st
+}
+
+// 轴对齐盒：x, y ∈ [-0.5, 0.5]，z ∈ [1, 2]
+var testAABB = Volume{Name: "box", Min: &Point3{-0.5, -0.5, 1}, Max: &Point3{0.5, 0.5, 2}, MinPoints: 1}
+
+// 有向盒：中心 (0, 0, 2)，边长 2 x 1 x 0.2，绕 Y 轴旋转 45°
+var testOBB = Volume{Name: "obb", Center: &Point3{0, 0, 2}, Size: &Point3{2, 1, 0.2},
+	RotationDeg: Point3{Y: 45}, MinPoints: 1}
+
+// pixelResult 是单个像素的判定结果
+type pixelResult int
+
+const (
+	outside  pixelResult = iota // 视线穿过体积，但点在体积之后
+	inside                      // 点在体积内
+	occluded                    // 点在体积之前，挡住了视线
+	noRay                       // 视线不穿过体积
+	invalid                     // 深度为 0
+)
+
+func TestVolumePixelDecisions(t *testing.T) {
+	flat := testOBB
+	flat.RotationDeg = Point3{}
+	shifted := Volume{Name: "world", Min: &Point3{-0.5, -0.5, 0}, Max: &Point3{0.5, 0.5, 1}, MinPoints: 1}
+	behind := Pose{Position: Point3{Z: -1}} // 相机在世界原点后 1 米
+
+	tests := []struct {
+		name   string
+		volume Volume
+		pose   Pose
+		x, y   int
+		d      float64
+		want   pixelResult
+	}{
+		{"aabb center inside", testAABB, Pose{}, 32, 24, 1.5, inside},
+		{"aabb front face", testAABB, Pose{}, 32, 24, 1, inside},
+		{"aabb back face", testAABB, Pose{}, 32, 24, 2, inside},
+		{"aabb in front", testAABB, Pose{}, 32, 24, 0.75, occluded},
+		{"aabb behind", testAABB, Pose{}, 32, 24, 2.5, outside},
+		{"aabb zero depth", testAABB, Pose{}, 32, 24, 0, invalid},
+		// x=48 的射线 (0.5, 0, 1) 只在 z=1 处擦过盒子的棱
+		{"aabb edge ray on edge", testAABB, Pose{}, 48, 24, 1, inside},
+		{"aabb edge ray beyond", testAABB, Pose{}, 48, 24, 1.25, outside},
+		{"aabb past edge", testAABB, Pose{}, 49, 24, 1, noRay},
+		{"aabb corner ray", testAABB, Pose{}, 16, 8, 1, inside},
+		// 旋转后中心射线的区间为 2 ± 0.1/cos45° = [1.859, 2.141]，未旋转时为 [1.9, 2.1]
+		{"obb rotated inside", testOBB, Pose{}, 32, 24, 2.125, inside},
+		{"flat obb same point", flat, Pose{}, 32, 24, 2.125, outside},
+		{"obb rotated near side", testOBB, Pose{}, 32, 24, 1.875, inside},
+		{"flat obb near side", flat, Pose{}, 32, 24, 1.875, occluded},
+		{"obb rotated beyond", testOBB, Pose{}, 32, 24, 2.25, outside},
+		// x=48 的射线：局部 z = cos45°·(1.5z - 2)，区间约 [1.239, 1.428]
+		{"obb off-axis inside", testOBB, Pose{}, 48, 24, 1.3125, inside},
+		{"obb off-axis occluded", testOBB, Pose{}, 48, 24, 1.125, occluded},
+		{"obb off-axis outside", testOBB, Pose{}, 48, 24, 1.5, outside},
+		// 相机位姿：体积在世界坐标 z ∈ [0, 1]，即相机坐标 z ∈ [1, 2]
+		{"pose inside", shifted, behind, 32, 24, 1.5, inside},
+		{"pose occluded", shifted, behind, 32, 24, 0.5, occluded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := measurePixel(t, tt.volume, tt.pose, tt.x, tt.y, tt.d)
+			got := outside
+			switch {
+			case st.Valid == 0 && tt.d == 0:
+				got = invalid
+			case st.Valid == 0:
+				got = noRay // 像素不在射线列表中
+			case st.Points == 1:
+				got = inside
+			case st.Occluded == 1:
+				got = occluded
+			}
+			if got != tt.want {
+				t.Errorf("result %d, want %d (stats %+v)", got, tt.want, st)
+			}
+		})
+	}
+}
+
+func TestVolumeRayCount(t *testing.T) {
+	// 射线 (rx, ry, 1) 穿过盒子当且仅当 |rx|, |ry| <= 0.5：x ∈ [16, 48]，y ∈ [8, 40]
+	st := measureImage(t, testAABB, Pose{}, depth.NewImage(64, 48))
+	if st.Pixels != 33*33 || st.Valid != 0 || st.Occupancy != 0 {
+		t.Errorf("stats %+v", st)
+	}
+}
+
+func TestVolumeOccupancy(t *testing.T) {
+	// 按列循环：无效、体积前方（遮挡）、体积前表面（命中）、体积后方
+	img := depth.NewImage(64, 48)
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			img.Pix[y*img.Width+x] = [4]uint16{0, unitsPerMeter / 2, unitsPerMeter, 3 * unitsPerMeter}[x%4]
+		}
+	}
+	st := measureImage(t, testAABB, Pose{}, img)
+	// 穿过盒子的 33 列中 x%4 为 0/1/2/3 的分别有 9/8/8/8 列
+	if st.Pixels != 1089 || st.Valid != 24*33 || st.Occluded != 8*33 || st.Points != 8*33 {
+		t.Errorf("stats %+v", st)
+	}
+	// 未被遮挡的有效视线中一半被命中
+	if st.Occupancy != 50 || !near(st.Ratio, float32(8*33)/1089) || st.MeanDepth != 1 {
+		t.Errorf("occupancy %g ratio %g mean %g", st.Occupancy, st.Ratio, st.MeanDepth)
+	}
+}
+
+func TestVolumeMatchesDeprojection(t *testing.T) {
+	// 与逐点反投影后判断是否在有向盒内的结果一致（距离边界 1e-4 米以内的点允许任一结果）
+	img := depth.NewImage(64, 48)
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint16(unitsPerMeter + seed>>16%(2*unitsPerMeter)) // 1-3 米
+	}
+	st := measureImage(t, testOBB, Pose{}, img)
+
+	c, s := math.Cos(math.Pi/4), math.Sin(math.Pi/4)
+	half := [3]float64{1, 0.5, 0.1}
+	var sure, maybe int
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			d := float32(img.Pix[y*img.Width+x]) / unitsPerMeter
+			p := volumeIntrinsics.Deproject(float32(x), float32(y), d)
+			// 盒子局部坐标 = Ry(45°)^T (p - center)
+			px, py, pz := float64(p[0]), float64(p[1]), float64(p[2])-2
+			local := [3]float64{c*px - s*pz, py, s*px + c*pz}
+			in, edge := true, false
+			for k := range local {
+				m := math.Abs(local[k]) - half[k]
+				if m > 1e-4 {
+					in = false
+				} else if m > -1e-4 {
+					edge = true
+				}
+			}
+			if in && !edge {
+				sure++
+			} else if in {
+				maybe++
+			}
+		}
+	}
+	if sure == 0 {
+		t.Fatal("no points inside the box, test data is wrong")
+	}
+	if st.Points < sure || st.Points > sure+maybe {
+		t.Errorf("volume has %d points, deprojection gives %d (+%d on the boundary)", st.Points, sure, maybe)
+	}
+}
+
+func TestSlab(t *testing.T) {
+	half := [3]float64{1, 1, 1}
+	tests := []struct {
+		name        string
+		origin, dir [3]float64
+		enter, exit float64
+		ok          bool
+	}{
+		{"through center", [3]float64{0, 0, -3}, [3]float64{0, 0, 1}, 2, 4, true},
+		{"origin inside", [3]float64{0, 0, 0}, [3]float64{0, 0, 1}, 0, 1, true},
+		{"pointing away", [3]float64{0, 0, 3}, [3]float64{0, 0, 1}, 0, 0, false},
+		{"parallel outside", [3]float64{2, 0, -3}, [3]float64{0, 0, 1}, 0, 0, false},
+		{"parallel on face", [3]float64{1, 0, -3}, [3]float64{0, 0, 1}, 2, 4, true},
+		{"diagonal", [3]float64{-3, -3, 0}, [3]float64{1, 1, 0}, 2, 4, true},
+		// y 方向区间 [1, 3]，z 方向区间 [3, 5]，只在 t=3 处擦过棱 (0, -1, -1)
+		{"touches edge", [3]float64{0, 2, -4}, [3]float64{0, -1, 1}, 3, 3, true},
+		{"misses edge", [3]float64{0, 1.9, -4}, [3]float64{0, -1, 1}, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enter, exit, ok := slab(tt.origin, tt.dir, half)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (math.Abs(enter-tt.enter) > 1e-12 || math.Abs(exit-tt.exit) > 1e-12) {
+				t.Errorf("[%g, %g], want [%g, %g]", enter, exit, tt.enter, tt.exit)
+			}
+		})
+	}
+}
@@ -5,18 +5,20 @@ package rs
 #include <stdlib.h>
 */
 import "C"
-
-// 必须引入此包
+import "fmt"
 
 // Align 结构体封装了对齐处理器
 type Align struct {
-	ptr   *C.rs2_processing_block
-	queue *C.rs2_frame_queue // 用于接收处理后的帧
+	processingBlock
+	target StreamType // 对齐的基准流
 }
 
 // NewAlign 创建一个新的对齐处理器
-// alignTo 参数指定对齐的基准流，通常是 StreamColor (彩色图)
-func NewAlign(alignTo StreamType) (*Align, error) {
+// alignTo 参数指定对齐的基准流，其它流会被重投影到该流的视角
+// StreamColor 表示深度对齐到彩色（最常用，用于 ROI），StreamDepth 表示彩色对齐到深度，
+// StreamInfra 表示对齐到红外视角
+// opts 可配置队列容量、超时时间和耗时预算
+func NewAlign(alignTo StreamType, opts ...ProcessingOption) (*Align, error) {
 	var err *C.rs2_error
 	// 创建对齐处理块
 	ptr := C.rs2_create_align(C.rs2_stream(alignTo), &err)
@@ -24,73 +26,91 @@ func NewAlign(alignTo StreamType) (*Align, error) {
 		return nil, errorFromC(err)
 	}
 
-	// 创建帧队列用于接收处理结果
-	queue := C.rs2_create_frame_queue(1, &err)
-	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		return nil, errorFromC(err)
-	}
-
-	// 将处理块的输出定向到队列
-	C.rs2_start_processing_queue(ptr, queue, &err)
-	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		C.rs2_delete_frame_queue(queue)
-		return nil, errorFromC(err)
+	block, goErr := newProcessingBlock(ptr, "align", opts)
+	if goErr != nil {
+		return nil, goErr
 	}
-
-	return &Align{ptr: ptr, queue: queue}, nil
+	return &Align{processingBlock: block, target: alignTo}, nil
 }
 
 // Process 处理并对齐帧集
+// 输入帧集仍需调用者释放，返回的帧集需要手动 Close
 func (a *Align) Process(frames *FrameSet) (*FrameSet, error) {
-	var err *C.rs2_error
-
-	// 增加引用计数，因为 rs2_process_frame 会接管所有权
-	// 如果不增加引用计数，frames.ptr 在 C 层被释放后，Go 层的 Frame.Close() 会导致 double-free
-	C.rs2_frame_add_ref(frames.ptr, &err)
+	result, err := a.process(frames.ptr)
 	if err != nil {
-		return nil, errorFromC(err)
+		return nil, err
 	}
 
-	// 执行对齐处理
-	// rs2_process_frame 返回 void，结果会放入绑定的队列中
-	C.rs2_process_frame(a.ptr, frames.ptr, &err)
-	if err != nil {
-		return nil, errorFromC(err)
-	}
+	// 此时 result 是一个新的 frame 引用（通常是一个 frameset）
+	return &FrameSet{ptr: result}, nil
+}
 
-	// 从队列中获取结果帧
-	result := C.rs2_wait_for_frame(a.queue, 5000, &err) // 5秒超时
-	if err != nil {
-		return nil, errorFromC(err)
+// TryProcess 非阻塞版本的 Process，结果尚未就绪时返回 nil, nil
+func (a *Align) TryProcess(frames *FrameSet) (*FrameSet, error) {
+	result, err := a.tryProcess(frames.ptr)
+	if err != nil || result == nil {
+		return nil, err
 	}
-
-	// 此时 result 是一个新的 frame 引用（通常是一个 frameset）
 	return &FrameSet{ptr: result}, nil
 }
 
 // Close 释放对齐处理器的内存
 func (a *Align) Close() {
-	if a.ptr != nil {
-		C.rs2_delete_processing_block(a.ptr)
-		a.ptr = nil
-	}
-	if a.queue != nil {
-		C.rs2_delete_frame_queue(a.queue)
-		a.queue = nil
+	a.close()
+}
+
+// AlignedFrameSet 是对齐后的帧集，记录了对齐的基准流
+// 所有非基准流的帧都已重投影到基准流的视角和分辨率
+type AlignedFrameSet struct {
+	*FrameSet
+	target StreamType
+}
+
+// Target 返回对齐的基准流
+func (a *AlignedFrameSet) Target() StreamType {
+	return a.target
+}
+
+// Reference 返回基准流的帧（未被重投影的那一帧）
+// 返回的 Frame 需要手动 Close
+func (a *AlignedFrameSet) Reference() (*Frame, error) {
+	return a.GetFrame(a.target)
+}
+
+// Aligned 返回已对齐到基准流的指定流的帧
+// 返回的 Frame 需要手动 Close
+func (a *AlignedFrameSet) Aligned(stream StreamType) (*Frame, error) {
+	if stream == a.target {
+		return nil, fmt.Errorf("stream %v is the alignment target, use Reference", stream)
 	}
+	return a.GetFrame(stream)
 }
 
-// GetSceneFrame 这是一个辅助函数，帮助从对齐后的帧集中提取特定流
-func (f *FrameSet) GetSceneFrame(stream C.rs2_stream) (*Frame, error) {
-	var err *C.rs2_error
+// Target 返回对齐的基准流
+func (a *Align) Target() StreamType {
+	return a.target
+}
 
-	// 从对齐后的帧集中提取单个流的帧
-	ptr := C.rs2_extract_frame(f.ptr, 0, &err) // 索引通常为0，或根据流类型查找
+// ProcessAligned 对齐帧集并返回带基准流信息的 AlignedFrameSet
+// 输入帧集缺少基准流时返回错误（此时 librealsense 不会做任何对齐）
+// 返回的帧集需要手动 Close
+func (a *Align) ProcessAligned(frames *FrameSet) (*AlignedFrameSet, error) {
+	ref, err := frames.GetFrame(a.target)
 	if err != nil {
-		return nil, errorFromC(err)
+		return nil, fmt.Errorf("align: input has no %v frame: %w", a.target, err)
 	}
+	ref.Close()
+
+	result, err := a.Process(frames)
+	if err != nil {
+		return nil, err
+	}
+	return &AlignedFrameSet{FrameSet: result, target: a.target}, nil
+}
 
-	return &Frame{ptr: ptr}, nil
+// GetSceneFrame 从帧集中提取指定流的帧
+//
+// Deprecated: 早期版本忽略 stream 参数，总是返回第 0 帧；现在等同于 GetFrame，请直接使用 GetFrame
+func (f *FrameSet) GetSceneFrame(stream StreamType) (*Frame, error) {
+	return f.GetFrame(stream)
 }
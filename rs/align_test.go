@@ -0,0 +1,298 @@
+//go:build librealsense
+
+// 需要链接真实的 librealsense，运行方式：go test -tags librealsense ./rs
+// 使用 SoftwareDevice 注入合成帧，不需要连接相机
+
+package rs
+
+import (
+	"testing"
+)
+
+const (
+	testDepthW, testDepthH = 320, 240
+	testColorW, testColorH = 424, 240
+)
+
+// softwareRig 是一个带深度和彩色传感器的软件设备，帧经 Syncer 组合为 FrameSet
+type softwareRig struct {
+	dev          *SoftwareDevice
+	depthSensor  *SoftwareSensor
+	colorSensor  *SoftwareSensor
+	depthProfile *SensorProfile
+	colorProfile *SensorProfile
+	syncer       *Syncer
+}
+
+func testIntrinsics(w, h int) Intrinsics {
+	return Intrinsics{Width: w, Height: h, PPX: float32(w) / 2, PPY: float32(h) / 2, FX: 300, FY: 300}
+}
+
+// newSoftwareRig 创建软件设备，ex 为深度流到彩色流的外参
+func newSoftwareRig(t *testing.T, ex Extrinsics) *softwareRig {
+	t.Helper()
+	dev, err := NewSoftwareDevice()
+	if err != nil {
+		t.Fatalf("NewSoftwareDevice: %v", err)
+	}
+	r := &softwareRig{dev: dev}
+	t.Cleanup(r.close)
+
+	if r.depthSensor, err = dev.AddSensor("Depth"); err != nil {
+		t.Fatal(err)
+	}
+	if r.colorSensor, err = dev.AddSensor("Color"); err != nil {
+		t.Fatal(err)
+	}
+	r.depthProfile, err = r.depthSensor.AddVideoStream(VideoStream{
+		Type: StreamDepth, UID: 1, Width: testDepthW, Height: testDepthH, FPS: 30, BPP: 2,
+		Format: FormatZ16, Intrinsics: testIntrinsics(testDepthW, testDepthH),
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.colorProfile, err = r.colorSensor.AddVideoStream(VideoStream{
+		Type: StreamColor, UID: 2, Width: testColorW, Height: testColorH, FPS: 30, BPP: 3,
+		Format: FormatRGB8, Intrinsics: testIntrinsics(testColorW, testColorH),
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.depthSensor.AddReadOnlyOption(OptionDepthUnits, 0.001); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterExtrinsics(r.depthProfile, r.colorProfile, ex); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.CreateMatcher(MatcherDefault); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.syncer, err = NewSyncer(WithQueueSize(2)); err != nil {
+		t.Fatal(err)
+	}
+	submit := func(f *Frame) {
+		if err := r.syncer.Submit(f); err != nil {
+			t.Errorf("syncer submit: %v", err)
+		}
+	}
+	for _, s := range []struct {
+		sensor  *SoftwareSensor
+		profile *SensorProfile
+	}{{r.depthSensor, r.depthProfile}, {r.colorSensor, r.colorProfile}} {
+		if err := s.sensor.Open(s.profile); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.sensor.Start(submit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func (r *softwareRig) close() {
+	if r.syncer != nil {
+		r.syncer.Close()
+	}
+	r.dev.Close()
+}
+
+// flatDepth 返回 1 米处的平面深度图
+func flatDepth() []uint16 {
+	data := make([]uint16, testDepthW*testDepthH)
+	for i := range data {
+		data[i] = 1000
+	}
+	return data
+}
+
+// frameSet 推送同一时间戳的深度和彩色帧，直到 Syncer 输出同时包含两者的帧集
+func (r *softwareRig) frameSet(t *testing.T, depthData []uint16) *FrameSet {
+	t.Helper()
+	color := make([]byte, testColorW*testColorH*3)
+	for i := range color {
+		color[i] = byte(i)
+	}
+
+	for n := 1; n <= 10; n++ {
+		ts := float64(n) * 33.3
+		if err := r.depthSensor.PushDepthFrame(r.depthProfile, depthData, ts, n, 0.001); err != nil {
+			t.Fatal(err)
+		}
+		err := r.colorSensor.PushVideoFrame(r.colorProfile, SoftwareVideoFrame{
+			Pixels: color, Timestamp: ts, Domain: TimestampDomainHardwareClock, FrameNumber: n,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fs, err := r.syncer.WaitForFrames(1000)
+		if err != nil {
+			t.Fatalf("WaitForFrames: %v", err)
+		}
+		d, derr := fs.GetFrame(StreamDepth)
+		c, cerr := fs.GetFrame(StreamColor)
+		if derr == nil {
+			d.Close()
+		}
+		if cerr == nil {
+			c.Close()
+		}
+		if derr == nil && cerr == nil {
+			return fs
+		}
+		fs.Close()
+	}
+	t.Fatal("syncer never produced a depth+color frameset")
+	return nil
+}
+
+// checkFrame 断言帧的流类型、格式和分辨率
+func checkFrame(t *testing.T, f *Frame, stream StreamType, format Format, w, h int) {
+	t.Helper()
+	defer f.Close()
+	st, err := f.GetStreamType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ft, err := f.GetFormat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != stream || ft != format {
+		t.Errorf("frame is %v/%v, want %v/%v", st, ft, stream, format)
+	}
+	if f.GetWidth() != w || f.GetHeight() != h {
+		t.Errorf("%v frame is %dx%d, want %dx%d", stream, f.GetWidth(), f.GetHeight(), w, h)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		name          string
+		target        StreamType
+		aligned       StreamType
+		alignedFormat Format
+		refFormat     Format
+		w, h          int // 基准流分辨率，对齐后的帧应与之相同
+	}{
+		{"depth to color", StreamColor, StreamDepth, FormatZ16, FormatRGB8, testColorW, testColorH},
+		{"color to depth", StreamDepth, StreamColor, FormatRGB8, FormatZ16, testDepthW, testDepthH},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rig := newSoftwareRig(t, IdentityExtrinsics())
+			fs := rig.frameSet(t, flatDepth())
+			defer fs.Close()
+
+			align, err := NewAlign(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer align.Close()
+			if align.Target() != tt.target {
+				t.Errorf("Align.Target = %v, want %v", align.Target(), tt.target)
+			}
+
+			out, err := align.ProcessAligned(fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer out.Close()
+			if out.Target() != tt.target {
+				t.Errorf("AlignedFrameSet.Target = %v, want %v", out.Target(), tt.target)
+			}
+
+			ref, err := out.Reference()
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFrame(t, ref, tt.target, tt.refFormat, tt.w, tt.h)
+
+			aligned, err := out.Aligned(tt.aligned)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFrame(t, aligned, tt.aligned, tt.alignedFormat, tt.w, tt.h)
+
+			if _, err := out.Aligned(tt.target); err == nil {
+				t.Error("Aligned(target) should fail")
+			}
+		})
+	}
+}
+
+func TestAlignMissingTarget(t *testing.T) {
+	rig := newSoftwareRig(t, IdentityExtrinsics())
+	fs := rig.frameSet(t, flatDepth())
+	defer fs.Close()
+
+	align, err := NewAlign(StreamInfra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer align.Close()
+	if _, err := align.ProcessAligned(fs); err == nil {
+		t.Error("ProcessAligned without infrared frame should fail")
+	}
+}
+
+func TestAlignExtrinsics(t *testing.T) {
+	// 深度图只在 1 米处有一个 40x40 的方块，其余为 0（无效）
+	const patchX, patchY, patchSize = 100, 100, 40
+	data := make([]uint16, testDepthW*testDepthH)
+	for y := patchY; y < patchY+patchSize; y++ {
+		for x := patchX; x < patchX+patchSize; x++ {
+			data[y*testDepthW+x] = 1000
+		}
+	}
+
+	// 彩色相机相对深度相机沿 x 轴平移 10 厘米：1 米处 fx=300 对应 30 像素的视差
+	ex := IdentityExtrinsics()
+	ex.Translation = [3]float32{0.1, 0, 0}
+	rig := newSoftwareRig(t, ex)
+	fs := rig.frameSet(t, data)
+	defer fs.Close()
+
+	align, err := NewAlign(StreamColor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer align.Close()
+	out, err := align.ProcessAligned(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	aligned, err := out.Aligned(StreamDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer aligned.Close()
+	got := aligned.GetDepthData()
+	if len(got) != testColorW*testColorH {
+		t.Fatalf("aligned depth has %d pixels, want %d", len(got), testColorW*testColorH)
+	}
+
+	// 深度像素 u 投影到彩色像素 u - 深度 ppx + 彩色 ppx + 30，y 方向主点相同不偏移
+	const shift = testColorW/2 - testDepthW/2 + 30
+	at := func(x, y int) uint16 { return got[y*testColorW+x] }
+	tests := []struct {
+		name string
+		x, y int
+		want uint16
+	}{
+		{"patch center", patchX + patchSize/2 + shift, patchY + patchSize/2, 1000},
+		{"patch top-left", patchX + 2 + shift, patchY + 2, 1000},
+		{"patch bottom-right", patchX + patchSize - 3 + shift, patchY + patchSize - 3, 1000},
+		// 单位外参时方块会落在这里，平移后应为空
+		{"without translation", patchX + 10 + shift - 30, patchY + patchSize/2, 0},
+		{"right of patch", patchX + patchSize + 10 + shift, patchY + patchSize/2, 0},
+		{"below patch", patchX + patchSize/2 + shift, patchY + patchSize + 10, 0},
+	}
+	for _, tt := range tests {
+		if v := at(tt.x, tt.y); v != tt.want {
+			t.Errorf("%s: aligned depth at (%d,%d) = %d, want %d", tt.name, tt.x, tt.y, v, tt.want)
+		}
+	}
+}
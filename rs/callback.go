@@ -0,0 +1,52 @@
+package rs
+
+/*
+#include <stdint.h>
+
+// rs_callback_ptr 将回调注册表中的 id 转换为 C 端的 user data 指针
+// 不能直接把 Go 指针交给 C 保存，这里只传递一个整数 id
+static inline void* rs_callback_ptr(uintptr_t id) { return (void*)id; }
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// callbackRegistry 保存所有注册给 C 端的 Go 回调对象
+// librealsense 在自己的线程中调用回调，通过 id 查找对应的 Go 对象
+var callbackRegistry = struct {
+	sync.Mutex
+	next  uintptr
+	items map[uintptr]interface{}
+}{items: make(map[uintptr]interface{})}
+
+// registerCallback 注册一个回调对象并返回其 id（从 1 开始，0 表示无效）
+func registerCallback(v interface{}) uintptr {
+	callbackRegistry.Lock()
+	defer callbackRegistry.Unlock()
+
+	callbackRegistry.next++
+	id := callbackRegistry.next
+	callbackRegistry.items[id] = v
+	return id
+}
+
+// lookupCallback 根据 id 查找回调对象，已注销时返回 nil
+func lookupCallback(id uintptr) interface{} {
+	callbackRegistry.Lock()
+	defer callbackRegistry.Unlock()
+	return callbackRegistry.items[id]
+}
+
+// unregisterCallback 注销回调对象，之后 C 端的调用将被忽略
+func unregisterCallback(id uintptr) {
+	callbackRegistry.Lock()
+	defer callbackRegistry.Unlock()
+	delete(callbackRegistry.items, id)
+}
+
+// callbackUserData 将回调 id 转换为传给 C 函数的 user data 参数
+func callbackUserData(id uintptr) unsafe.Pointer {
+	return C.rs_callback_ptr(C.uintptr_t(id))
+}
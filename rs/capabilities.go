@@ -10,6 +10,7 @@ import "C"
 // StreamProfile 描述了一个具体的流配置能力
 type StreamProfile struct {
 	Stream    string `json:"stream"`     // 流类型 (Color, Depth, Infrared)
+	Index     int    `json:"index"`      // 流索引，红外左右分别为 1 和 2
 	Format    string `json:"format"`     // 像素格式 (Z16, RGB8, Y8)
 	Width     int    `json:"width"`      // 宽度
 	Height    int    `json:"height"`     // 高度
@@ -88,6 +89,7 @@ func (d *Device) GetCapabilities() ([]StreamProfile, error) {
 
 			p := StreamProfile{
 				Stream:    C.GoString(C.rs2_stream_to_string(streamType)),
+				Index:     int(index),
 				Format:    C.GoString(C.rs2_format_to_string(format)),
 				Width:     int(width),
 				Height:    int(height),
@@ -0,0 +1,400 @@
+package rs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Stage 是处理链中的一个处理阶段
+// Process 不得释放输入帧；返回的帧由处理链负责释放
+// Filter 和 Colorizer 已实现该接口，Align 可通过 AlignStage 适配
+type Stage interface {
+	Process(frame *Frame) (*Frame, error)
+}
+
+// StageFunc 允许使用普通 Go 函数作为处理阶段
+// 函数可以直接返回输入帧（透传），处理链不会重复释放
+type StageFunc func(frame *Frame) (*Frame, error)
+
+// Process 实现 Stage 接口
+func (fn StageFunc) Process(frame *Frame) (*Frame, error) {
+	return fn(frame)
+}
+
+// alignStage 将 Align 适配为 Stage
+// 帧集本身也是一个 rs2_frame，因此可以在链中以 Frame 的形式传递
+type alignStage struct {
+	align *Align
+}
+
+// AlignStage 将 Align 包装为处理链阶段
+// 输入应为帧集（例如 Pipeline 输出的帧集），输出为对齐后的帧集
+func AlignStage(a *Align) Stage {
+	return &alignStage{align: a}
+}
+
+// Process 实现 Stage 接口
+func (s *alignStage) Process(frame *Frame) (*Frame, error) {
+	fs, err := s.align.Process(&FrameSet{ptr: frame.ptr})
+	if err != nil {
+		return nil, err
+	}
+	return &Frame{ptr: fs.ptr}, nil
+}
+
+// Close 释放内部的 Align
+func (s *alignStage) Close() {
+	s.align.Close()
+}
+
+// chainStage 是处理链中的一个命名阶段
+type chainStage struct {
+	name    string
+	stage   Stage
+	enabled bool
+	owned   bool // 是否由处理链负责 Close
+}
+
+// Chain 按顺序组合多个处理阶段
+// 负责释放中间帧、传播错误，并支持在运行时启用/禁用阶段
+// Chain 的所有方法都可以在多个 goroutine 中调用；Process 执行期间持有写锁，
+// 处理块的输出队列不会被并发调用者交错读取，并发的 Process 会依次执行。
+// SetEnabled、Add 和 Close 同样会等待正在进行的 Process 结束，因此阶段内部不能再调用这些方法
+type Chain struct {
+	mu     sync.RWMutex
+	stages []chainStage
+}
+
+// NewChain 创建一个空的处理链
+func NewChain() *Chain {
+	return &Chain{}
+}
+
+// Add 在链尾追加一个处理阶段，name 必须唯一
+// 通过 Add 加入的阶段不归处理链所有，调用者负责 Close
+func (c *Chain) Add(name string, stage Stage) error {
+	return c.add(name, stage, true, false)
+}
+
+// add 内部追加实现
+func (c *Chain) add(name string, stage Stage, enabled, owned bool) error {
+	if stage == nil {
+		return fmt.Errorf("chain stage %q is nil", name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, st := range c.stages {
+		if st.name == name {
+			return fmt.Errorf("duplicate chain stage %q", name)
+		}
+	}
+	c.stages = append(c.stages, chainStage{name: name, stage: stage, enabled: enabled, owned: owned})
+	return nil
+}
+
+// SetEnabled 在运行时启用或禁用某个阶段，禁用的阶段会被直接跳过
+func (c *Chain) SetEnabled(name string, enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.stages {
+		if c.stages[i].name == name {
+			c.stages[i].enabled = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("chain stage %q not found", name)
+}
+
+// Enabled 返回阶段是否启用，阶段不存在时返回 false
+func (c *Chain) Enabled(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, st := range c.stages {
+		if st.name == name {
+			return st.enabled
+		}
+	}
+	return false
+}
+
+// Stages 按顺序返回所有阶段的名称
+func (c *Chain) Stages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, len(c.stages))
+	for i, st := range c.stages {
+		names[i] = st.name
+	}
+	return names
+}
+
+// Process 依次执行所有启用的阶段
+// 输入帧仍由调用者释放，返回的帧需要手动 Close
+// 任意阶段出错时会释放已产生的中间帧，并返回带阶段名称的错误
+func (c *Chain) Process(frame *Frame) (*Frame, error) {
+	// 整个处理期间持有写锁：处理块按“提交后从队列取结果”工作，
+	// 两个调用者同时使用同一个处理块会取走对方的结果；同时也避免 Close 释放仍在使用的处理块
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := frame
+	for _, st := range c.stages {
+		if !st.enabled {
+			continue
+		}
+
+		out, err := st.stage.Process(current)
+		if err == nil && out == nil {
+			err = fmt.Errorf("stage returned no frame")
+		}
+		if err != nil {
+			if current != frame {
+				current.Close()
+			}
+			return nil, fmt.Errorf("chain stage %q: %w", st.name, err)
+		}
+
+		// 释放上一阶段的中间结果（透传时 out 与 current 相同，不能释放）
+		if current != frame && out != current {
+			current.Close()
+		}
+		current = out
+	}
+
+	// 没有任何阶段产生新帧时，返回输入帧的新引用，保证返回值总是需要调用者 Close
+	if current == frame {
+		return frame.Clone()
+	}
+	return current, nil
+}
+
+// ProcessFrameSet 以帧集作为输入执行处理链，返回的帧集需要手动 Close
+func (c *Chain) ProcessFrameSet(frames *FrameSet) (*FrameSet, error) {
+	out, err := c.Process(&Frame{ptr: frames.ptr})
+	if err != nil {
+		return nil, err
+	}
+	return &FrameSet{ptr: out.ptr}, nil
+}
+
+// Close 释放由处理链创建的阶段（例如 NewChainFromConfig 创建的过滤器）
+// 会等待正在进行的 Process 结束
+func (c *Chain) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, st := range c.stages {
+		if !st.owned {
+			continue
+		}
+		if closer, ok := st.stage.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+	c.stages = nil
+}
+
+// StageConfig 是处理链中单个阶段的声明式配置
+// Options 按参数名的字典序依次设置，结果与 JSON 中的书写顺序无关
+type StageConfig struct {
+	Type    string             `json:"type"`               // 处理块类型，见 newConfiguredStage
+	Name    string             `json:"name,omitempty"`     // 阶段名称，默认与 Type 相同
+	Enabled *bool              `json:"enabled,omitempty"`  // 初始是否启用，默认启用
+	AlignTo string             `json:"align_to,omitempty"` // 仅 align 使用：color, depth, infrared
+	Options map[string]float32 `json:"options,omitempty"`  // 处理块参数，如 {"magnitude": 2}，可用名称见 stageOptionNames
+}
+
+// ChainConfig 是处理链的声明式配置
+// 只支持 JSON（见 ParseChainConfig），不提供 YAML 解析，避免为此引入额外依赖
+type ChainConfig struct {
+	Stages []StageConfig `json:"stages"`
+}
+
+// ParseChainConfig 从 JSON 读取处理链配置
+func ParseChainConfig(r io.Reader) (ChainConfig, error) {
+	var cfg ChainConfig
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return ChainConfig{}, fmt.Errorf("parse chain config: %w", err)
+	}
+	return cfg, nil
+}
+
+// stageOptionNames 每种处理块在声明式配置中可用的参数名称
+// 不同处理块共用同一个选项编号（如 holes_fill 在时间过滤器中表示持续性模式），
+// 因此按类型列出，其他类型使用这些名称会报错
+var stageOptionNames = map[string]map[string]int{
+	"decimation": {"magnitude": OptionFilterMagnitude},
+	"spatial": {
+		"magnitude":    OptionFilterMagnitude,
+		"smooth_alpha": OptionFilterSmoothAlpha,
+		"smooth_delta": OptionFilterSmoothDelta,
+		"holes_fill":   OptionHolesFill,
+	},
+	"temporal": {
+		"smooth_alpha": OptionFilterSmoothAlpha,
+		"smooth_delta": OptionFilterSmoothDelta,
+		"persistency":  OptionHolesFill,
+	},
+	"hole_filling": {"holes_fill": OptionHolesFill},
+	"threshold":    {"min_distance": OptionMinDistance, "max_distance": OptionMaxDistance},
+	"colorizer":    {"min_distance": OptionMinDistance, "max_distance": OptionMaxDistance},
+	"sequence_id":  {"sequence_id": OptionSequenceID},
+}
+
+// stageSettings 是声明式配置中一个阶段的参数，作为类型化参数在创建处理块时应用
+type stageSettings struct {
+	kind   string
+	values []optionValue
+}
+
+// newStageSettings 按处理块类型校验参数名称，并按参数名排序，保证每次运行的设置顺序一致
+func newStageSettings(kind string, options map[string]float32) (stageSettings, error) {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	settings := stageSettings{kind: kind}
+	for _, key := range keys {
+		option, ok := stageOptionNames[kind][key]
+		if !ok {
+			return stageSettings{}, fmt.Errorf("unknown option %q for %s", key, kind)
+		}
+		settings.values = append(settings.values, optionValue{option, options[key]})
+	}
+	return settings, nil
+}
+
+func (o stageSettings) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, o.kind); err != nil {
+		return err
+	}
+
+	// 只设置了 min_distance 或 max_distance 之一时，与处理块的当前值比较
+	minDistance, maxDistance, ranged := float32(0), float32(0), 0
+	for _, v := range o.values {
+		switch v.option {
+		case OptionMinDistance:
+			minDistance, ranged = v.value, ranged|1
+		case OptionMaxDistance:
+			maxDistance, ranged = v.value, ranged|2
+		}
+	}
+	var err error
+	switch ranged {
+	case 1:
+		maxDistance, err = b.getOption(OptionMaxDistance)
+	case 2:
+		minDistance, err = b.getOption(OptionMinDistance)
+	}
+	if err != nil {
+		return err
+	}
+	if ranged != 0 && minDistance > maxDistance {
+		return fmt.Errorf("%s: min distance %g > max distance %g", b.name, minDistance, maxDistance)
+	}
+
+	return applyOptionValues(b, o.values)
+}
+
+// streamNames 声明式配置中可用的流名称
+var streamNames = map[string]StreamType{
+	"color":    StreamColor,
+	"depth":    StreamDepth,
+	"infrared": StreamInfra,
+}
+
+// NewChainFromConfig 根据声明式配置创建处理链
+// 创建的处理块归处理链所有，调用 Chain.Close 时统一释放
+// opts 会应用到每一个处理块；各阶段的 Options 按类型校验名称，
+// 所有取值（包括 min_distance 不大于 max_distance）校验通过后才写入，任何一项非法都视为配置错误
+func NewChainFromConfig(cfg ChainConfig, opts ...ProcessingOption) (*Chain, error) {
+	chain := NewChain()
+
+	for i, sc := range cfg.Stages {
+		stage, err := newConfiguredStage(sc, opts)
+		if err != nil {
+			chain.Close()
+			return nil, fmt.Errorf("chain stage #%d (%s): %w", i, sc.Type, err)
+		}
+
+		name := sc.Name
+		if name == "" {
+			name = sc.Type
+		}
+		enabled := sc.Enabled == nil || *sc.Enabled
+		if err := chain.add(name, stage, enabled, true); err != nil {
+			if closer, ok := stage.(interface{ Close() }); ok {
+				closer.Close()
+			}
+			chain.Close()
+			return nil, fmt.Errorf("chain stage #%d (%s): %w", i, sc.Type, err)
+		}
+	}
+
+	return chain, nil
+}
+
+// newConfiguredStage 按类型创建处理块
+// 支持 decimation, threshold, depth_to_disparity, spatial, temporal, disparity_to_depth,
+// hole_filling, hdr_merge, sequence_id, units_transform, colorizer, align
+// rotation 需要传感器的流配置，无法声明式创建，请使用 NewRotationFilter（需 rs2_56 构建标签）后通过 Chain.Add 加入
+func newConfiguredStage(sc StageConfig, opts []ProcessingOption) (Stage, error) {
+	if len(sc.Options) > 0 {
+		settings, err := newStageSettings(sc.Type, sc.Options)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts[:len(opts):len(opts)], WithFilterOptions(settings))
+	}
+
+	switch sc.Type {
+	case "decimation":
+		return NewDecimationFilter(opts...)
+	case "spatial":
+		return NewSpatialFilter(opts...)
+	case "temporal":
+		return NewTemporalFilter(opts...)
+	case "hole_filling":
+		return NewHoleFillingFilter(opts...)
+	case "threshold":
+		return NewThresholdFilter(opts...)
+	case "depth_to_disparity", "disparity_to_depth":
+		return NewDisparityTransform(sc.Type == "depth_to_disparity", opts...)
+	case "hdr_merge":
+		return NewHDRMergeFilter(opts...)
+	case "sequence_id":
+		return NewSequenceIDFilter(opts...)
+	case "units_transform":
+		return NewUnitsTransform(opts...)
+	case "colorizer":
+		return NewColorizer(opts...)
+	case "align":
+		to := StreamColor
+		if sc.AlignTo != "" {
+			stream, ok := streamNames[sc.AlignTo]
+			if !ok {
+				return nil, fmt.Errorf("unknown align target %q", sc.AlignTo)
+			}
+			to = stream
+		}
+		a, err := NewAlign(to, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return AlignStage(a), nil
+	default:
+		return nil, fmt.Errorf("unknown stage type %q", sc.Type)
+	}
+}
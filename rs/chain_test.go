@@ -0,0 +1,78 @@
+//go:build librealsense
+
+// 需要链接真实的 librealsense，运行方式：go test -tags librealsense ./rs
+
+package rs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewChainFromConfigOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string // 空表示有效
+	}{
+		{"valid", `{"stages": [{"type": "decimation", "options": {"magnitude": 3}},
+			{"type": "spatial", "options": {"holes_fill": 2, "smooth_alpha": 0.6}},
+			{"type": "temporal", "options": {"persistency": 4}},
+			{"type": "threshold", "options": {"min_distance": 0.3, "max_distance": 2}}]}`, ""},
+		{"persistency on spatial", `{"stages": [{"type": "spatial", "options": {"persistency": 3}}]}`,
+			`unknown option "persistency" for spatial`},
+		{"holes_fill on temporal", `{"stages": [{"type": "temporal", "options": {"holes_fill": 3}}]}`,
+			`unknown option "holes_fill" for temporal`},
+		{"option on align", `{"stages": [{"type": "align", "options": {"magnitude": 2}}]}`, "unknown option"},
+		{"out of range", `{"stages": [{"type": "decimation", "options": {"magnitude": 100}}]}`, "out of range"},
+		{"inverted threshold", `{"stages": [{"type": "threshold", "options": {"min_distance": 3, "max_distance": 1}}]}`,
+			"min distance 3 > max distance 1"},
+		// 默认最大距离为 4 米
+		{"min above default max", `{"stages": [{"type": "threshold", "options": {"min_distance": 5}}]}`, "min distance"},
+		{"unknown field", `{"stages": [{"type": "spatial", "option": {}}]}`, "unknown field"},
+		{"duplicate name", `{"stages": [{"type": "spatial"}, {"type": "spatial"}]}`, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseChainConfig(strings.NewReader(tt.json))
+			if err == nil {
+				var chain *Chain
+				chain, err = NewChainFromConfig(cfg)
+				if err == nil {
+					chain.Close()
+				}
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("no error, want %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChainConfigAppliesOptions(t *testing.T) {
+	cfg, err := ParseChainConfig(strings.NewReader(`{"stages": [
+		{"type": "threshold", "options": {"max_distance": 2}},
+		{"type": "temporal", "options": {"persistency": 8, "smooth_alpha": 0.2}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := NewChainFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	threshold := chain.stages[0].stage.(*ThresholdFilter)
+	if o, err := threshold.Options(); err != nil || o.MinDistance != 0.1 || o.MaxDistance != 2 {
+		t.Errorf("threshold options %+v, %v", o, err)
+	}
+	temporal := chain.stages[1].stage.(*Filter)
+	if o, err := temporal.TemporalOptions(); err != nil || o.PersistencyMode != PersistencyIndefinitely || o.Alpha != 0.2 {
+		t.Errorf("temporal options %+v, %v", o, err)
+	}
+}
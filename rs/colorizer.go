@@ -5,74 +5,146 @@ package rs
 #include <stdlib.h>
 */
 import "C"
+import "fmt"
 
 // Colorizer 封装了伪彩色处理器
 // 用于将深度图（Z16）转换为可视化友好的彩虹图（RGB8）
 type Colorizer struct {
-	ptr   *C.rs2_processing_block
-	queue *C.rs2_frame_queue
+	processingBlock
 }
 
 // NewColorizer 创建一个新的 Colorizer
-func NewColorizer() (*Colorizer, error) {
+// opts 可配置队列容量、超时时间和耗时预算，
+// 也可以通过 WithFilterOptions(ColorizerOptions{...}) 设置配色方案和映射范围
+func NewColorizer(opts ...ProcessingOption) (*Colorizer, error) {
 	var err *C.rs2_error
 	ptr := C.rs2_create_colorizer(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
 
-	// 创建帧队列用于接收处理结果
-	queue := C.rs2_create_frame_queue(1, &err)
-	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		return nil, errorFromC(err)
-	}
-
-	// 启动处理块，将结果输出到队列
-	C.rs2_start_processing_queue(ptr, queue, &err)
-	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		C.rs2_delete_frame_queue(queue)
-		return nil, errorFromC(err)
+	block, goErr := newProcessingBlock(ptr, "colorizer", opts)
+	if goErr != nil {
+		return nil, goErr
 	}
-
-	return &Colorizer{ptr: ptr, queue: queue}, nil
+	return &Colorizer{processingBlock: block}, nil
 }
 
 // Process 处理帧，将深度帧转换为彩色帧
 // 注意：返回的 Frame 需要手动 Close
 func (c *Colorizer) Process(frame *Frame) (*Frame, error) {
-	var err *C.rs2_error
-
-	// 增加引用计数，因为 rs2_process_frame 会消耗一个引用
-	// 否则原 frame 在 Go 端 Close 时会导致 double free，或者在这里被消耗掉
-	C.rs2_frame_add_ref(frame.ptr, &err)
+	result, err := c.process(frame.ptr)
 	if err != nil {
-		return nil, errorFromC(err)
-	}
-
-	C.rs2_process_frame(c.ptr, frame.ptr, &err)
-	if err != nil {
-		return nil, errorFromC(err)
+		return nil, err
 	}
+	return &Frame{ptr: result}, nil
+}
 
-	// 获取结果
-	result := C.rs2_wait_for_frame(c.queue, 5000, &err)
-	if err != nil {
-		return nil, errorFromC(err)
+// TryProcess 非阻塞版本的 Process，结果尚未就绪时返回 nil, nil
+func (c *Colorizer) TryProcess(frame *Frame) (*Frame, error) {
+	result, err := c.tryProcess(frame.ptr)
+	if err != nil || result == nil {
+		return nil, err
 	}
-
 	return &Frame{ptr: result}, nil
 }
 
 // Close 释放资源
 func (c *Colorizer) Close() {
-	if c.ptr != nil {
-		C.rs2_delete_processing_block(c.ptr)
-		c.ptr = nil
+	c.close()
+}
+
+// ColorScheme 伪彩色配色方案，与 depth.ColorScheme 取值一致
+type ColorScheme int
+
+const (
+	ColorSchemeJet          ColorScheme = 0
+	ColorSchemeClassic      ColorScheme = 1
+	ColorSchemeWhiteToBlack ColorScheme = 2
+	ColorSchemeBlackToWhite ColorScheme = 3
+	ColorSchemeBio          ColorScheme = 4
+	ColorSchemeCold         ColorScheme = 5
+	ColorSchemeWarm         ColorScheme = 6
+	ColorSchemeQuantized    ColorScheme = 7
+	ColorSchemePattern      ColorScheme = 8
+	ColorSchemeHue          ColorScheme = 9
+)
+
+// ColorizerOptions 伪彩色参数
+// 关闭直方图均衡时，按 [MinDistance, MaxDistance]（米）的固定范围映射颜色
+type ColorizerOptions struct {
+	Scheme                ColorScheme `json:"scheme"`
+	HistogramEqualization bool        `json:"histogram_equalization"`
+	MinDistance           float32     `json:"min_distance"`
+	MaxDistance           float32     `json:"max_distance"`
+}
+
+// DefaultColorizerOptions 返回 librealsense 的默认参数
+func DefaultColorizerOptions() ColorizerOptions {
+	return ColorizerOptions{Scheme: ColorSchemeJet, HistogramEqualization: true, MinDistance: 0, MaxDistance: 6}
+}
+
+func (o ColorizerOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "colorizer"); err != nil {
+		return err
 	}
-	if c.queue != nil {
-		C.rs2_delete_frame_queue(c.queue)
-		c.queue = nil
+	if o.MinDistance > o.MaxDistance {
+		return fmt.Errorf("%s: min distance %g > max distance %g", b.name, o.MinDistance, o.MaxDistance)
+	}
+	var equalize float32
+	if o.HistogramEqualization {
+		equalize = 1
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionColorScheme, float32(o.Scheme)},
+		{OptionHistogramEqualization, equalize},
+		{OptionMinDistance, o.MinDistance},
+		{OptionMaxDistance, o.MaxDistance},
+	})
+}
+
+// SetColorScheme 设置配色方案
+func (c *Colorizer) SetColorScheme(scheme ColorScheme) error {
+	return c.setOption(OptionColorScheme, float32(scheme))
+}
+
+// SetHistogramEqualization 启用或关闭直方图均衡
+func (c *Colorizer) SetHistogramEqualization(enabled bool) error {
+	var value float32
+	if enabled {
+		value = 1
+	}
+	return c.setOption(OptionHistogramEqualization, value)
+}
+
+// SetRange 设置固定的颜色映射范围（米），同时关闭直方图均衡
+func (c *Colorizer) SetRange(minMeters, maxMeters float32) error {
+	if minMeters > maxMeters {
+		return fmt.Errorf("%s: min distance %g > max distance %g", c.name, minMeters, maxMeters)
+	}
+	return applyOptionValues(&c.processingBlock, []optionValue{
+		{OptionHistogramEqualization, 0},
+		{OptionMinDistance, minMeters},
+		{OptionMaxDistance, maxMeters},
+	})
+}
+
+// ApplyOptions 在运行时应用伪彩色参数
+func (c *Colorizer) ApplyOptions(opts ColorizerOptions) error {
+	return opts.applyTo(&c.processingBlock)
+}
+
+// Options 读取当前的伪彩色参数
+func (c *Colorizer) Options() (ColorizerOptions, error) {
+	v, err := readOptionValues(&c.processingBlock,
+		OptionColorScheme, OptionHistogramEqualization, OptionMinDistance, OptionMaxDistance)
+	if err != nil {
+		return ColorizerOptions{}, err
 	}
+	return ColorizerOptions{
+		Scheme:                ColorScheme(v[0]),
+		HistogramEqualization: v[1] != 0,
+		MinDistance:           v[2],
+		MaxDistance:           v[3],
+	}, nil
 }
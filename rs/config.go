@@ -6,16 +6,31 @@ package rs
 #include <stdlib.h>
 */
 import "C"
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
 
 // Format 定义数据格式
 type Format int
 
 const (
-	FormatAny  Format = C.RS2_FORMAT_ANY
-	FormatZ16  Format = C.RS2_FORMAT_Z16  // 深度图标准格式 [cite: 54]
-	FormatRGB8 Format = C.RS2_FORMAT_RGB8 // 彩色图标准格式 [cite: 54]
+	FormatAny   Format = C.RS2_FORMAT_ANY
+	FormatZ16   Format = C.RS2_FORMAT_Z16  // 深度图标准格式 [cite: 54]
+	FormatRGB8  Format = C.RS2_FORMAT_RGB8 // 彩色图标准格式 [cite: 54]
+	FormatBGR8  Format = C.RS2_FORMAT_BGR8
+	FormatRGBA8 Format = C.RS2_FORMAT_RGBA8
+	FormatBGRA8 Format = C.RS2_FORMAT_BGRA8
+	FormatY8    Format = C.RS2_FORMAT_Y8  // 红外 8 位
+	FormatY16   Format = C.RS2_FORMAT_Y16 // 红外 16 位
 )
 
+// String 返回格式名称，例如 "Z16"、"RGB8"
+func (f Format) String() string {
+	return C.GoString(C.rs2_format_to_string(C.rs2_format(f)))
+}
+
 // NewConfig 初始化配置容器[cite:29,30]
 func NewConfig() (*Config, error) {
 	var err *C.rs2_error
@@ -28,12 +43,18 @@ func NewConfig() (*Config, error) {
 
 // EnableStream 设置流的具体参数（分辨率、FPS、格式） [cite: 31, 32]
 func (c *Config) EnableStream(stype StreamType, w, h, fps int, format Format) error {
+	return c.EnableStreamIndex(stype, 0, w, h, fps, format)
+}
+
+// EnableStreamIndex 与 EnableStream 相同，但可以指定流索引
+// 例如左右红外分别为 (StreamInfra, 1) 和 (StreamInfra, 2)，0 表示任意索引
+func (c *Config) EnableStreamIndex(stype StreamType, index, w, h, fps int, format Format) error {
 	var err *C.rs2_error
 
 	C.rs2_config_enable_stream(
 		c.ptr,
 		C.rs2_stream(stype),
-		C.int(0), // 传感器索引，通常为 0
+		C.int(index),
 		C.int(w),
 		C.int(h),
 		C.rs2_format(format),
@@ -47,6 +68,70 @@ func (c *Config) EnableStream(stype StreamType, w, h, fps int, format Format) er
 	return nil
 }
 
+// DisableAllStreams 清除之前启用的所有流
+func (c *Config) DisableAllStreams() error {
+	var err *C.rs2_error
+	C.rs2_config_disable_all_streams(c.ptr, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// formatNames 配置文件和 C 接口中可用的格式名称
+var formatNames = map[string]Format{
+	"any":   FormatAny,
+	"z16":   FormatZ16,
+	"rgb8":  FormatRGB8,
+	"bgr8":  FormatBGR8,
+	"rgba8": FormatRGBA8,
+	"bgra8": FormatBGRA8,
+	"y8":    FormatY8,
+	"y16":   FormatY16,
+}
+
+// ParseFormat 将名称（如 "z16"、"rgb8"，不区分大小写）转换为 Format
+func ParseFormat(name string) (Format, error) {
+	if f, ok := formatNames[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// ParseStreamType 将名称（"depth"、"color"、"infrared"，不区分大小写）转换为 StreamType
+func ParseStreamType(name string) (StreamType, error) {
+	if st, ok := streamNames[strings.ToLower(name)]; ok {
+		return st, nil
+	}
+	return 0, fmt.Errorf("unknown stream %q", name)
+}
+
+// EnableDevice 指定按序列号使用的设备，多台相机同时连接时用于区分
+func (c *Config) EnableDevice(serial string) error {
+	var err *C.rs2_error
+	cs := C.CString(serial)
+	defer C.free(unsafe.Pointer(cs))
+
+	C.rs2_config_enable_device(c.ptr, cs, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// EnableDeviceFromFile 改为从 .bag 录像回放，不需要连接相机；播放到结尾后从头循环
+func (c *Config) EnableDeviceFromFile(path string) error {
+	var err *C.rs2_error
+	cs := C.CString(path)
+	defer C.free(unsafe.Pointer(cs))
+
+	C.rs2_config_enable_device_from_file(c.ptr, cs, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
 // Close 释放配置对象内存
 func (c *Config) Close() {
 	if c.ptr != nil {
@@ -79,6 +79,36 @@ func (p *Pipeline) GetDevice() (*Device, error) {
 	return &Device{ptr: dev}, nil
 }
 
+// QueryDevices 枚举当前连接的所有设备
+// 注意：返回的每个 Device 都需要手动 Close
+func (ctx *Context) QueryDevices() ([]*Device, error) {
+	var err *C.rs2_error
+
+	list := C.rs2_query_devices(ctx.ptr, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	defer C.rs2_delete_device_list(list)
+
+	count := int(C.rs2_get_device_count(list, &err))
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+
+	var devices []*Device
+	for i := 0; i < count; i++ {
+		ptr := C.rs2_create_device(list, C.int(i), &err)
+		if err != nil {
+			for _, d := range devices {
+				d.Close()
+			}
+			return nil, errorFromC(err)
+		}
+		devices = append(devices, &Device{ptr: ptr})
+	}
+	return devices, nil
+}
+
 // GetSensors 获取设备的所有传感器
 // 注意：返回的 Sensor 切片中的每个元素都需要手动 Close
 func (d *Device) GetSensors() ([]*Sensor, error) {
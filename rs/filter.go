@@ -5,134 +5,240 @@ package rs
 #include <stdlib.h>
 */
 import "C"
-import "unsafe"
+import "fmt"
 
 // Filter 封装了各类图像处理过滤器
 type Filter struct {
-	ptr   *C.rs2_processing_block
-	queue *C.rs2_frame_queue
+	processingBlock
 }
 
 // newFilter 内部辅助函数，统一初始化过滤器
-func newFilter(ptr *C.rs2_processing_block) (*Filter, error) {
-	var err *C.rs2_error
-
-	// 创建帧队列用于接收处理结果
-	queue := C.rs2_create_frame_queue(1, &err)
-	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		return nil, errorFromC(err)
-	}
-
-	// 启动处理块，将结果输出到队列
-	C.rs2_start_processing_queue(ptr, queue, &err)
+func newFilter(ptr *C.rs2_processing_block, name string, opts []ProcessingOption) (*Filter, error) {
+	block, err := newProcessingBlock(ptr, name, opts)
 	if err != nil {
-		C.rs2_delete_processing_block(ptr)
-		C.rs2_delete_frame_queue(queue)
-		return nil, errorFromC(err)
+		return nil, err
 	}
-
-	return &Filter{ptr: ptr, queue: queue}, nil
+	return &Filter{processingBlock: block}, nil
 }
 
 // NewDecimationFilter 创建降采样过滤器
 // magnitude: 降采样倍数 (2-8)
-func NewDecimationFilter() (*Filter, error) {
+func NewDecimationFilter(opts ...ProcessingOption) (*Filter, error) {
 	var err *C.rs2_error
 	ptr := C.rs2_create_decimation_filter_block(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
-	return newFilter(ptr)
+	return newFilter(ptr, "decimation", opts)
 }
 
 // NewSpatialFilter 创建空间过滤器
 // 用于平滑深度数据，保留边缘
-func NewSpatialFilter() (*Filter, error) {
+func NewSpatialFilter(opts ...ProcessingOption) (*Filter, error) {
 	var err *C.rs2_error
 	ptr := C.rs2_create_spatial_filter_block(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
-	return newFilter(ptr)
+	return newFilter(ptr, "spatial", opts)
 }
 
 // NewTemporalFilter 创建时间过滤器
 // 利用多帧数据进行平滑，减少噪点
-func NewTemporalFilter() (*Filter, error) {
+func NewTemporalFilter(opts ...ProcessingOption) (*Filter, error) {
 	var err *C.rs2_error
 	ptr := C.rs2_create_temporal_filter_block(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
-	return newFilter(ptr)
+	return newFilter(ptr, "temporal", opts)
 }
 
 // NewHoleFillingFilter 创建孔洞填充过滤器
-func NewHoleFillingFilter() (*Filter, error) {
+func NewHoleFillingFilter(opts ...ProcessingOption) (*Filter, error) {
 	var err *C.rs2_error
 	ptr := C.rs2_create_hole_filling_filter_block(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
-	return newFilter(ptr)
+	return newFilter(ptr, "hole_filling", opts)
 }
 
 // Process 处理帧
 // 注意：会增加输入帧的引用计数，原帧仍需调用者释放
 func (f *Filter) Process(frame *Frame) (*Frame, error) {
-	var err *C.rs2_error
-
-	// 增加引用计数，因为 rs2_process_frame 会消耗一个引用
-	// 如果不增加，Go 层的 frame.Close() 会导致 double free
-	C.rs2_frame_add_ref(frame.ptr, &err)
+	result, err := f.process(frame.ptr)
 	if err != nil {
-		return nil, errorFromC(err)
+		return nil, err
+	}
+	return &Frame{ptr: result}, nil
+}
+
+// TryProcess 非阻塞版本的 Process，结果尚未就绪时返回 nil, nil
+func (f *Filter) TryProcess(frame *Frame) (*Frame, error) {
+	result, err := f.tryProcess(frame.ptr)
+	if err != nil || result == nil {
+		return nil, err
 	}
+	return &Frame{ptr: result}, nil
+}
+
+// SetOption 设置过滤器参数
+// option: 选项枚举 (如 OptionFilterMagnitude)
+// value: 值
+// 过滤器不支持该选项或值超出范围时返回错误
+func (f *Filter) SetOption(option int, value float32) error {
+	return f.setOption(option, value)
+}
+
+// GetOption 读取过滤器参数，不支持的选项返回错误
+func (f *Filter) GetOption(option int) (float32, error) {
+	return f.getOption(option)
+}
 
-	// 处理
-	C.rs2_process_frame(f.ptr, frame.ptr, &err)
+// GetOptionRange 查询过滤器参数的取值范围
+func (f *Filter) GetOptionRange(option int) (OptionRange, error) {
+	return f.optionRange(option)
+}
+
+// Close 释放资源
+func (f *Filter) Close() {
+	f.close()
+}
+
+// ThresholdFilter 距离阈值过滤器，将超出 [min, max] 范围的深度置零
+type ThresholdFilter struct {
+	*Filter
+}
+
+// NewThresholdFilter 创建距离阈值过滤器
+func NewThresholdFilter(opts ...ProcessingOption) (*ThresholdFilter, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_threshold(&err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
+	f, goErr := newFilter(ptr, "threshold", opts)
+	if goErr != nil {
+		return nil, goErr
+	}
+	return &ThresholdFilter{Filter: f}, nil
+}
+
+// SetMinDistance 设置最小距离（米）
+func (f *ThresholdFilter) SetMinDistance(meters float32) error {
+	return f.SetOption(OptionMinDistance, meters)
+}
 
-	// 等待结果
-	result := C.rs2_wait_for_frame(f.queue, 5000, &err)
+// SetMaxDistance 设置最大距离（米）
+func (f *ThresholdFilter) SetMaxDistance(meters float32) error {
+	return f.SetOption(OptionMaxDistance, meters)
+}
+
+// SetRange 同时设置最小和最大距离（米）
+func (f *ThresholdFilter) SetRange(minMeters, maxMeters float32) error {
+	if minMeters > maxMeters {
+		return fmt.Errorf("threshold min %.3f > max %.3f", minMeters, maxMeters)
+	}
+	if err := f.SetMinDistance(minMeters); err != nil {
+		return err
+	}
+	return f.SetMaxDistance(maxMeters)
+}
+
+// DisparityTransform 深度与视差之间的转换
+// Intel 推荐在 Spatial/Temporal 前转换到视差域，处理完后再转换回深度
+type DisparityTransform struct {
+	*Filter
+	toDisparity bool
+}
+
+// NewDisparityTransform 创建视差转换块
+// toDisparity 为 true 时深度 -> 视差，否则视差 -> 深度
+func NewDisparityTransform(toDisparity bool, opts ...ProcessingOption) (*DisparityTransform, error) {
+	var err *C.rs2_error
+	var direction C.uchar
+	name := "disparity_to_depth"
+	if toDisparity {
+		direction = 1
+		name = "depth_to_disparity"
+	}
+
+	ptr := C.rs2_create_disparity_transform_block(direction, &err)
 	if err != nil {
 		return nil, errorFromC(err)
 	}
+	f, goErr := newFilter(ptr, name, opts)
+	if goErr != nil {
+		return nil, goErr
+	}
+	return &DisparityTransform{Filter: f, toDisparity: toDisparity}, nil
+}
 
-	return &Frame{ptr: result}, nil
+// ToDisparity 返回转换方向，true 表示深度 -> 视差
+func (f *DisparityTransform) ToDisparity() bool {
+	return f.toDisparity
 }
 
-// SetOption 设置过滤器参数
-// option: 选项枚举 (如 C.RS2_OPTION_FILTER_MAGNITUDE)
-// value: 值
-func (f *Filter) SetOption(option int, value float32) error {
-	var err *C.rs2_error
-	// processing block 也是一种 options interface
-	// 直接转换为 options 指针
-	opts := (*C.rs2_options)(unsafe.Pointer(f.ptr))
+// HDRMergeFilter 将两组不同曝光的深度帧合并为一帧高动态范围深度
+// 需要相机开启 HDR 序列模式 (sequence size = 2)
+type HDRMergeFilter struct {
+	*Filter
+}
 
-	if C.rs2_supports_option(opts, C.rs2_option(option), &err) == 0 {
-		return nil // 或者返回错误说不支持
+// NewHDRMergeFilter 创建 HDR 合并过滤器
+func NewHDRMergeFilter(opts ...ProcessingOption) (*HDRMergeFilter, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_hdr_merge_processing_block(&err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	f, goErr := newFilter(ptr, "hdr_merge", opts)
+	if goErr != nil {
+		return nil, goErr
 	}
+	return &HDRMergeFilter{Filter: f}, nil
+}
 
-	C.rs2_set_option(opts, C.rs2_option(option), C.float(value), &err)
+// SequenceIDFilter 在 HDR 序列模式下只保留指定序列号的帧
+type SequenceIDFilter struct {
+	*Filter
+}
+
+// NewSequenceIDFilter 创建序列号过滤器
+func NewSequenceIDFilter(opts ...ProcessingOption) (*SequenceIDFilter, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_sequence_id_filter(&err)
 	if err != nil {
-		return errorFromC(err)
+		return nil, errorFromC(err)
+	}
+	f, goErr := newFilter(ptr, "sequence_id", opts)
+	if goErr != nil {
+		return nil, goErr
 	}
-	return nil
+	return &SequenceIDFilter{Filter: f}, nil
 }
 
-// Close 释放资源
-func (f *Filter) Close() {
-	if f.ptr != nil {
-		C.rs2_delete_processing_block(f.ptr)
-		f.ptr = nil
+// SetSequenceID 设置要保留的序列号（HDR 模式下为 1 或 2）
+func (f *SequenceIDFilter) SetSequenceID(id int) error {
+	return f.SetOption(OptionSequenceID, float32(id))
+}
+
+// UnitsTransform 将 Z16 深度转换为以米为单位的 float 距离帧
+type UnitsTransform struct {
+	*Filter
+}
+
+// NewUnitsTransform 创建单位转换块
+func NewUnitsTransform(opts ...ProcessingOption) (*UnitsTransform, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_units_transform(&err)
+	if err != nil {
+		return nil, errorFromC(err)
 	}
-	if f.queue != nil {
-		C.rs2_delete_frame_queue(f.queue)
-		f.queue = nil
+	f, goErr := newFilter(ptr, "units_transform", opts)
+	if goErr != nil {
+		return nil, goErr
 	}
+	return &UnitsTransform{Filter: f}, nil
 }
@@ -0,0 +1,275 @@
+package rs
+
+import "fmt"
+
+// FilterOptions 是过滤器的类型化参数集合
+// 可以通过 WithFilterOptions 在创建时应用，也可以通过 Filter.ApplyOptions 在运行时应用
+// 建议基于 Default*Options() 的返回值修改，未修改的字段保持 librealsense 的默认值
+// 参数结构体只能用于对应类型的过滤器，例如 DecimationFilterOptions 用于空间过滤器时返回错误
+type FilterOptions interface {
+	applyTo(b *processingBlock) error
+}
+
+// WithFilterOptions 在创建过滤器时应用类型化参数
+// 参数非法（超出范围、过滤器不支持）时创建失败
+func WithFilterOptions(settings FilterOptions) ProcessingOption {
+	return func(c *processingConfig) {
+		c.settings = append(c.settings, settings)
+	}
+}
+
+// checkBlock 确认参数与处理块类型一致
+// 不同过滤器共用同一个选项编号（如 magnitude 在空间过滤器中是迭代次数），不检查会静默改错参数
+func checkBlock(b *processingBlock, kind string) error {
+	if b.name != kind {
+		return fmt.Errorf("%s options cannot be used with %s block", kind, b.name)
+	}
+	return nil
+}
+
+// optionValue 是待设置的一个选项
+type optionValue struct {
+	option int
+	value  float32
+}
+
+// applyOptionValues 先校验所有取值再依次设置，避免只应用了一部分参数
+func applyOptionValues(b *processingBlock, values []optionValue) error {
+	for _, v := range values {
+		r, err := b.optionRange(v.option)
+		if err != nil {
+			return err
+		}
+		if v.value < r.Min || v.value > r.Max {
+			return fmt.Errorf("%s: option %s value %g out of range [%g, %g]",
+				b.name, optionName(v.option), v.value, r.Min, r.Max)
+		}
+	}
+	for _, v := range values {
+		if err := b.setOption(v.option, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readOptionValues 依次读取选项的当前值
+func readOptionValues(b *processingBlock, options ...int) ([]float32, error) {
+	values := make([]float32, len(options))
+	for i, option := range options {
+		v, err := b.getOption(option)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// DecimationFilterOptions 降采样过滤器参数
+type DecimationFilterOptions struct {
+	Magnitude int `json:"magnitude"` // 降采样倍数 (1-8)
+}
+
+// DefaultDecimationFilterOptions 返回 librealsense 的默认参数
+func DefaultDecimationFilterOptions() DecimationFilterOptions {
+	return DecimationFilterOptions{Magnitude: 2}
+}
+
+func (o DecimationFilterOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "decimation"); err != nil {
+		return err
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionFilterMagnitude, float32(o.Magnitude)},
+	})
+}
+
+// SpatialFilterOptions 空间过滤器参数
+type SpatialFilterOptions struct {
+	Magnitude   int     `json:"magnitude"`    // 迭代次数 (1-5)
+	SmoothAlpha float32 `json:"smooth_alpha"` // 平滑系数 (0.25-1)，越小越平滑
+	SmoothDelta float32 `json:"smooth_delta"` // 边缘阈值 (1-50)，深度差超过该值视为边缘
+	HolesFill   int     `json:"holes_fill"`   // 孔洞填充半径 (0=关闭, 1=2px, 2=4px, 3=8px, 4=16px, 5=无限)
+}
+
+// DefaultSpatialFilterOptions 返回 librealsense 的默认参数
+func DefaultSpatialFilterOptions() SpatialFilterOptions {
+	return SpatialFilterOptions{Magnitude: 2, SmoothAlpha: 0.5, SmoothDelta: 20, HolesFill: 0}
+}
+
+func (o SpatialFilterOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "spatial"); err != nil {
+		return err
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionFilterMagnitude, float32(o.Magnitude)},
+		{OptionFilterSmoothAlpha, o.SmoothAlpha},
+		{OptionFilterSmoothDelta, o.SmoothDelta},
+		{OptionHolesFill, float32(o.HolesFill)},
+	})
+}
+
+// PersistencyMode 时间过滤器的持续性模式
+type PersistencyMode int
+
+const (
+	PersistencyDisabled     PersistencyMode = 0 // 不使用历史数据填充
+	PersistencyValid8of8    PersistencyMode = 1 // 最近 8 帧均有效
+	PersistencyValid2of3    PersistencyMode = 2 // 最近 3 帧中 2 帧有效
+	PersistencyValid2of4    PersistencyMode = 3 // 最近 4 帧中 2 帧有效（默认）
+	PersistencyValid2of8    PersistencyMode = 4 // 最近 8 帧中 2 帧有效
+	PersistencyValid1of2    PersistencyMode = 5 // 最近 2 帧中 1 帧有效
+	PersistencyValid1of5    PersistencyMode = 6 // 最近 5 帧中 1 帧有效
+	PersistencyValid1of8    PersistencyMode = 7 // 最近 8 帧中 1 帧有效
+	PersistencyIndefinitely PersistencyMode = 8 // 始终使用最后一次有效值
+)
+
+// TemporalFilterOptions 时间过滤器参数
+type TemporalFilterOptions struct {
+	Alpha           float32         `json:"alpha"`            // 平滑系数 (0-1)
+	Delta           float32         `json:"delta"`            // 边缘阈值 (1-100)
+	PersistencyMode PersistencyMode `json:"persistency_mode"` // 持续性模式 (0-8)
+}
+
+// DefaultTemporalFilterOptions 返回 librealsense 的默认参数
+func DefaultTemporalFilterOptions() TemporalFilterOptions {
+	return TemporalFilterOptions{Alpha: 0.4, Delta: 20, PersistencyMode: PersistencyValid2of4}
+}
+
+func (o TemporalFilterOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "temporal"); err != nil {
+		return err
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionFilterSmoothAlpha, o.Alpha},
+		{OptionFilterSmoothDelta, o.Delta},
+		{OptionHolesFill, float32(o.PersistencyMode)},
+	})
+}
+
+// HoleFillingMode 孔洞填充模式
+type HoleFillingMode int
+
+const (
+	HoleFillFromLeft      HoleFillingMode = 0 // 使用左侧像素填充
+	HoleFillFarestAround  HoleFillingMode = 1 // 使用周围最远的像素填充（默认）
+	HoleFillNearestAround HoleFillingMode = 2 // 使用周围最近的像素填充
+)
+
+// HoleFillingFilterOptions 孔洞填充过滤器参数
+type HoleFillingFilterOptions struct {
+	Mode HoleFillingMode `json:"mode"` // 填充模式 (0-2)
+}
+
+// DefaultHoleFillingFilterOptions 返回 librealsense 的默认参数
+func DefaultHoleFillingFilterOptions() HoleFillingFilterOptions {
+	return HoleFillingFilterOptions{Mode: HoleFillFarestAround}
+}
+
+func (o HoleFillingFilterOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "hole_filling"); err != nil {
+		return err
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionHolesFill, float32(o.Mode)},
+	})
+}
+
+// ThresholdFilterOptions 距离阈值过滤器参数
+type ThresholdFilterOptions struct {
+	MinDistance float32 `json:"min_distance"` // 最小距离（米）
+	MaxDistance float32 `json:"max_distance"` // 最大距离（米）
+}
+
+// DefaultThresholdFilterOptions 返回 librealsense 的默认参数
+func DefaultThresholdFilterOptions() ThresholdFilterOptions {
+	return ThresholdFilterOptions{MinDistance: 0.1, MaxDistance: 4}
+}
+
+func (o ThresholdFilterOptions) applyTo(b *processingBlock) error {
+	if err := checkBlock(b, "threshold"); err != nil {
+		return err
+	}
+	if o.MinDistance > o.MaxDistance {
+		return fmt.Errorf("%s: min distance %g > max distance %g", b.name, o.MinDistance, o.MaxDistance)
+	}
+	return applyOptionValues(b, []optionValue{
+		{OptionMinDistance, o.MinDistance},
+		{OptionMaxDistance, o.MaxDistance},
+	})
+}
+
+// ApplyOptions 在运行时应用类型化参数
+// 所有取值校验通过后才会写入，任何一项非法都不会修改过滤器
+func (f *Filter) ApplyOptions(settings FilterOptions) error {
+	return settings.applyTo(&f.processingBlock)
+}
+
+// DecimationOptions 读取降采样过滤器的当前参数
+func (f *Filter) DecimationOptions() (DecimationFilterOptions, error) {
+	if err := checkBlock(&f.processingBlock, "decimation"); err != nil {
+		return DecimationFilterOptions{}, err
+	}
+	v, err := readOptionValues(&f.processingBlock, OptionFilterMagnitude)
+	if err != nil {
+		return DecimationFilterOptions{}, err
+	}
+	return DecimationFilterOptions{Magnitude: int(v[0])}, nil
+}
+
+// SpatialOptions 读取空间过滤器的当前参数
+func (f *Filter) SpatialOptions() (SpatialFilterOptions, error) {
+	if err := checkBlock(&f.processingBlock, "spatial"); err != nil {
+		return SpatialFilterOptions{}, err
+	}
+	v, err := readOptionValues(&f.processingBlock,
+		OptionFilterMagnitude, OptionFilterSmoothAlpha, OptionFilterSmoothDelta, OptionHolesFill)
+	if err != nil {
+		return SpatialFilterOptions{}, err
+	}
+	return SpatialFilterOptions{
+		Magnitude:   int(v[0]),
+		SmoothAlpha: v[1],
+		SmoothDelta: v[2],
+		HolesFill:   int(v[3]),
+	}, nil
+}
+
+// TemporalOptions 读取时间过滤器的当前参数
+func (f *Filter) TemporalOptions() (TemporalFilterOptions, error) {
+	if err := checkBlock(&f.processingBlock, "temporal"); err != nil {
+		return TemporalFilterOptions{}, err
+	}
+	v, err := readOptionValues(&f.processingBlock,
+		OptionFilterSmoothAlpha, OptionFilterSmoothDelta, OptionHolesFill)
+	if err != nil {
+		return TemporalFilterOptions{}, err
+	}
+	return TemporalFilterOptions{
+		Alpha:           v[0],
+		Delta:           v[1],
+		PersistencyMode: PersistencyMode(v[2]),
+	}, nil
+}
+
+// HoleFillingOptions 读取孔洞填充过滤器的当前参数
+func (f *Filter) HoleFillingOptions() (HoleFillingFilterOptions, error) {
+	if err := checkBlock(&f.processingBlock, "hole_filling"); err != nil {
+		return HoleFillingFilterOptions{}, err
+	}
+	v, err := readOptionValues(&f.processingBlock, OptionHolesFill)
+	if err != nil {
+		return HoleFillingFilterOptions{}, err
+	}
+	return HoleFillingFilterOptions{Mode: HoleFillingMode(v[0])}, nil
+}
+
+// Options 读取距离阈值过滤器的当前参数
+func (f *ThresholdFilter) Options() (ThresholdFilterOptions, error) {
+	v, err := readOptionValues(&f.processingBlock, OptionMinDistance, OptionMaxDistance)
+	if err != nil {
+		return ThresholdFilterOptions{}, err
+	}
+	return ThresholdFilterOptions{MinDistance: v[0], MaxDistance: v[1]}, nil
+}
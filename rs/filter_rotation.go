@@ -0,0 +1,49 @@
+//go:build rs2_56
+
+package rs
+
+/*
+#include <librealsense2/rs.h>
+*/
+import "C"
+import "fmt"
+
+// 旋转过滤器和 RS2_OPTION_ROTATION 从 librealsense 2.56 开始提供，
+// 默认构建面向 2.54 不包含本文件，需要时使用 go build -tags rs2_56 启用
+
+// OptionRotation 是旋转过滤器的角度选项
+const OptionRotation = C.RS2_OPTION_ROTATION
+
+// RotationFilter 按 90 度的倍数旋转图像
+type RotationFilter struct {
+	*Filter
+}
+
+// NewRotationFilter 创建旋转过滤器
+// librealsense 要求在创建时给出需要旋转的流，这里使用 sensor 支持的流配置
+func NewRotationFilter(sensor *Sensor, opts ...ProcessingOption) (*RotationFilter, error) {
+	if _, err := sensor.StreamProfiles(); err != nil {
+		return nil, err
+	}
+
+	var err *C.rs2_error
+	ptr := C.rs2_create_rotation_filter_block(sensor.profiles, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	f, goErr := newFilter(ptr, "rotation", opts)
+	if goErr != nil {
+		return nil, goErr
+	}
+	return &RotationFilter{Filter: f}, nil
+}
+
+// SetRotation 设置旋转角度，只支持 0、90、-90 和 180
+func (f *RotationFilter) SetRotation(degrees int) error {
+	switch degrees {
+	case 0, 90, -90, 180:
+	default:
+		return fmt.Errorf("unsupported rotation %d, expected 0, 90, -90 or 180", degrees)
+	}
+	return f.SetOption(OptionRotation, float32(degrees))
+}
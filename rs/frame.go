@@ -84,6 +84,47 @@ func (f *Frame) GetHeight() int {
 	return int(h)
 }
 
+// GetStride 获取每行的字节数（可能大于 宽度*每像素字节数）
+func (f *Frame) GetStride() int {
+	var err *C.rs2_error
+	stride := C.rs2_get_frame_stride_in_bytes(f.ptr, &err)
+	if checkError(err) != nil {
+		return 0
+	}
+	return int(stride)
+}
+
+// GetBitsPerPixel 获取每像素的位数，例如 Z16 为 16，RGB8 为 24
+func (f *Frame) GetBitsPerPixel() int {
+	var err *C.rs2_error
+	bpp := C.rs2_get_frame_bits_per_pixel(f.ptr, &err)
+	if checkError(err) != nil {
+		return 0
+	}
+	return int(bpp)
+}
+
+// GetFormat 获取帧的像素格式
+func (f *Frame) GetFormat() (Format, error) {
+	var err *C.rs2_error
+	profile := C.rs2_get_frame_stream_profile(f.ptr, &err)
+	if err != nil {
+		return 0, errorFromC(err)
+	}
+	_, format, _, _, _, goErr := getProfileData(profile)
+	return format, goErr
+}
+
+// GetDepthUnits 获取深度帧的深度单位（米/单位），非深度帧返回错误
+func (f *Frame) GetDepthUnits() (float32, error) {
+	var err *C.rs2_error
+	units := C.rs2_depth_frame_get_units(f.ptr, &err)
+	if err != nil {
+		return 0, errorFromC(err)
+	}
+	return float32(units), nil
+}
+
 // GetTimestamp 获取帧的硬件时间戳（毫秒）
 func (f *Frame) GetTimestamp() (float64, error) {
 	var err *C.rs2_error
@@ -94,6 +135,16 @@ func (f *Frame) GetTimestamp() (float64, error) {
 	return float64(ts), nil
 }
 
+// GetFrameNumber 获取帧序号
+func (f *Frame) GetFrameNumber() (uint64, error) {
+	var err *C.rs2_error
+	n := C.rs2_get_frame_number(f.ptr, &err)
+	if err != nil {
+		return 0, errorFromC(err)
+	}
+	return uint64(n), nil
+}
+
 // GetTimestampDomain 获取时间戳域
 // RS2_TIMESTAMP_DOMAIN_HARDWARE_CLOCK (1) 表示硬件时间戳
 // RS2_TIMESTAMP_DOMAIN_SYSTEM_TIME (2) 表示系统时间
@@ -106,6 +157,17 @@ func (f *Frame) GetTimestampDomain() (int, error) {
 	return int(domain), nil
 }
 
+// Clone 增加帧的引用计数并返回新的 Frame 句柄
+// 两个句柄指向同一块帧数据，需要分别 Close
+func (f *Frame) Clone() (*Frame, error) {
+	var err *C.rs2_error
+	C.rs2_frame_add_ref(f.ptr, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	return &Frame{ptr: f.ptr}, nil
+}
+
 // Close 极其重要！必须手动释放每一帧，否则 Jetson 会迅速崩溃
 func (f *Frame) Close() {
 	if f.ptr != nil {
@@ -114,9 +176,43 @@ func (f *Frame) Close() {
 	}
 }
 
+// frameStream 读取帧所属流的类型和索引
+func frameStream(frame *C.rs2_frame) (StreamType, int, error) {
+	var err *C.rs2_error
+	profile := C.rs2_get_frame_stream_profile(frame, &err)
+	if err != nil {
+		return 0, 0, errorFromC(err)
+	}
+
+	stream, _, index, _, _, goErr := getProfileData(profile)
+	if goErr != nil {
+		return 0, 0, goErr
+	}
+	return stream, index, nil
+}
+
+// GetStreamType 获取帧所属的流类型
+func (f *Frame) GetStreamType() (StreamType, error) {
+	stream, _, err := frameStream(f.ptr)
+	return stream, err
+}
+
+// GetStreamIndex 获取帧所属的流索引（例如红外左右目分别为 1 和 2）
+func (f *Frame) GetStreamIndex() (int, error) {
+	_, index, err := frameStream(f.ptr)
+	return index, err
+}
+
 // GetFrame 从 FrameSet 中提取特定类型的帧
 // 注意：返回的 Frame 必须手动 Close，否则会导致内存泄漏
 func (fs *FrameSet) GetFrame(stream StreamType) (*Frame, error) {
+	return fs.GetFrameByIndex(stream, -1)
+}
+
+// GetFrameByIndex 从 FrameSet 中提取指定流类型和索引的帧
+// index 为 -1 时匹配该类型的第一帧；红外流的左右目索引分别为 1 和 2
+// 注意：返回的 Frame 必须手动 Close，否则会导致内存泄漏
+func (fs *FrameSet) GetFrameByIndex(stream StreamType, index int) (*Frame, error) {
 	var err *C.rs2_error
 	count := int(C.rs2_embedded_frames_count(fs.ptr, &err))
 	if e := checkError(err); e != nil {
@@ -131,26 +227,14 @@ func (fs *FrameSet) GetFrame(stream StreamType) (*Frame, error) {
 		}
 
 		// 检查该帧的流类型
-		// 获取 profile
-		profile := C.rs2_get_frame_stream_profile(frame, &err)
-		if e := checkError(err); e != nil {
-			C.rs2_release_frame(frame)
-			return nil, e
-		}
-
-		var cstream C.rs2_stream
-		var format C.rs2_format
-		var index C.int
-		var uniqueID C.int
-		var framerate C.int
-		C.rs2_get_stream_profile_data(profile, &cstream, &format, &index, &uniqueID, &framerate, &err)
-		if e := checkError(err); e != nil {
+		cstream, cindex, e := frameStream(frame)
+		if e != nil {
 			C.rs2_release_frame(frame)
 			return nil, e
 		}
 
 		// 匹配流类型
-		if StreamType(cstream) == stream || stream == StreamAny {
+		if (cstream == stream || stream == StreamAny) && (index < 0 || cindex == index) {
 			// rs2_extract_frame 返回的 frame 引用计数已经是 +1 的
 			// 我们直接封装返回
 			return &Frame{ptr: frame}, nil
@@ -160,9 +244,34 @@ func (fs *FrameSet) GetFrame(stream StreamType) (*Frame, error) {
 		C.rs2_release_frame(frame)
 	}
 
+	if index >= 0 {
+		return nil, fmt.Errorf("frame not found for stream %v index %d", stream, index)
+	}
 	return nil, fmt.Errorf("frame not found for stream %v", stream)
 }
 
+// Frames 返回帧集中的所有帧，每一帧都需要手动 Close
+func (fs *FrameSet) Frames() ([]*Frame, error) {
+	var err *C.rs2_error
+	count := int(C.rs2_embedded_frames_count(fs.ptr, &err))
+	if e := checkError(err); e != nil {
+		return nil, e
+	}
+
+	frames := make([]*Frame, 0, count)
+	for i := 0; i < count; i++ {
+		frame := C.rs2_extract_frame(fs.ptr, C.int(i), &err)
+		if e := checkError(err); e != nil {
+			for _, f := range frames {
+				f.Close()
+			}
+			return nil, e
+		}
+		frames = append(frames, &Frame{ptr: frame})
+	}
+	return frames, nil
+}
+
 // Close 释放帧集
 func (fs *FrameSet) Close() {
 	if fs.ptr != nil {
@@ -0,0 +1,138 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_sensor.h>
+#include <librealsense2/h/rs_frame.h>
+*/
+import "C"
+
+import "github.com/tianfei212/jetson-rs-middleware/depth"
+
+// Distortion 映射 rs2_distortion 畸变模型
+type Distortion int
+
+const (
+	DistortionNone                 Distortion = C.RS2_DISTORTION_NONE
+	DistortionModifiedBrownConrady Distortion = C.RS2_DISTORTION_MODIFIED_BROWN_CONRADY
+	DistortionInverseBrownConrady  Distortion = C.RS2_DISTORTION_INVERSE_BROWN_CONRADY
+	DistortionFTheta               Distortion = C.RS2_DISTORTION_FTHETA
+	DistortionBrownConrady         Distortion = C.RS2_DISTORTION_BROWN_CONRADY
+	DistortionKannalaBrandt4       Distortion = C.RS2_DISTORTION_KANNALA_BRANDT4
+)
+
+// Intrinsics 是视频流的相机内参
+type Intrinsics struct {
+	Width  int        `json:"width"`
+	Height int        `json:"height"`
+	PPX    float32    `json:"ppx"` // 主点横坐标（像素）
+	PPY    float32    `json:"ppy"` // 主点纵坐标（像素）
+	FX     float32    `json:"fx"`  // 焦距（像素）
+	FY     float32    `json:"fy"`
+	Model  Distortion `json:"model"`
+	Coeffs [5]float32 `json:"coeffs"`
+}
+
+// Extrinsics 是两个流之间的外参（列主序旋转矩阵 + 平移，单位米）
+type Extrinsics struct {
+	Rotation    [9]float32 `json:"rotation"`
+	Translation [3]float32 `json:"translation"`
+}
+
+// IdentityExtrinsics 返回单位外参（两个流坐标系重合）
+func IdentityExtrinsics() Extrinsics {
+	return Extrinsics{Rotation: [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}}
+}
+
+// intrinsicsFromC 转换 C 内参结构
+func intrinsicsFromC(in C.rs2_intrinsics) Intrinsics {
+	out := Intrinsics{
+		Width:  int(in.width),
+		Height: int(in.height),
+		PPX:    float32(in.ppx),
+		PPY:    float32(in.ppy),
+		FX:     float32(in.fx),
+		FY:     float32(in.fy),
+		Model:  Distortion(in.model),
+	}
+	for i := range out.Coeffs {
+		out.Coeffs[i] = float32(in.coeffs[i])
+	}
+	return out
+}
+
+// toC 转换为 C 内参结构
+func (in Intrinsics) toC() C.rs2_intrinsics {
+	out := C.rs2_intrinsics{
+		width:  C.int(in.Width),
+		height: C.int(in.Height),
+		ppx:    C.float(in.PPX),
+		ppy:    C.float(in.PPY),
+		fx:     C.float(in.FX),
+		fy:     C.float(in.FY),
+		model:  C.rs2_distortion(in.Model),
+	}
+	for i, c := range in.Coeffs {
+		out.coeffs[i] = C.float(c)
+	}
+	return out
+}
+
+// ToDepth 转换为纯 Go 的 depth.Intrinsics，用于反投影和 roi 3D 体积
+func (in Intrinsics) ToDepth() depth.Intrinsics {
+	return depth.Intrinsics{
+		Width:  in.Width,
+		Height: in.Height,
+		PPX:    in.PPX,
+		PPY:    in.PPY,
+		FX:     in.FX,
+		FY:     in.FY,
+		Model:  depth.Distortion(in.Model),
+		Coeffs: in.Coeffs,
+	}
+}
+
+// Intrinsics 获取视频流配置的内参
+func (p *SensorProfile) Intrinsics() (Intrinsics, error) {
+	return profileIntrinsics(p.ptr)
+}
+
+// profileIntrinsics 读取 profile 的内参
+func profileIntrinsics(profile *C.rs2_stream_profile) (Intrinsics, error) {
+	var err *C.rs2_error
+	var in C.rs2_intrinsics
+	C.rs2_get_video_stream_intrinsics(profile, &in, &err)
+	if err != nil {
+		return Intrinsics{}, errorFromC(err)
+	}
+	return intrinsicsFromC(in), nil
+}
+
+// ExtrinsicsTo 获取从当前流到目标流的外参
+func (p *SensorProfile) ExtrinsicsTo(to *SensorProfile) (Extrinsics, error) {
+	var err *C.rs2_error
+	var ex C.rs2_extrinsics
+	C.rs2_get_extrinsics(p.ptr, to.ptr, &ex, &err)
+	if err != nil {
+		return Extrinsics{}, errorFromC(err)
+	}
+
+	var out Extrinsics
+	for i := range out.Rotation {
+		out.Rotation[i] = float32(ex.rotation[i])
+	}
+	for i := range out.Translation {
+		out.Translation[i] = float32(ex.translation[i])
+	}
+	return out, nil
+}
+
+// GetIntrinsics 获取帧所属视频流的内参
+func (f *Frame) GetIntrinsics() (Intrinsics, error) {
+	var err *C.rs2_error
+	profile := C.rs2_get_frame_stream_profile(f.ptr, &err)
+	if err != nil {
+		return Intrinsics{}, errorFromC(err)
+	}
+	return profileIntrinsics(profile)
+}
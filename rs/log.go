@@ -0,0 +1,63 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+
+extern void goLogCallback(rs2_log_severity severity, rs2_log_message* msg, void* user);
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+)
+
+// LogHandler 接收 librealsense 的日志，在 librealsense 的线程中调用，不应阻塞
+type LogHandler func(severity LogSeverity, message string)
+
+// logState 保存当前的日志处理函数
+// librealsense 不支持注销日志回调，回调只注册一次，之后仅替换处理函数
+var logState struct {
+	sync.Mutex
+	handler    LogHandler
+	min        LogSeverity
+	registered bool
+}
+
+//export goLogCallback
+func goLogCallback(severity C.rs2_log_severity, msg *C.rs2_log_message, user unsafe.Pointer) {
+	logState.Lock()
+	handler, min := logState.handler, logState.min
+	logState.Unlock()
+
+	sev := LogSeverity(severity)
+	if handler == nil || sev < min {
+		return
+	}
+	var err *C.rs2_error
+	text := C.rs2_get_raw_log_message(msg, &err)
+	if err != nil {
+		C.rs2_free_error(err)
+		return
+	}
+	handler(sev, C.GoString(text))
+}
+
+// SetLogHandler 将 min 及以上级别的 librealsense 日志转发给 handler，handler 为 nil 时停止转发
+// 回调在首次调用时注册，之后再调用只能提高最低级别（更低级别的日志不会由 librealsense 产生）
+func SetLogHandler(min LogSeverity, handler LogHandler) error {
+	logState.Lock()
+	defer logState.Unlock()
+
+	if handler != nil && !logState.registered {
+		var err *C.rs2_error
+		C.rs2_log_to_callback(C.rs2_log_severity(min),
+			C.rs2_log_callback_ptr(C.goLogCallback), nil, &err)
+		if err != nil {
+			return errorFromC(err)
+		}
+		logState.registered = true
+	}
+	logState.handler = handler
+	logState.min = min
+	return nil
+}
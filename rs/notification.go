@@ -0,0 +1,186 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_sensor.h>
+
+extern void goNotificationCallback(rs2_notification* n, void* user);
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// NotificationCategory 映射 rs2_notification_category
+type NotificationCategory int
+
+const (
+	NotificationFramesTimeout             NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_FRAMES_TIMEOUT
+	NotificationFrameCorrupted            NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_FRAME_CORRUPTED
+	NotificationHardwareError             NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_HARDWARE_ERROR
+	NotificationHardwareEvent             NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_HARDWARE_EVENT
+	NotificationUnknownError              NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_UNKNOWN_ERROR
+	NotificationFirmwareUpdateRecommended NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_FIRMWARE_UPDATE_RECOMMENDED
+	NotificationPoseRelocalization        NotificationCategory = C.RS2_NOTIFICATION_CATEGORY_POSE_RELOCALIZATION
+)
+
+// String 返回通知类别的可读名称
+func (c NotificationCategory) String() string {
+	return C.GoString(C.rs2_notification_category_to_string(C.rs2_notification_category(c)))
+}
+
+// LogSeverity 映射 rs2_log_severity，通知与日志共用
+type LogSeverity int
+
+const (
+	LogSeverityDebug LogSeverity = C.RS2_LOG_SEVERITY_DEBUG
+	LogSeverityInfo  LogSeverity = C.RS2_LOG_SEVERITY_INFO
+	LogSeverityWarn  LogSeverity = C.RS2_LOG_SEVERITY_WARN
+	LogSeverityError LogSeverity = C.RS2_LOG_SEVERITY_ERROR
+	LogSeverityFatal LogSeverity = C.RS2_LOG_SEVERITY_FATAL
+	LogSeverityNone  LogSeverity = C.RS2_LOG_SEVERITY_NONE
+)
+
+// String 返回严重级别的可读名称
+func (s LogSeverity) String() string {
+	return C.GoString(C.rs2_log_severity_to_string(C.rs2_log_severity(s)))
+}
+
+// Notification 是 librealsense 传感器通知的 Go 表示
+// 用于记录掉帧、硬件错误以及固件升级建议等事件
+type Notification struct {
+	Category       NotificationCategory `json:"category"`
+	Severity       LogSeverity          `json:"severity"`
+	Description    string               `json:"description"`
+	Timestamp      float64              `json:"timestamp"`       // 通知产生的时间（毫秒）
+	SerializedData string               `json:"serialized_data"` // 附加数据（通常为 JSON 字符串）
+}
+
+// String 便于直接写入日志
+func (n Notification) String() string {
+	return fmt.Sprintf("[%s] %s: %s", n.Severity, n.Category, n.Description)
+}
+
+// notificationSubscription 管理一个传感器的通知通道
+// 回调运行在 librealsense 的线程中，关闭通道前必须加锁，避免向已关闭的通道发送
+type notificationSubscription struct {
+	mu      sync.Mutex
+	ch      chan Notification
+	closed  bool
+	dropped uint64
+}
+
+// deliver 非阻塞地投递通知，通道已满时丢弃并计数，不阻塞 librealsense 线程
+func (sub *notificationSubscription) deliver(n Notification) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- n:
+	default:
+		sub.dropped++
+	}
+}
+
+// close 关闭通知通道
+func (sub *notificationSubscription) close() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+//export goNotificationCallback
+func goNotificationCallback(n *C.rs2_notification, user unsafe.Pointer) {
+	sub, ok := lookupCallback(uintptr(user)).(*notificationSubscription)
+	if !ok {
+		return
+	}
+
+	var err *C.rs2_error
+	note := Notification{}
+
+	note.Category = NotificationCategory(C.rs2_get_notification_category(n, &err))
+	if checkError(err) != nil {
+		return
+	}
+	note.Severity = LogSeverity(C.rs2_get_notification_severity(n, &err))
+	if checkError(err) != nil {
+		return
+	}
+	note.Description = C.GoString(C.rs2_get_notification_description(n, &err))
+	if checkError(err) != nil {
+		return
+	}
+	note.Timestamp = float64(C.rs2_get_notification_timestamp(n, &err))
+	if checkError(err) != nil {
+		return
+	}
+	// 并非所有通知都带有附加数据，获取失败时保持为空
+	note.SerializedData = C.GoString(C.rs2_get_notification_serialized_data(n, &err))
+	if checkError(err) != nil {
+		note.SerializedData = ""
+	}
+
+	sub.deliver(note)
+}
+
+// SubscribeNotifications 订阅传感器的通知
+// bufSize 为通道缓冲大小，通道已满时新的通知会被丢弃（不会阻塞驱动线程）
+// 每个传感器只保留一个订阅，重复调用会关闭之前的通道
+// 返回的通道在 UnsubscribeNotifications 或 Sensor.Close 时关闭
+func (s *Sensor) SubscribeNotifications(bufSize int) (<-chan Notification, error) {
+	if bufSize < 0 {
+		return nil, fmt.Errorf("invalid notification buffer size %d", bufSize)
+	}
+
+	sub := &notificationSubscription{ch: make(chan Notification, bufSize)}
+	id := registerCallback(sub)
+
+	var err *C.rs2_error
+	C.rs2_set_notifications_callback(s.ptr,
+		C.rs2_notification_callback_ptr(C.goNotificationCallback),
+		callbackUserData(id), &err)
+	if err != nil {
+		unregisterCallback(id)
+		return nil, errorFromC(err)
+	}
+
+	// 替换旧的订阅
+	s.UnsubscribeNotifications()
+	s.notifyID = id
+
+	return sub.ch, nil
+}
+
+// UnsubscribeNotifications 取消通知订阅并关闭通道
+// librealsense 无法注销回调，之后到达的通知会被直接忽略
+func (s *Sensor) UnsubscribeNotifications() {
+	if s.notifyID == 0 {
+		return
+	}
+	if sub, ok := lookupCallback(s.notifyID).(*notificationSubscription); ok {
+		sub.close()
+	}
+	unregisterCallback(s.notifyID)
+	s.notifyID = 0
+}
+
+// DroppedNotifications 返回因通道已满而丢弃的通知数量
+func (s *Sensor) DroppedNotifications() uint64 {
+	sub, ok := lookupCallback(s.notifyID).(*notificationSubscription)
+	if !ok {
+		return 0
+	}
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.dropped
+}
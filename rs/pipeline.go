@@ -59,6 +59,21 @@ func (p *Pipeline) WaitForFrames(timeout uint) (*FrameSet, error) {
 	return &FrameSet{ptr: ptr}, nil
 }
 
+// TryWaitForFrames 与 WaitForFrames 相同，但超时不视为错误，而是返回 ok=false
+// 便于调用方区分超时和设备故障
+func (p *Pipeline) TryWaitForFrames(timeout uint) (fs *FrameSet, ok bool, e error) {
+	var err *C.rs2_error
+	var ptr *C.rs2_frame
+
+	if C.rs2_pipeline_try_wait_for_frames(p.ptr, &ptr, C.uint(timeout), &err) == 0 {
+		if err != nil {
+			return nil, false, errorFromC(err)
+		}
+		return nil, false, nil
+	}
+	return &FrameSet{ptr: ptr}, true, nil
+}
+
 // Stop 停止相机流
 func (p *Pipeline) Stop() {
 	var err *C.rs2_error
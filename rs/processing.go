@@ -0,0 +1,309 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_processing.h>
+*/
+import "C"
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// 处理块的默认参数，与早期版本硬编码的行为保持一致
+const (
+	defaultQueueSize = 1
+	defaultTimeout   = 5000 * time.Millisecond
+)
+
+// ProcessingOption 是 Align/Colorizer/Filter 等处理块的可选配置
+type ProcessingOption func(*processingConfig)
+
+// processingConfig 处理块的配置项
+type processingConfig struct {
+	queueSize int
+	timeout   time.Duration
+	budget    time.Duration
+	onSlow    func(block string, elapsed, budget time.Duration)
+	settings  []FilterOptions // 创建后立即应用的类型化参数
+}
+
+// WithQueueSize 设置输出帧队列容量（默认 1）
+func WithQueueSize(size int) ProcessingOption {
+	return func(c *processingConfig) {
+		c.queueSize = size
+	}
+}
+
+// WithTimeout 设置 Process 等待结果的超时时间（默认 5 秒，最小 1 毫秒，按毫秒向下取整）
+// 处理块异常时，Process 最多阻塞该时长后返回错误
+func WithTimeout(timeout time.Duration) ProcessingOption {
+	return func(c *processingConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithLatencyBudget 设置单帧处理耗时预算
+// 处理耗时超过 budget 时调用 onSlow（在调用 Process 的 goroutine 中同步执行），
+// 包括等待结果超时的情况
+func WithLatencyBudget(budget time.Duration, onSlow func(block string, elapsed, budget time.Duration)) ProcessingOption {
+	return func(c *processingConfig) {
+		c.budget = budget
+		c.onSlow = onSlow
+	}
+}
+
+// newProcessingConfig 合并默认值与用户选项，并校验参数
+func newProcessingConfig(opts []ProcessingOption) (processingConfig, error) {
+	cfg := processingConfig{
+		queueSize: defaultQueueSize,
+		timeout:   defaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.queueSize <= 0 {
+		return cfg, fmt.Errorf("invalid queue size %d", cfg.queueSize)
+	}
+	// librealsense 的等待以毫秒为单位，不足 1 毫秒会截断为 0（不等待）
+	if cfg.timeout < time.Millisecond {
+		return cfg, fmt.Errorf("invalid timeout %v (minimum 1ms)", cfg.timeout)
+	}
+	return cfg, nil
+}
+
+// processingBlock 是所有基于队列的处理块的公共实现
+// Align、Colorizer、Filter 均内嵌此结构
+type processingBlock struct {
+	ptr   *C.rs2_processing_block
+	queue *C.rs2_frame_queue
+	name  string
+	cfg   processingConfig
+}
+
+// newProcessingBlock 为处理块创建输出队列并启动处理
+// 出错时会释放传入的 ptr
+func newProcessingBlock(ptr *C.rs2_processing_block, name string, opts []ProcessingOption) (processingBlock, error) {
+	cfg, goErr := newProcessingConfig(opts)
+	if goErr != nil {
+		C.rs2_delete_processing_block(ptr)
+		return processingBlock{}, goErr
+	}
+
+	var err *C.rs2_error
+
+	// 创建帧队列用于接收处理结果
+	queue := C.rs2_create_frame_queue(C.int(cfg.queueSize), &err)
+	if err != nil {
+		C.rs2_delete_processing_block(ptr)
+		return processingBlock{}, errorFromC(err)
+	}
+
+	// 启动处理块，将结果输出到队列
+	C.rs2_start_processing_queue(ptr, queue, &err)
+	if err != nil {
+		C.rs2_delete_processing_block(ptr)
+		C.rs2_delete_frame_queue(queue)
+		return processingBlock{}, errorFromC(err)
+	}
+
+	block := processingBlock{ptr: ptr, queue: queue, name: name, cfg: cfg}
+
+	// 应用类型化参数，任何参数非法都视为创建失败
+	for _, settings := range cfg.settings {
+		if goErr := settings.applyTo(&block); goErr != nil {
+			block.close()
+			return processingBlock{}, goErr
+		}
+	}
+	return block, nil
+}
+
+// submit 将帧送入处理块
+// 会增加输入帧的引用计数，因为 rs2_process_frame 会消耗一个引用
+// 如果不增加，Go 层的 Close() 会导致 double free
+func (b *processingBlock) submit(frame *C.rs2_frame) error {
+	var err *C.rs2_error
+
+	C.rs2_frame_add_ref(frame, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+
+	C.rs2_process_frame(b.ptr, frame, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// drain 释放输出队列中残留的结果
+// tryProcess 未就绪或 process 超时后迟到的结果属于更早的输入帧，提交新帧前必须丢弃，
+// 否则之后每次取到的都是上一帧的结果，对齐和滤波链会错配深度与彩色
+func (b *processingBlock) drain() error {
+	for {
+		var err *C.rs2_error
+		var stale *C.rs2_frame
+		ok := C.rs2_poll_for_frame(b.queue, &stale, &err)
+		if err != nil {
+			return errorFromC(err)
+		}
+		if ok == 0 {
+			return nil
+		}
+		C.rs2_release_frame(stale)
+	}
+}
+
+// process 处理一帧并等待结果，最多等待 cfg.timeout
+func (b *processingBlock) process(frame *C.rs2_frame) (*C.rs2_frame, error) {
+	start := time.Now()
+
+	if e := b.drain(); e != nil {
+		return nil, e
+	}
+	if e := b.submit(frame); e != nil {
+		return nil, e
+	}
+
+	var err *C.rs2_error
+	var result *C.rs2_frame
+	timeoutMs := C.uint(b.cfg.timeout / time.Millisecond)
+	ok := C.rs2_try_wait_for_frame(b.queue, timeoutMs, &result, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	if ok == 0 {
+		// 超时同样超出预算，通知调用者后再返回错误
+		b.checkBudget(time.Since(start))
+		return nil, fmt.Errorf("%s: no result within %v", b.name, b.cfg.timeout)
+	}
+
+	b.checkBudget(time.Since(start))
+	return result, nil
+}
+
+// tryProcess 处理一帧并立即返回，结果尚未就绪时返回 nil
+// 未取走的结果在下一次 tryProcess/process 提交前被丢弃，返回的结果总是对应本次输入
+func (b *processingBlock) tryProcess(frame *C.rs2_frame) (*C.rs2_frame, error) {
+	start := time.Now()
+
+	if e := b.drain(); e != nil {
+		return nil, e
+	}
+	if e := b.submit(frame); e != nil {
+		return nil, e
+	}
+
+	var err *C.rs2_error
+	var result *C.rs2_frame
+	ok := C.rs2_poll_for_frame(b.queue, &result, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	if ok == 0 {
+		return nil, nil
+	}
+
+	b.checkBudget(time.Since(start))
+	return result, nil
+}
+
+// checkBudget 处理耗时超出预算时通知调用者
+func (b *processingBlock) checkBudget(elapsed time.Duration) {
+	if b.cfg.budget > 0 && b.cfg.onSlow != nil && elapsed > b.cfg.budget {
+		b.cfg.onSlow(b.name, elapsed, b.cfg.budget)
+	}
+}
+
+// OptionRange 描述选项的取值范围
+type OptionRange struct {
+	Min     float32 `json:"min"`
+	Max     float32 `json:"max"`
+	Step    float32 `json:"step"`
+	Default float32 `json:"default"`
+}
+
+// options 将处理块转换为 options 接口
+// processing block 也是一种 options interface，直接转换为 options 指针
+func (b *processingBlock) options() *C.rs2_options {
+	return (*C.rs2_options)(unsafe.Pointer(b.ptr))
+}
+
+// optionRange 查询选项的取值范围，不支持的选项返回错误
+func (b *processingBlock) optionRange(option int) (OptionRange, error) {
+	var err *C.rs2_error
+	opts := b.options()
+
+	if C.rs2_supports_option(opts, C.rs2_option(option), &err) == 0 {
+		if err != nil {
+			return OptionRange{}, errorFromC(err)
+		}
+		return OptionRange{}, fmt.Errorf("%s: option %s not supported", b.name, optionName(option))
+	}
+
+	var min, max, step, def C.float
+	C.rs2_get_option_range(opts, C.rs2_option(option), &min, &max, &step, &def, &err)
+	if err != nil {
+		return OptionRange{}, errorFromC(err)
+	}
+	return OptionRange{Min: float32(min), Max: float32(max), Step: float32(step), Default: float32(def)}, nil
+}
+
+// setOption 设置处理块参数
+// 不支持的选项或超出范围的值返回错误，而不是静默忽略
+func (b *processingBlock) setOption(option int, value float32) error {
+	r, goErr := b.optionRange(option)
+	if goErr != nil {
+		return goErr
+	}
+	if value < r.Min || value > r.Max {
+		return fmt.Errorf("%s: option %s value %g out of range [%g, %g]",
+			b.name, optionName(option), value, r.Min, r.Max)
+	}
+
+	var err *C.rs2_error
+	C.rs2_set_option(b.options(), C.rs2_option(option), C.float(value), &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// getOption 读取处理块参数，不支持的选项返回错误
+func (b *processingBlock) getOption(option int) (float32, error) {
+	var err *C.rs2_error
+	opts := b.options()
+
+	if C.rs2_supports_option(opts, C.rs2_option(option), &err) == 0 {
+		if err != nil {
+			return 0, errorFromC(err)
+		}
+		return 0, fmt.Errorf("%s: option %s not supported", b.name, optionName(option))
+	}
+
+	val := C.rs2_get_option(opts, C.rs2_option(option), &err)
+	if err != nil {
+		return 0, errorFromC(err)
+	}
+	return float32(val), nil
+}
+
+// optionName 返回选项的可读名称，用于错误信息
+func optionName(option int) string {
+	return C.GoString(C.rs2_option_to_string(C.rs2_option(option)))
+}
+
+// close 释放处理块和队列
+func (b *processingBlock) close() {
+	if b.ptr != nil {
+		C.rs2_delete_processing_block(b.ptr)
+		b.ptr = nil
+	}
+	if b.queue != nil {
+		C.rs2_delete_frame_queue(b.queue)
+		b.queue = nil
+	}
+}
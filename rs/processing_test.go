@@ -0,0 +1,67 @@
+//go:build librealsense
+
+// 需要链接真实的 librealsense，运行方式：go test -tags librealsense ./rs
+
+package rs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProcessingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ProcessingOption
+		timeout time.Duration
+		wantErr string // 空表示有效
+	}{
+		{"default", nil, defaultTimeout, ""},
+		{"1ms", []ProcessingOption{WithTimeout(time.Millisecond)}, time.Millisecond, ""},
+		// 不足 1 毫秒会被 librealsense 截断为不等待
+		{"sub-millisecond", []ProcessingOption{WithTimeout(500 * time.Microsecond)}, 0, "minimum 1ms"},
+		{"zero", []ProcessingOption{WithTimeout(0)}, 0, "invalid timeout"},
+		{"negative", []ProcessingOption{WithTimeout(-time.Second)}, 0, "invalid timeout"},
+		{"queue size", []ProcessingOption{WithQueueSize(0)}, 0, "invalid queue size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newProcessingConfig(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.timeout != tt.timeout {
+				t.Errorf("timeout %v, want %v", cfg.timeout, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	var calls []time.Duration
+	b := &processingBlock{name: "test", cfg: processingConfig{
+		budget: 10 * time.Millisecond,
+		onSlow: func(block string, elapsed, budget time.Duration) {
+			if block != "test" || budget != 10*time.Millisecond {
+				t.Errorf("onSlow(%q, %v, %v)", block, elapsed, budget)
+			}
+			calls = append(calls, elapsed)
+		},
+	}}
+	b.checkBudget(5 * time.Millisecond)
+	b.checkBudget(10 * time.Millisecond) // 等于预算不算超出
+	b.checkBudget(15 * time.Millisecond)
+	if len(calls) != 1 || calls[0] != 15*time.Millisecond {
+		t.Errorf("onSlow calls %v, want [15ms]", calls)
+	}
+
+	b.cfg.onSlow = nil
+	b.checkBudget(time.Second) // 未设置回调时不调用
+}
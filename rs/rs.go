@@ -36,7 +36,11 @@ type Device struct {
 
 // Sensor 封装了 rs2_sensor 结构
 type Sensor struct {
-	ptr *C.rs2_sensor
+	ptr       *C.rs2_sensor
+	notifyID  uintptr                    // 通知回调在注册表中的 id，0 表示未订阅
+	profiles  *C.rs2_stream_profile_list // StreamProfiles 返回的句柄所属的列表
+	opened    bool                       // 是否已通过 Open 打开流
+	frameCbID uintptr                    // Start 注册的帧回调 id，0 表示未启动
 }
 
 // StreamType 映射 C 的流类型
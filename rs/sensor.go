@@ -6,20 +6,33 @@ package rs
 #include <stdlib.h>
 */
 import "C"
-import "unsafe"
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
 
 // 传感器选项常量
 const (
-	OptionExposure             = C.RS2_OPTION_EXPOSURE
-	OptionGain                 = C.RS2_OPTION_GAIN
-	OptionLaserPower           = C.RS2_OPTION_LASER_POWER
-	OptionEnableAutoExposure   = C.RS2_OPTION_ENABLE_AUTO_EXPOSURE
-	OptionFilterMagnitude      = C.RS2_OPTION_FILTER_MAGNITUDE
-	OptionHolesFill            = C.RS2_OPTION_HOLES_FILL
-	OptionVisualPreset         = C.RS2_OPTION_VISUAL_PRESET
-	OptionAsicTemperature      = C.RS2_OPTION_ASIC_TEMPERATURE
-	OptionProjectorTemperature = C.RS2_OPTION_PROJECTOR_TEMPERATURE
-	OptionInterCamSyncMode     = C.RS2_OPTION_INTER_CAM_SYNC_MODE
+	OptionExposure              = C.RS2_OPTION_EXPOSURE
+	OptionGain                  = C.RS2_OPTION_GAIN
+	OptionLaserPower            = C.RS2_OPTION_LASER_POWER
+	OptionEnableAutoExposure    = C.RS2_OPTION_ENABLE_AUTO_EXPOSURE
+	OptionFilterMagnitude       = C.RS2_OPTION_FILTER_MAGNITUDE
+	OptionFilterSmoothAlpha     = C.RS2_OPTION_FILTER_SMOOTH_ALPHA
+	OptionFilterSmoothDelta     = C.RS2_OPTION_FILTER_SMOOTH_DELTA
+	OptionFilterOption          = C.RS2_OPTION_FILTER_OPTION
+	OptionHolesFill             = C.RS2_OPTION_HOLES_FILL
+	OptionMinDistance           = C.RS2_OPTION_MIN_DISTANCE
+	OptionMaxDistance           = C.RS2_OPTION_MAX_DISTANCE
+	OptionSequenceID            = C.RS2_OPTION_SEQUENCE_ID
+	OptionColorScheme           = C.RS2_OPTION_COLOR_SCHEME
+	OptionHistogramEqualization = C.RS2_OPTION_HISTOGRAM_EQUALIZATION_ENABLED
+	OptionDepthUnits            = C.RS2_OPTION_DEPTH_UNITS
+	OptionVisualPreset          = C.RS2_OPTION_VISUAL_PRESET
+	OptionAsicTemperature       = C.RS2_OPTION_ASIC_TEMPERATURE
+	OptionProjectorTemperature  = C.RS2_OPTION_PROJECTOR_TEMPERATURE
+	OptionInterCamSyncMode      = C.RS2_OPTION_INTER_CAM_SYNC_MODE
 )
 
 // VisualPreset 定义 D400 系列相机的视觉预设模式
@@ -35,6 +48,25 @@ const (
 	VisualPresetRemoveIRPattern VisualPreset = C.RS2_RS400_VISUAL_PRESET_REMOVE_IR_PATTERN
 )
 
+// presetNames 配置文件和 C 接口中可用的预设名称
+var presetNames = map[string]VisualPreset{
+	"custom":            VisualPresetCustom,
+	"default":           VisualPresetDefault,
+	"hand":              VisualPresetHand,
+	"high_accuracy":     VisualPresetHighAccuracy,
+	"high_density":      VisualPresetHighDensity,
+	"medium_density":    VisualPresetMediumDensity,
+	"remove_ir_pattern": VisualPresetRemoveIRPattern,
+}
+
+// ParseVisualPreset 将名称（如 "high_accuracy"，不区分大小写）转换为 VisualPreset
+func ParseVisualPreset(name string) (VisualPreset, error) {
+	if p, ok := presetNames[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("unknown visual preset %q", name)
+}
+
 // SetVisualPreset 设置视觉预设模式
 func (s *Sensor) SetVisualPreset(preset VisualPreset) error {
 	return s.SetOption(OptionVisualPreset, float32(preset))
@@ -103,7 +135,15 @@ func (s *Sensor) GetOption(option int) (float32, error) {
 }
 
 // Close 释放传感器资源
+// 如果传感器仍在出帧，会先停止并关闭已打开的流
 func (s *Sensor) Close() {
+	s.Stop()
+	s.closeStreams()
+	s.UnsubscribeNotifications()
+	if s.profiles != nil {
+		C.rs2_delete_stream_profiles_list(s.profiles)
+		s.profiles = nil
+	}
 	if s.ptr != nil {
 		C.rs2_delete_sensor(s.ptr)
 		s.ptr = nil
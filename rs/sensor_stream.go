@@ -0,0 +1,272 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_sensor.h>
+#include <librealsense2/h/rs_frame.h>
+
+extern void goSensorFrameCallback(rs2_frame* frame, void* user);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// SensorProfile 是传感器上某个流配置的句柄
+// 句柄由 Sensor 持有的 profile 列表管理，在 Sensor.Close 之前有效
+type SensorProfile struct {
+	ptr *C.rs2_stream_profile
+}
+
+// getProfileData 读取 profile 的基础参数
+func getProfileData(ptr *C.rs2_stream_profile) (StreamType, Format, int, int, int, error) {
+	var err *C.rs2_error
+	var stream C.rs2_stream
+	var format C.rs2_format
+	var index, uniqueID, fps C.int
+
+	C.rs2_get_stream_profile_data(ptr, &stream, &format, &index, &uniqueID, &fps, &err)
+	if err != nil {
+		return 0, 0, 0, 0, 0, errorFromC(err)
+	}
+	return StreamType(stream), Format(format), int(index), int(uniqueID), int(fps), nil
+}
+
+// Stream 返回 profile 的流类型
+func (p *SensorProfile) Stream() StreamType {
+	stream, _, _, _, _, err := getProfileData(p.ptr)
+	if err != nil {
+		return StreamAny
+	}
+	return stream
+}
+
+// Format 返回 profile 的像素格式
+func (p *SensorProfile) Format() Format {
+	_, format, _, _, _, err := getProfileData(p.ptr)
+	if err != nil {
+		return FormatAny
+	}
+	return format
+}
+
+// Index 返回流索引（例如红外左右目分别为 1 和 2）
+func (p *SensorProfile) Index() int {
+	_, _, index, _, _, err := getProfileData(p.ptr)
+	if err != nil {
+		return 0
+	}
+	return index
+}
+
+// FPS 返回 profile 的帧率
+func (p *SensorProfile) FPS() int {
+	_, _, _, _, fps, err := getProfileData(p.ptr)
+	if err != nil {
+		return 0
+	}
+	return fps
+}
+
+// Resolution 返回视频流的分辨率，非视频流返回错误
+func (p *SensorProfile) Resolution() (int, int, error) {
+	var err *C.rs2_error
+	var width, height C.int
+	C.rs2_get_video_stream_resolution(p.ptr, &width, &height, &err)
+	if err != nil {
+		return 0, 0, errorFromC(err)
+	}
+	return int(width), int(height), nil
+}
+
+// Info 返回 JSON 友好的 profile 描述，与 GetCapabilities 的输出一致
+func (p *SensorProfile) Info() (StreamProfile, error) {
+	stream, format, _, _, fps, err := getProfileData(p.ptr)
+	if err != nil {
+		return StreamProfile{}, err
+	}
+	width, height, err := p.Resolution()
+	if err != nil {
+		return StreamProfile{}, err
+	}
+
+	var cerr *C.rs2_error
+	isDefault := C.rs2_is_stream_profile_default(p.ptr, &cerr)
+	if cerr != nil {
+		C.rs2_free_error(cerr)
+		isDefault = 0
+	}
+
+	return StreamProfile{
+		Stream:    C.GoString(C.rs2_stream_to_string(C.rs2_stream(stream))),
+		Format:    C.GoString(C.rs2_format_to_string(C.rs2_format(format))),
+		Width:     width,
+		Height:    height,
+		FPS:       fps,
+		IsDefault: isDefault != 0,
+	}, nil
+}
+
+// StreamProfiles 返回传感器支持的所有流配置句柄
+// 句柄在 Sensor.Close 之前有效，可直接传给 Sensor.Open
+func (s *Sensor) StreamProfiles() ([]*SensorProfile, error) {
+	var err *C.rs2_error
+
+	if s.profiles == nil {
+		list := C.rs2_get_stream_profiles(s.ptr, &err)
+		if err != nil {
+			return nil, errorFromC(err)
+		}
+		s.profiles = list
+	}
+
+	count := int(C.rs2_get_stream_profiles_count(s.profiles, &err))
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+
+	profiles := make([]*SensorProfile, 0, count)
+	for i := 0; i < count; i++ {
+		ptr := C.rs2_get_stream_profile(s.profiles, C.int(i), &err)
+		if err != nil {
+			return nil, errorFromC(err)
+		}
+		profiles = append(profiles, &SensorProfile{ptr: ptr})
+	}
+	return profiles, nil
+}
+
+// FindProfile 查找匹配的流配置句柄
+// width/height/fps 为 0 或 format 为 FormatAny 时表示不限制该项
+func (s *Sensor) FindProfile(stream StreamType, index, width, height, fps int, format Format) (*SensorProfile, error) {
+	profiles, err := s.StreamProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range profiles {
+		pStream, pFormat, pIndex, _, pFPS, err := getProfileData(p.ptr)
+		if err != nil {
+			continue
+		}
+		if pStream != stream || (index != 0 && pIndex != index) {
+			continue
+		}
+		if (format != FormatAny && pFormat != format) || (fps != 0 && pFPS != fps) {
+			continue
+		}
+		if width != 0 || height != 0 {
+			w, h, err := p.Resolution()
+			if err != nil || (width != 0 && w != width) || (height != 0 && h != height) {
+				continue
+			}
+		}
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("no matching profile for stream %v (%dx%d@%d)", stream, width, height, fps)
+}
+
+// Open 以指定的流配置打开传感器
+// 打开后调用 Start 开始出帧，使用完毕后依次调用 Stop 和 Close
+func (s *Sensor) Open(profiles ...*SensorProfile) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no stream profile specified")
+	}
+	if s.opened {
+		return fmt.Errorf("sensor already opened")
+	}
+
+	ptrs := make([]*C.rs2_stream_profile, len(profiles))
+	for i, p := range profiles {
+		ptrs[i] = p.ptr
+	}
+
+	var err *C.rs2_error
+	C.rs2_open_multiple(s.ptr, &ptrs[0], C.int(len(ptrs)), &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+
+	s.opened = true
+	return nil
+}
+
+// sensorFrameHandler 保存 Start 注册的 Go 回调
+type sensorFrameHandler struct {
+	fn func(*Frame)
+}
+
+//export goSensorFrameCallback
+func goSensorFrameCallback(frame *C.rs2_frame, user unsafe.Pointer) {
+	handler, ok := lookupCallback(uintptr(user)).(*sensorFrameHandler)
+	if !ok {
+		C.rs2_release_frame(frame)
+		return
+	}
+
+	// 回调结束后释放帧，如需保留请在回调中调用 Frame.Clone
+	f := &Frame{ptr: frame}
+	defer f.Close()
+	handler.fn(f)
+}
+
+// Start 开始出帧，每一帧都会在 librealsense 的线程中调用 callback
+// callback 中的 Frame 仅在回调期间有效，如需跨回调使用请调用 Frame.Clone
+// 注意：callback 应尽快返回，否则会阻塞驱动线程导致掉帧
+func (s *Sensor) Start(callback func(*Frame)) error {
+	if !s.opened {
+		return fmt.Errorf("sensor not opened")
+	}
+	if s.frameCbID != 0 {
+		return fmt.Errorf("sensor already started")
+	}
+	if callback == nil {
+		return fmt.Errorf("nil frame callback")
+	}
+
+	id := registerCallback(&sensorFrameHandler{fn: callback})
+
+	var err *C.rs2_error
+	C.rs2_start(s.ptr, C.rs2_frame_callback_ptr(C.goSensorFrameCallback), callbackUserData(id), &err)
+	if err != nil {
+		unregisterCallback(id)
+		return errorFromC(err)
+	}
+
+	s.frameCbID = id
+	return nil
+}
+
+// Stop 停止出帧，传感器保持打开状态，可以再次调用 Start
+func (s *Sensor) Stop() error {
+	if s.frameCbID == 0 {
+		return nil
+	}
+
+	var err *C.rs2_error
+	C.rs2_stop(s.ptr, &err)
+	// 无论是否出错都注销回调，避免停止后仍处理帧
+	unregisterCallback(s.frameCbID)
+	s.frameCbID = 0
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// closeStreams 释放 Open 占用的流（rs2_close）
+func (s *Sensor) closeStreams() error {
+	if !s.opened {
+		return nil
+	}
+
+	var err *C.rs2_error
+	C.rs2_close(s.ptr, &err)
+	s.opened = false
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_internal.h>
+#include <stdlib.h>
+#include <string.h>
+
+// rs_software_free 是软件帧像素内存的释放函数，librealsense 在帧释放时调用
+static void rs_software_free(void* pixels) { free(pixels); }
+
+// rs_software_push_video 组装 rs2_software_video_frame 并推送
+// pixels 必须由 malloc 分配，所有权交给 librealsense
+static void rs_software_push_video(rs2_sensor* sensor, void* pixels, int stride, int bpp,
+	double timestamp, rs2_timestamp_domain domain, int frame_number,
+	const rs2_stream_profile* profile, float depth_units, rs2_error** error)
+{
+	rs2_software_video_frame frame;
+	memset(&frame, 0, sizeof(frame));
+	frame.pixels = pixels;
+	frame.deleter = rs_software_free;
+	frame.stride = stride;
+	frame.bpp = bpp;
+	frame.timestamp = timestamp;
+	frame.domain = domain;
+	frame.frame_number = frame_number;
+	frame.profile = profile;
+	frame.depth_units = depth_units;
+	rs2_software_sensor_on_video_frame(sensor, frame, error);
+}
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// TimestampDomain 映射 rs2_timestamp_domain
+type TimestampDomain int
+
+const (
+	TimestampDomainHardwareClock TimestampDomain = C.RS2_TIMESTAMP_DOMAIN_HARDWARE_CLOCK
+	TimestampDomainSystemTime    TimestampDomain = C.RS2_TIMESTAMP_DOMAIN_SYSTEM_TIME
+	TimestampDomainGlobalTime    TimestampDomain = C.RS2_TIMESTAMP_DOMAIN_GLOBAL_TIME
+)
+
+// Matcher 映射 rs2_matchers，决定软件设备上的 Syncer 如何组合帧
+type Matcher int
+
+const (
+	MatcherDI       Matcher = C.RS2_MATCHER_DI      // 深度 + 红外
+	MatcherDIColor  Matcher = C.RS2_MATCHER_DI_C    // 深度 + 红外 + 彩色
+	MatcherDLRColor Matcher = C.RS2_MATCHER_DLR_C   // 深度 + 左右红外 + 彩色
+	MatcherDLR      Matcher = C.RS2_MATCHER_DLR     // 深度 + 左右红外
+	MatcherDefault  Matcher = C.RS2_MATCHER_DEFAULT // 按时间戳匹配
+)
+
+// VideoStream 描述软件传感器上的一个视频流
+type VideoStream struct {
+	Type       StreamType
+	Index      int
+	UID        int // 流的唯一 id，同一设备内不能重复
+	Width      int
+	Height     int
+	FPS        int
+	BPP        int // 每像素字节数，例如 Z16 为 2、RGB8 为 3
+	Format     Format
+	Intrinsics Intrinsics
+}
+
+// SoftwareDevice 是一个虚拟的 RealSense 设备
+// 可以把文件、仿真器或其它相机的数据以帧的形式注入，
+// 再交给 Align、Filter、Colorizer 等处理块，无需真实相机
+type SoftwareDevice struct {
+	*Device
+	sensors []*SoftwareSensor
+}
+
+// SoftwareSensor 是软件设备上的传感器，用于添加流并推送帧
+// 它同时是一个普通的 Sensor，可以 Open/Start 后配合 Syncer 使用
+type SoftwareSensor struct {
+	*Sensor
+}
+
+// SoftwareVideoFrame 是要推送的一帧视频数据
+type SoftwareVideoFrame struct {
+	Pixels      []byte
+	Stride      int // 每行字节数，0 表示 Width*BPP
+	BPP         int // 每像素字节数，0 表示使用流定义的 BPP
+	Timestamp   float64
+	Domain      TimestampDomain
+	FrameNumber int
+	DepthUnits  float32 // 深度单位（米），仅深度帧使用，0 表示 depth.DefaultDepthScale
+}
+
+// NewSoftwareDevice 创建软件设备
+func NewSoftwareDevice() (*SoftwareDevice, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_software_device(&err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	return &SoftwareDevice{Device: &Device{ptr: ptr}}, nil
+}
+
+// RegisterInfo 注册设备信息（例如名称、序列号），可通过 GetInfo 读取
+func (d *SoftwareDevice) RegisterInfo(info CameraInfo, value string) error {
+	cValue := C.CString(value)
+	defer C.free(unsafe.Pointer(cValue))
+
+	var err *C.rs2_error
+	C.rs2_software_device_register_info(d.ptr, C.rs2_camera_info(info), cValue, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// AddSensor 添加一个软件传感器
+// 返回的传感器归设备所有，在 SoftwareDevice.Close 时释放
+func (d *SoftwareDevice) AddSensor(name string) (*SoftwareSensor, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var err *C.rs2_error
+	ptr := C.rs2_software_device_add_sensor(d.ptr, cName, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+
+	s := &SoftwareSensor{Sensor: &Sensor{ptr: ptr}}
+	d.sensors = append(d.sensors, s)
+	return s, nil
+}
+
+// CreateMatcher 设置帧匹配方式，配合 Syncer 组合帧集时使用
+func (d *SoftwareDevice) CreateMatcher(m Matcher) error {
+	var err *C.rs2_error
+	C.rs2_software_device_create_matcher(d.ptr, C.rs2_matchers(m), &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// Close 释放设备及其所有软件传感器
+func (d *SoftwareDevice) Close() {
+	for _, s := range d.sensors {
+		s.Close()
+	}
+	d.sensors = nil
+	d.Device.Close()
+}
+
+// AddVideoStream 为传感器添加视频流，返回的 profile 可用于 Open 和推送帧
+// isDefault 表示是否为该传感器的默认流配置
+func (s *SoftwareSensor) AddVideoStream(vs VideoStream, isDefault bool) (*SensorProfile, error) {
+	if vs.Width <= 0 || vs.Height <= 0 || vs.BPP <= 0 {
+		return nil, fmt.Errorf("invalid video stream %dx%d bpp %d", vs.Width, vs.Height, vs.BPP)
+	}
+	if vs.Intrinsics.Width == 0 && vs.Intrinsics.Height == 0 {
+		vs.Intrinsics.Width, vs.Intrinsics.Height = vs.Width, vs.Height
+	}
+
+	stream := C.rs2_video_stream{
+		_type:      C.rs2_stream(vs.Type),
+		index:      C.int(vs.Index),
+		uid:        C.int(vs.UID),
+		width:      C.int(vs.Width),
+		height:     C.int(vs.Height),
+		fps:        C.int(vs.FPS),
+		bpp:        C.int(vs.BPP),
+		fmt:        C.rs2_format(vs.Format),
+		intrinsics: vs.Intrinsics.toC(),
+	}
+
+	var def C.int
+	if isDefault {
+		def = 1
+	}
+
+	var err *C.rs2_error
+	ptr := C.rs2_software_sensor_add_video_stream_ex(s.ptr, stream, def, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	return &SensorProfile{ptr: ptr}, nil
+}
+
+// AddReadOnlyOption 添加只读选项，例如深度传感器的 OptionDepthUnits
+func (s *SoftwareSensor) AddReadOnlyOption(option int, value float32) error {
+	var err *C.rs2_error
+	C.rs2_software_sensor_add_read_only_option(s.ptr, C.rs2_option(option), C.float(value), &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// PushVideoFrame 推送一帧视频数据
+// 数据会被拷贝到 C 内存中，调用返回后即可复用 frame.Pixels
+// 传感器需要先 Open 并 Start，帧才会被送到回调
+func (s *SoftwareSensor) PushVideoFrame(profile *SensorProfile, frame SoftwareVideoFrame) error {
+	width, height, goErr := profile.Resolution()
+	if goErr != nil {
+		return goErr
+	}
+
+	bpp := frame.BPP
+	if bpp == 0 {
+		bpp = formatBytesPerPixel(profile.Format())
+	}
+	stride := frame.Stride
+	if stride == 0 {
+		stride = width * bpp
+	}
+	if bpp <= 0 || stride < width*bpp {
+		return fmt.Errorf("invalid frame layout: stride %d, bpp %d, width %d", stride, bpp, width)
+	}
+	size := stride * height
+	if len(frame.Pixels) < size {
+		return fmt.Errorf("frame buffer too small: %d < %d", len(frame.Pixels), size)
+	}
+
+	units := frame.DepthUnits
+	if units == 0 {
+		units = depth.DefaultDepthScale
+	}
+
+	// 推送成功后像素内存交给 librealsense，帧释放时由 rs_software_free 回收
+	pixels := C.malloc(C.size_t(size))
+	if pixels == nil {
+		return fmt.Errorf("failed to allocate %d bytes for software frame", size)
+	}
+	C.memcpy(pixels, unsafe.Pointer(&frame.Pixels[0]), C.size_t(size))
+
+	var err *C.rs2_error
+	C.rs_software_push_video(s.ptr, pixels, C.int(stride), C.int(bpp),
+		C.double(frame.Timestamp), C.rs2_timestamp_domain(frame.Domain), C.int(frame.FrameNumber),
+		profile.ptr, C.float(units), &err)
+	if err != nil {
+		// 推送失败时帧未创建，librealsense 不会调用 rs_software_free，像素内存由这里回收
+		C.free(pixels)
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// PushDepthFrame 推送一帧 Z16 深度数据的便捷方法
+func (s *SoftwareSensor) PushDepthFrame(profile *SensorProfile, data []uint16, timestamp float64, frameNumber int, depthUnits float32) error {
+	if len(data) == 0 {
+		return fmt.Errorf("empty depth buffer")
+	}
+	pixels := unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), len(data)*2)
+	return s.PushVideoFrame(profile, SoftwareVideoFrame{
+		Pixels:      pixels,
+		BPP:         2,
+		Timestamp:   timestamp,
+		Domain:      TimestampDomainHardwareClock,
+		FrameNumber: frameNumber,
+		DepthUnits:  depthUnits,
+	})
+}
+
+// RegisterExtrinsics 注册两个流之间的外参，Align 需要用到
+func RegisterExtrinsics(from, to *SensorProfile, ex Extrinsics) error {
+	var cex C.rs2_extrinsics
+	for i, v := range ex.Rotation {
+		cex.rotation[i] = C.float(v)
+	}
+	for i, v := range ex.Translation {
+		cex.translation[i] = C.float(v)
+	}
+
+	var err *C.rs2_error
+	C.rs2_register_extrinsics(from.ptr, to.ptr, cex, &err)
+	if err != nil {
+		return errorFromC(err)
+	}
+	return nil
+}
+
+// formatBytesPerPixel 返回常见格式的每像素字节数，未知格式返回 0
+func formatBytesPerPixel(f Format) int {
+	switch f {
+	case FormatZ16, FormatY16:
+		return 2
+	case FormatRGB8, FormatBGR8:
+		return 3
+	case FormatRGBA8, FormatBGRA8:
+		return 4
+	case FormatY8:
+		return 1
+	}
+	return 0
+}
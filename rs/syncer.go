@@ -0,0 +1,65 @@
+package rs
+
+/*
+#include <librealsense2/rs.h>
+#include <librealsense2/h/rs_processing.h>
+*/
+import "C"
+
+// Syncer 封装了 librealsense 的同步处理块
+// 用于在不使用 Pipeline 时，将多个传感器各自输出的帧按时间戳重新组合为 FrameSet
+type Syncer struct {
+	processingBlock
+}
+
+// NewSyncer 创建同步器
+// 输出队列容量建议通过 WithQueueSize 设为传感器数量
+func NewSyncer(opts ...ProcessingOption) (*Syncer, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_create_sync_processing_block(&err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+
+	block, goErr := newProcessingBlock(ptr, "syncer", opts)
+	if goErr != nil {
+		return nil, goErr
+	}
+	return &Syncer{processingBlock: block}, nil
+}
+
+// Submit 将单帧送入同步器，可直接在 Sensor.Start 的回调中调用
+// 会增加输入帧的引用计数，原帧仍由调用者释放
+func (s *Syncer) Submit(frame *Frame) error {
+	return s.submit(frame.ptr)
+}
+
+// WaitForFrames 等待下一组同步后的帧集
+// timeout 为等待时间（毫秒）
+func (s *Syncer) WaitForFrames(timeout uint) (*FrameSet, error) {
+	var err *C.rs2_error
+	ptr := C.rs2_wait_for_frame(s.queue, C.uint(timeout), &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	return &FrameSet{ptr: ptr}, nil
+}
+
+// PollForFrames 非阻塞地获取同步后的帧集，没有可用数据时返回 nil
+func (s *Syncer) PollForFrames() (*FrameSet, error) {
+	var err *C.rs2_error
+	var ptr *C.rs2_frame
+	ok := C.rs2_poll_for_frame(s.queue, &ptr, &err)
+	if err != nil {
+		return nil, errorFromC(err)
+	}
+	if ok == 0 {
+		return nil, nil
+	}
+	return &FrameSet{ptr: ptr}, nil
+}
+
+// Close 释放同步器资源
+func (s *Syncer) Close() {
+	s.close()
+}
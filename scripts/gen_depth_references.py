@@ -0,0 +1,167 @@
+#!/usr/bin/env python3
+"""用 librealsense (pyrealsense2) 生成 depth 包的对照参考输出。
+
+读取 depth/testdata/scene_<i>.png（16 位灰度，Z16），通过 software_device 注入为深度帧，
+经 librealsense 的过滤器处理后写入 depth/testdata/librealsense/<case>.png，
+供 depth/reference_test.go 中的 TestLibrealsenseReference 按容差比较。
+
+依赖：pyrealsense2、numpy、Pillow。用法（在仓库根目录）：
+
+    python3 scripts/gen_depth_references.py
+    go test ./depth -run TestLibrealsenseReference -v
+
+CASES 与 reference_test.go 中的 referenceCases 一一对应，修改时需同步。
+"""
+
+import os
+import sys
+
+import numpy as np
+import pyrealsense2 as rs
+from PIL import Image
+
+ROOT = os.path.dirname(os.path.dirname(os.path.abspath(__file__)))
+TESTDATA = os.path.join(ROOT, "depth", "testdata")
+OUT_DIR = os.path.join(TESTDATA, "librealsense")
+
+SCENE_FRAMES = 3  # 与 golden_test.go 中的 sceneFrames 一致
+DEPTH_UNITS = 0.001
+FPS = 30
+
+
+def read_png16(path):
+    """读取 16 位灰度 PNG，返回 uint16 数组。"""
+    img = Image.open(path)
+    if img.mode not in ("I;16", "I;16B", "I"):
+        sys.exit(f"{path}: mode {img.mode}, want 16-bit grayscale")
+    return np.asarray(img, dtype=np.uint16)
+
+
+def write_png16(path, data):
+    """将 uint16 数组写为 16 位灰度 PNG。"""
+    Image.fromarray(np.ascontiguousarray(data, dtype=np.uint16), mode="I;16").save(path)
+
+
+class DepthSource:
+    """用 software_device 将 numpy 深度图注入为 librealsense 深度帧。"""
+
+    def __init__(self, width, height):
+        self.width, self.height = width, height
+        self.device = rs.software_device()
+        sensor = self.device.add_sensor("Depth")
+
+        intr = rs.intrinsics()
+        intr.width, intr.height = width, height
+        intr.ppx, intr.ppy = width / 2, height / 2
+        intr.fx = intr.fy = float(width)
+        intr.model = rs.distortion.none
+
+        vs = rs.video_stream()
+        vs.type = rs.stream.depth
+        vs.index = 0
+        vs.uid = 0
+        vs.width, vs.height = width, height
+        vs.fps = FPS
+        vs.bpp = 2
+        vs.fmt = rs.format.z16
+        vs.intrinsics = intr
+        self.profile = sensor.add_video_stream(vs)
+        sensor.add_read_only_option(rs.option.depth_units, DEPTH_UNITS)
+
+        self.sensor = sensor
+        self.queue = rs.frame_queue(16, keep_frames=True)
+        sensor.open(self.profile)
+        sensor.start(self.queue)
+        self.count = 0
+        self.keep = []  # software_video_frame 不拷贝像素，保持引用直到处理完
+
+    def push(self, data):
+        """注入一帧并返回 librealsense 的 depth_frame。"""
+        assert data.shape == (self.height, self.width)
+        pixels = np.ascontiguousarray(data, dtype=np.uint16)
+        self.keep.append(pixels)
+
+        self.count += 1
+        f = rs.software_video_frame()
+        f.pixels = pixels
+        f.bpp = 2
+        f.stride = self.width * 2
+        f.timestamp = self.count * 1000.0 / FPS
+        f.domain = rs.timestamp_domain.hardware_clock
+        f.frame_number = self.count
+        f.profile = self.profile.as_video_stream_profile()
+        self.sensor.on_video_frame(f)
+        return self.queue.wait_for_frame(5000).as_depth_frame()
+
+    def close(self):
+        self.sensor.stop()
+        self.sensor.close()
+
+
+def decimation(magnitude):
+    f = rs.decimation_filter()
+    f.set_option(rs.option.filter_magnitude, magnitude)
+    return f
+
+
+def hole_filling(mode):
+    f = rs.hole_filling_filter()
+    f.set_option(rs.option.holes_fill, mode)
+    return f
+
+
+def spatial(magnitude, alpha, delta, holes_fill):
+    f = rs.spatial_filter()
+    f.set_option(rs.option.filter_magnitude, magnitude)
+    f.set_option(rs.option.filter_smooth_alpha, alpha)
+    f.set_option(rs.option.filter_smooth_delta, delta)
+    f.set_option(rs.option.holes_fill, holes_fill)
+    return f
+
+
+def temporal(alpha, delta, persistency):
+    f = rs.temporal_filter()
+    f.set_option(rs.option.filter_smooth_alpha, alpha)
+    f.set_option(rs.option.filter_smooth_delta, delta)
+    f.set_option(rs.option.holes_fill, persistency)
+    return f
+
+
+# (名称, 过滤器工厂, 是否处理全部输入帧)
+# 只处理第一帧的用例使用 scene_0；时间过滤器依次处理全部帧，保存最后一帧的输出
+CASES = [
+    ("decimation_m2", lambda: decimation(2), False),
+    ("decimation_m3", lambda: decimation(3), False),
+    ("decimation_m5", lambda: decimation(5), False),
+    ("holefill_left", lambda: hole_filling(0), False),
+    ("holefill_farest", lambda: hole_filling(1), False),
+    ("holefill_nearest", lambda: hole_filling(2), False),
+    ("spatial_default", lambda: spatial(2, 0.5, 20, 0), False),
+    ("spatial_m3_fill4", lambda: spatial(3, 0.4, 30, 2), False),
+    ("temporal_default", lambda: temporal(0.4, 20, 3), True),
+]
+
+
+def main():
+    scenes = [read_png16(os.path.join(TESTDATA, f"scene_{i}.png")) for i in range(SCENE_FRAMES)]
+    height, width = scenes[0].shape
+    os.makedirs(OUT_DIR, exist_ok=True)
+    print(f"librealsense {rs.__version__}")
+
+    for name, make_filter, sequence in CASES:
+        # 每个用例使用新的设备和过滤器，避免时间过滤器等保留上一个用例的状态
+        src = DepthSource(width, height)
+        filt = make_filter()
+        out = None
+        for scene in scenes if sequence else scenes[:1]:
+            out = filt.process(src.push(scene))
+        data = np.asanyarray(out.get_data()).copy()
+        src.close()
+
+        path = os.path.join(OUT_DIR, name + ".png")
+        write_png16(path, data)
+        print(f"{path}: {data.shape[1]}x{data.shape[0]}")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// mjpegBoundary 是 multipart/x-mixed-replace 的分隔符
+const mjpegBoundary = "rsframe"
+
+// indexPage 是根路径的预览页面
+const indexPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>RealSense</title>
+<style>body{background:#111;color:#ccc;font-family:sans-serif}img{max-width:49%}</style></head>
+<body>
+<img src="/stream/color.mjpeg" alt="color"> <img src="/stream/depth.mjpeg" alt="depth">
+<p><a href="/snapshot/color.jpg">color.jpg</a> | <a href="/snapshot/depth.png">depth.png (16-bit)</a> | <a href="/status">status</a></p>
+</body></html>
+`
+
+// Handler 返回服务的 HTTP 处理器，路由如下：
+//
+//	/                    预览页面
+//	/stream/color.mjpeg  彩色 MJPEG 流
+//	/stream/depth.mjpeg  伪彩色深度 MJPEG 流
+//	/snapshot/color.jpg  最新彩色帧 (JPEG)
+//	/snapshot/depth.png  最新深度帧 (16 位 PNG，原始深度值)
+//	/status              JSON 状态
+//	/ws                  WebSocket 二进制帧流 (协议见 stream 包)
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.serveIndex)
+	mux.HandleFunc("/stream/color.mjpeg", s.serveStream(KindColor))
+	mux.HandleFunc("/stream/depth.mjpeg", s.serveStream(KindDepth))
+	mux.HandleFunc("/snapshot/color.jpg", s.serveColorSnapshot)
+	mux.HandleFunc("/snapshot/depth.png", s.serveDepthSnapshot)
+	mux.HandleFunc("/status", s.serveStatus)
+	mux.HandleFunc("/ws", s.serveWebSocket)
+	return mux
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, indexPage)
+}
+
+// serveStream 以 multipart/x-mixed-replace 持续发送 JPEG，直到客户端断开或服务关闭
+func (s *Server) serveStream(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.subscribe(kind)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer s.unsubscribe(c)
+
+		flusher, _ := w.(http.Flusher)
+		h := w.Header()
+		h.Set("Content-Type", "multipart/x-mixed-replace; boundary="+mjpegBoundary)
+		h.Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		h.Set("Connection", "close")
+		w.WriteHeader(http.StatusOK)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data, ok := <-c.frames:
+				if !ok {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n",
+					mjpegBoundary, len(data)); err != nil {
+					return
+				}
+				if _, err := w.Write(data); err != nil {
+					return
+				}
+				if _, err := w.Write([]byte("\r\n")); err != nil {
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		}
+	}
+}
+
+// latestOr503 返回最近一帧，尚无帧时回复 503
+func (s *Server) latestOr503(w http.ResponseWriter) (snapshot.Frame, bool) {
+	f, ok := s.Latest()
+	if !ok {
+		http.Error(w, "no frame yet", http.StatusServiceUnavailable)
+	}
+	return f, ok
+}
+
+// writeFrameHeaders 写出帧元数据，便于脚本关联抓拍与时间戳
+func writeFrameHeaders(w http.ResponseWriter, f snapshot.Frame, contentType string, size int) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(size))
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Frame-Number", strconv.FormatUint(f.FrameNumber, 10))
+	h.Set("X-Frame-Timestamp", strconv.FormatFloat(f.Timestamp, 'f', 3, 64))
+}
+
+func (s *Server) serveColorSnapshot(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.latestOr503(w)
+	if !ok {
+		return
+	}
+	data, err := s.encodeJPEG(f, KindColor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeFrameHeaders(w, f, "image/jpeg", len(data))
+	w.Write(data)
+}
+
+func (s *Server) serveDepthSnapshot(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.latestOr503(w)
+	if !ok {
+		return
+	}
+	if f.Depth == nil {
+		http.Error(w, "server: frame has no depth image", http.StatusNotFound)
+		return
+	}
+	var buf bytes.Buffer
+	if err := snapshot.EncodeDepth(&buf, f.Depth, snapshot.DepthPNG16); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeFrameHeaders(w, f, "image/png", buf.Len())
+	w.Header().Set("X-Depth-Scale", strconv.FormatFloat(float64(f.DepthScale), 'g', -1, 32))
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(s.Status())
+}
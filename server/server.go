@@ -0,0 +1,354 @@
+// Package server 通过 HTTP 向局域网内的浏览器提供相机画面
+// 一个帧源 (Publish) 扇出给任意多个客户端：MJPEG 实时流、单帧抓拍、JSON 状态，
+// 以及 stream 包定义的 WebSocket 二进制帧流（供 Wails 等前端使用），
+// 每个客户端有独立的发送队列，慢客户端只会丢弃自己的旧帧，不影响帧源和其他客户端
+// 帧源可以是相机、回放数据或合成帧，测试用合成帧配合 httptest
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"sync"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// ErrClosed 表示服务已关闭
+var ErrClosed = errors.New("server: closed")
+
+// Kind 是 MJPEG 流的类型
+type Kind int
+
+const (
+	KindColor Kind = iota // 彩色图
+	KindDepth             // 伪彩色深度图
+	numKinds
+)
+
+// String 返回流名称，与 URL 中的名称一致
+func (k Kind) String() string {
+	if k == KindDepth {
+		return "depth"
+	}
+	return "color"
+}
+
+// Options 服务参数
+type Options struct {
+	JPEGQuality  int                    // 1-100，默认 80
+	Colorize     *depth.ColorizeOptions // 深度伪彩色参数，nil 表示 depth.DefaultColorizeOptions
+	ClientBuffer int                    // 每个客户端最多排队的帧数，默认 2，队列满时丢弃最旧的帧
+
+	// AllowedOrigins 是允许连接 /ws 的跨源页面，如 "http://192.168.1.20:3000"，"*" 表示任意来源
+	// 默认只接受与服务同源的页面和不带 Origin 的客户端（如 stream.Dial）
+	AllowedOrigins []string
+
+	// Status 返回附加到 /status 的字段（设备信息、遥测等），可为空
+	Status func() map[string]any
+}
+
+// withDefaults 填充默认值并校验参数
+func (o Options) withDefaults() (Options, error) {
+	if o.JPEGQuality == 0 {
+		o.JPEGQuality = 80
+	}
+	if o.JPEGQuality < 1 || o.JPEGQuality > 100 {
+		return o, fmt.Errorf("server: invalid jpeg quality %d", o.JPEGQuality)
+	}
+	if o.Colorize == nil {
+		c := depth.DefaultColorizeOptions()
+		o.Colorize = &c
+	}
+	if err := o.Colorize.Validate(); err != nil {
+		return o, fmt.Errorf("server: %w", err)
+	}
+	if o.ClientBuffer == 0 {
+		o.ClientBuffer = 2
+	}
+	if o.ClientBuffer < 1 {
+		return o, fmt.Errorf("server: invalid client buffer %d", o.ClientBuffer)
+	}
+	return o, nil
+}
+
+// client 是一个 MJPEG 订阅者
+type client struct {
+	kind    Kind
+	frames  chan []byte // 编码后的 JPEG
+	dropped uint64      // 因队列满丢弃的帧数，由 Server.mu 保护
+}
+
+// Server 接收帧并分发给 HTTP 客户端，可并发调用
+type Server struct {
+	opts    Options
+	started time.Time
+
+	mu        sync.Mutex
+	latest    snapshot.Frame
+	hasFrame  bool
+	pending   bool // latest 尚未编码分发
+	published uint64
+	encoded   [numKinds]uint64
+	dropped   uint64 // 已断开客户端的丢帧数累计
+	clients   map[*client]struct{}
+	wsClients map[*wsClient]struct{}
+	closed    bool
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+// New 创建服务并启动分发线程，使用完毕后调用 Close
+func New(opts Options) (*Server, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		opts:      opts,
+		started:   time.Now(),
+		clients:   map[*client]struct{}{},
+		wsClients: map[*wsClient]struct{}{},
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Options 返回生效的参数
+func (s *Server) Options() Options {
+	return s.opts
+}
+
+// Publish 发布一帧，立即返回；分发线程来不及编码时只保留最新的一帧
+// f 的数据此后归服务所有，调用方不应再修改（snapshot.NewFrame 创建的帧满足该要求）
+func (s *Server) Publish(f snapshot.Frame) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.latest, s.hasFrame, s.pending = f, true, true
+	s.published++
+	// 持锁发送，避免与 Close 关闭 notify 竞争
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Latest 返回最近发布的一帧
+func (s *Server) Latest() (snapshot.Frame, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest, s.hasFrame
+}
+
+// Close 断开所有客户端并停止分发线程
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.notify)
+	s.mu.Unlock()
+	<-s.done
+}
+
+// run 是分发线程：按有订阅者的流编码最新帧，每帧每种流只编码一次
+func (s *Server) run() {
+	defer close(s.done)
+	for range s.notify {
+		s.mu.Lock()
+		f, pending := s.latest, s.pending
+		s.pending = false
+		var want [numKinds]bool
+		for c := range s.clients {
+			want[c.kind] = true
+		}
+		if pending {
+			for c := range s.wsClients {
+				c.offer(f)
+			}
+		}
+		s.mu.Unlock()
+		if !pending {
+			continue
+		}
+
+		for kind := Kind(0); kind < numKinds; kind++ {
+			if !want[kind] {
+				continue
+			}
+			data, err := s.encodeJPEG(f, kind)
+			if err != nil {
+				continue // 该帧缺少这种流
+			}
+			s.broadcast(kind, data)
+		}
+	}
+
+	// 关闭所有客户端的队列，HTTP 处理函数随之返回
+	s.mu.Lock()
+	for c := range s.clients {
+		close(c.frames)
+		delete(s.clients, c)
+	}
+	for c := range s.wsClients {
+		close(c.frames)
+		delete(s.wsClients, c)
+	}
+	s.mu.Unlock()
+}
+
+// broadcast 将编码后的帧放入订阅者队列，队列满时丢弃最旧的一帧
+func (s *Server) broadcast(kind Kind, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoded[kind]++
+	for c := range s.clients {
+		if c.kind != kind {
+			continue
+		}
+		select {
+		case c.frames <- data:
+			continue
+		default:
+		}
+		// 队列满：丢弃最旧的帧再放入（只有分发线程写入，不会再次阻塞）
+		select {
+		case <-c.frames:
+			c.dropped++
+		default:
+		}
+		select {
+		case c.frames <- data:
+		default:
+		}
+	}
+}
+
+// subscribe 注册一个订阅者，服务已关闭时返回 ErrClosed
+func (s *Server) subscribe(kind Kind) (*client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	c := &client{kind: kind, frames: make(chan []byte, s.opts.ClientBuffer)}
+	s.clients[c] = struct{}{}
+	return c, nil
+}
+
+// unsubscribe 注销订阅者
+func (s *Server) unsubscribe(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[c]; ok {
+		delete(s.clients, c)
+		s.dropped += c.dropped
+	}
+}
+
+// encodeJPEG 将帧中的彩色图或伪彩色深度图编码为 JPEG
+func (s *Server) encodeJPEG(f snapshot.Frame, kind Kind) ([]byte, error) {
+	var buf bytes.Buffer
+	switch kind {
+	case KindColor:
+		if f.Color == nil {
+			return nil, fmt.Errorf("server: frame has no color image")
+		}
+		if err := snapshot.EncodeColor(&buf, f.Color, f.ColorWidth, f.ColorHeight, snapshot.ColorJPEG, s.opts.JPEGQuality); err != nil {
+			return nil, err
+		}
+	case KindDepth:
+		img, err := s.colorize(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: s.opts.JPEGQuality}); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// colorize 渲染伪彩色深度图
+func (s *Server) colorize(f snapshot.Frame) (image.Image, error) {
+	if f.Depth == nil {
+		return nil, fmt.Errorf("server: frame has no depth image")
+	}
+	scale := f.DepthScale
+	if scale <= 0 {
+		scale = depth.DefaultDepthScale
+	}
+	return depth.Colorize(f.Depth, scale, *s.opts.Colorize)
+}
+
+// Status 是 /status 返回的服务状态
+type Status struct {
+	Uptime      float64           `json:"uptime"` // 秒
+	Published   uint64            `json:"published"`
+	Encoded     map[string]uint64 `json:"encoded"` // 各流编码的帧数
+	Clients     map[string]int    `json:"clients"` // 各流的 MJPEG 客户端数，ws 为 WebSocket 客户端数
+	Dropped     uint64            `json:"dropped"` // 所有客户端因队列满丢弃的帧数
+	FrameNumber uint64            `json:"frame_number"`
+	Timestamp   float64           `json:"timestamp"`
+	Color       *Size             `json:"color,omitempty"`
+	Depth       *Size             `json:"depth,omitempty"`
+	DepthScale  float32           `json:"depth_scale,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+// Size 是图像尺寸
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Status 返回当前状态
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	st := Status{
+		Uptime:    time.Since(s.started).Seconds(),
+		Published: s.published,
+		Encoded:   map[string]uint64{},
+		Clients:   map[string]int{},
+		Dropped:   s.dropped,
+	}
+	for kind := Kind(0); kind < numKinds; kind++ {
+		st.Encoded[kind.String()] = s.encoded[kind]
+		st.Clients[kind.String()] = 0
+	}
+	for c := range s.clients {
+		st.Clients[c.kind.String()]++
+		st.Dropped += c.dropped
+	}
+	st.Clients["ws"] = len(s.wsClients)
+	if s.hasFrame {
+		f := s.latest
+		st.FrameNumber, st.Timestamp, st.DepthScale = f.FrameNumber, f.Timestamp, f.DepthScale
+		if f.Color != nil {
+			st.Color = &Size{f.ColorWidth, f.ColorHeight}
+		}
+		if f.Depth != nil {
+			st.Depth = &Size{f.Depth.Width, f.Depth.Height}
+		}
+	}
+	s.mu.Unlock()
+
+	if s.opts.Status != nil {
+		st.Extra = s.opts.Status()
+	}
+	return st
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/websocket"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// testFrame 生成合成帧：彩色为单色，深度为按像素序号递增的值
+func testFrame(t *testing.T, n uint64) snapshot.Frame {
+	t.Helper()
+	const w, h = 16, 8
+	color := make([]byte, w*h*3)
+	for i := 0; i < len(color); i += 3 {
+		color[i], color[i+1], color[i+2] = byte(n*40), 128, 255-byte(n*40)
+	}
+	data := make([]uint16, w*h)
+	for i := range data {
+		data[i] = uint16(1000 + i*37 + int(n))
+	}
+	f, err := snapshot.NewFrame(color, w, h, data, w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.DepthScale, f.Timestamp, f.FrameNumber = 0.00025, 1000+float64(n)*33.3, n
+	return f
+}
+
+func newTestServer(t *testing.T, opts Options) (*Server, *httptest.Server) {
+	t.Helper()
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		s.Close() // 先断开长连接，ts.Close 才不会等待
+		ts.Close()
+	})
+	return s, ts
+}
+
+// waitClients 等待指定流的订阅者数达到 n
+func waitClients(t *testing.T, s *Server, kind Kind, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Status().Clients[kind.String()] != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d %s clients", n, kind)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// openStream 打开 MJPEG 流并返回 multipart 读取器
+func openStream(t *testing.T, url string) *multipart.Reader {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/x-mixed-replace" || params["boundary"] != mjpegBoundary {
+		t.Fatalf("Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+	return multipart.NewReader(resp.Body, mjpegBoundary)
+}
+
+// readPart 按 Content-Length 读取一帧 JPEG，校验分段头
+// 分段结尾的分隔符随下一帧才发送，因此不能读到 EOF
+func readPart(t *testing.T, mr *multipart.Reader) []byte {
+	t.Helper()
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := part.Header.Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("part Content-Type %q", ct)
+	}
+	n, err := strconv.Atoi(part.Header.Get("Content-Length"))
+	if err != nil || n <= 0 {
+		t.Fatalf("part Content-Length %q", part.Header.Get("Content-Length"))
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(part, data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMJPEGStream(t *testing.T) {
+	for _, kind := range []Kind{KindColor, KindDepth} {
+		t.Run(kind.String(), func(t *testing.T) {
+			s, ts := newTestServer(t, Options{})
+			mr := openStream(t, ts.URL+"/stream/"+kind.String()+".mjpeg")
+			waitClients(t, s, kind, 1)
+
+			for n := uint64(1); n <= 3; n++ {
+				if err := s.Publish(testFrame(t, n)); err != nil {
+					t.Fatal(err)
+				}
+				img, err := jpeg.Decode(bytes.NewReader(readPart(t, mr)))
+				if err != nil {
+					t.Fatalf("frame %d: %v", n, err)
+				}
+				if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+					t.Errorf("frame %d: size %v", n, b)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotBeforeFirstFrame(t *testing.T) {
+	_, ts := newTestServer(t, Options{})
+	for _, path := range []string{"/snapshot/color.jpg", "/snapshot/depth.png"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: status %d, want 503", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestDepthSnapshotRoundTrip(t *testing.T) {
+	s, ts := newTestServer(t, Options{})
+	want := testFrame(t, 7)
+	s.Publish(want)
+
+	resp, err := http.Get(ts.URL + "/snapshot/depth.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	h := resp.Header
+	if h.Get("Content-Type") != "image/png" || h.Get("X-Frame-Number") != "7" ||
+		h.Get("X-Depth-Scale") != "0.00025" || h.Get("X-Frame-Timestamp") != "1233.100" {
+		t.Errorf("headers %v", h)
+	}
+
+	img, err := png.Decode(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	if b.Dx() != want.Depth.Width || b.Dy() != want.Depth.Height {
+		t.Fatalf("size %v", b)
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			r, _, _, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			if got, exp := uint16(r), want.Depth.Pix[y*want.Depth.Width+x]; got != exp {
+				t.Fatalf("pixel (%d,%d) = %d, want %d", x, y, got, exp)
+			}
+		}
+	}
+}
+
+func TestStatusCounts(t *testing.T) {
+	s, ts := newTestServer(t, Options{Status: func() map[string]any {
+		return map[string]any{"serial_number": "test"}
+	}})
+	openStream(t, ts.URL+"/stream/color.mjpeg")
+	openStream(t, ts.URL+"/stream/color.mjpeg")
+	openStream(t, ts.URL+"/stream/depth.mjpeg")
+	waitClients(t, s, KindColor, 2)
+	waitClients(t, s, KindDepth, 1)
+	s.Publish(testFrame(t, 1))
+	s.Publish(testFrame(t, 2))
+
+	resp, err := http.Get(ts.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st Status
+	if err := json.NewDecoder(bufio.NewReader(resp.Body)).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Published != 2 || st.FrameNumber != 2 || st.DepthScale != 0.00025 {
+		t.Errorf("published %d, frame %d, scale %v", st.Published, st.FrameNumber, st.DepthScale)
+	}
+	if st.Clients["color"] != 2 || st.Clients["depth"] != 1 || st.Clients["ws"] != 0 {
+		t.Errorf("clients %v", st.Clients)
+	}
+	if st.Color == nil || *st.Color != (Size{16, 8}) || st.Depth == nil || *st.Depth != (Size{16, 8}) {
+		t.Errorf("sizes %v %v", st.Color, st.Depth)
+	}
+	if st.Extra["serial_number"] != "test" {
+		t.Errorf("extra %v", st.Extra)
+	}
+}
+
+func TestSlowClientDropsOnlyItsOwnFrames(t *testing.T) {
+	const frames = 5
+	s, ts := newTestServer(t, Options{ClientBuffer: 1})
+
+	// 慢客户端只订阅、从不读取
+	slow, err := s.subscribe(KindColor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast := openStream(t, ts.URL+"/stream/color.mjpeg")
+	waitClients(t, s, KindColor, 2)
+
+	var last []byte
+	for n := uint64(1); n <= frames; n++ {
+		if err := s.Publish(testFrame(t, n)); err != nil {
+			t.Fatal(err)
+		}
+		// 快客户端收到每一帧后才发布下一帧，分发线程不会合并帧
+		last = readPart(t, fast)
+	}
+
+	st := s.Status()
+	if st.Encoded["color"] != frames {
+		t.Errorf("encoded %d, want %d", st.Encoded["color"], frames)
+	}
+	if st.Dropped != frames-1 {
+		t.Errorf("dropped %d, want %d", st.Dropped, frames-1)
+	}
+	s.mu.Lock()
+	slowDropped := slow.dropped
+	s.mu.Unlock()
+	if slowDropped != frames-1 {
+		t.Errorf("slow client dropped %d, want %d", slowDropped, frames-1)
+	}
+	// 慢客户端队列中保留的是最新一帧
+	if got := <-slow.frames; !bytes.Equal(got, last) {
+		t.Error("slow client queue does not hold the latest frame")
+	}
+	s.unsubscribe(slow)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string // 空表示不带 Origin，"self" 表示与服务同源
+		ok      bool
+	}{
+		{"no origin", nil, "", true},
+		{"same origin", nil, "self", true},
+		{"cross origin", nil, "http://evil.example", false},
+		{"null origin", nil, "null", false},
+		{"allowed origin", []string{"http://app.example:3000"}, "http://app.example:3000", true},
+		{"other origin", []string{"http://app.example:3000"}, "http://app.example:4000", false},
+		{"any origin", []string{"*"}, "http://evil.example", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ts := newTestServer(t, Options{AllowedOrigins: tt.allowed})
+			header := http.Header{}
+			switch tt.origin {
+			case "":
+			case "self":
+				header.Set("Origin", ts.URL)
+			default:
+				header.Set("Origin", tt.origin)
+			}
+			conn, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", header)
+			if tt.ok {
+				if err != nil {
+					t.Fatal(err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("cross-origin upgrade accepted")
+			}
+			if !strings.Contains(err.Error(), "403") {
+				t.Errorf("error %v, want 403", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/websocket"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+	"github.com/tianfei212/jetson-rs-middleware/stream"
+)
+
+// wsWriteTimeout 是单条 WebSocket 消息的写超时，超时的客户端被断开
+const wsWriteTimeout = 5 * time.Second
+
+// wsReadLimit 是客户端控制消息的最大长度
+const wsReadLimit = 64 << 10
+
+// wsClient 是一个 WebSocket 订阅者
+// 每个客户端的订阅参数不同，由各自的 goroutine 编码，队列只保留最新的一帧
+type wsClient struct {
+	frames chan snapshot.Frame
+}
+
+// offer 放入最新帧，替换尚未取走的旧帧，调用方持有 Server.mu
+func (c *wsClient) offer(f snapshot.Frame) {
+	select {
+	case <-c.frames:
+	default:
+	}
+	c.frames <- f
+}
+
+// subscribeWS 注册一个 WebSocket 订阅者，服务已关闭时返回 ErrClosed
+func (s *Server) subscribeWS() (*wsClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	c := &wsClient{frames: make(chan snapshot.Frame, 1)}
+	s.wsClients[c] = struct{}{}
+	return c, nil
+}
+
+// unsubscribeWS 注销 WebSocket 订阅者
+func (s *Server) unsubscribeWS(c *wsClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.wsClients, c)
+}
+
+// serveWebSocket 实现 stream 包定义的帧流协议：
+// 连接后发送 hello，客户端发送 subscribe 后按其参数推送二进制帧消息
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, err := s.subscribeWS()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribeWS(c)
+
+	conn, err := websocket.Upgrade(w, r, s.opts.AllowedOrigins)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(wsReadLimit)
+
+	hello := stream.Control{Type: stream.ControlHello, Version: stream.ProtocolVersion}
+	hello.Streams = []string{stream.StreamColor.String(), stream.StreamDepth.String(), stream.StreamHeatmap.String()}
+	if writeControl(conn, hello) != nil {
+		return
+	}
+
+	subs := make(chan stream.Subscription)
+	quit := make(chan struct{})
+	defer close(quit)
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		s.readSubscriptions(conn, subs, quit)
+	}()
+
+	var sub stream.Subscription
+	var limiter rateLimiter
+	for {
+		select {
+		case <-readDone:
+			return
+		case sub = <-subs:
+			limiter.reset(sub.MaxFPS)
+		case f, ok := <-c.frames:
+			if !ok {
+				conn.CloseWithCode(websocket.CloseGoingAway, "server closed")
+				return
+			}
+			if len(sub.Streams) == 0 || !limiter.allow(time.Now()) {
+				continue
+			}
+			msg, err := s.encodeMessage(f, sub)
+			if err != nil {
+				continue // 该帧缺少订阅的流
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if conn.WriteMessage(websocket.OpBinary, msg) != nil {
+				return
+			}
+		}
+	}
+}
+
+// readSubscriptions 读取客户端的控制消息，有效的订阅发送到 subs，无效时回复错误消息
+// 连接断开、出错或 quit 关闭时返回
+func (s *Server) readSubscriptions(conn *websocket.Conn, subs chan<- stream.Subscription, quit <-chan struct{}) {
+	for {
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if op != websocket.OpText {
+			conn.CloseWithCode(websocket.CloseUnsupported, "binary messages are not accepted")
+			return
+		}
+		var ctl stream.Control
+		if err := json.Unmarshal(data, &ctl); err != nil {
+			err = fmt.Errorf("stream: invalid control message: %w", err)
+			writeControl(conn, stream.Control{Type: stream.ControlError, Version: stream.ProtocolVersion, Message: err.Error()})
+			continue
+		}
+		if ctl.Type != stream.ControlSubscribe {
+			continue // 未知的控制消息留给后续版本
+		}
+		if ctl.Version != stream.ProtocolVersion {
+			msg := fmt.Sprintf("%v %d, server speaks %d", stream.ErrVersion, ctl.Version, stream.ProtocolVersion)
+			conn.CloseWithCode(websocket.ClosePolicy, msg)
+			return
+		}
+		if err := ctl.Subscription.Validate(); err != nil {
+			writeControl(conn, stream.Control{Type: stream.ControlError, Version: stream.ProtocolVersion, Message: err.Error()})
+			continue
+		}
+		select {
+		case subs <- ctl.Subscription:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// writeControl 发送 JSON 控制消息
+func writeControl(conn *websocket.Conn, ctl stream.Control) error {
+	data, err := json.Marshal(ctl)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteMessage(websocket.OpText, data)
+}
+
+// encodeMessage 按订阅参数裁剪、缩放并编码一帧
+func (s *Server) encodeMessage(f snapshot.Frame, sub stream.Subscription) ([]byte, error) {
+	wantDepth := sub.Has(stream.StreamDepth) || sub.Has(stream.StreamHeatmap)
+	if !sub.Has(stream.StreamColor) {
+		f.Color = nil
+	}
+	if !wantDepth {
+		f.Depth = nil
+	}
+	p, err := stream.FromSnapshot(f)
+	if err != nil {
+		return nil, err
+	}
+	if (sub.Has(stream.StreamColor) && p.ColorBuffer == nil) || (wantDepth && p.DepthBuffer == nil) {
+		return nil, fmt.Errorf("server: frame lacks subscribed streams")
+	}
+
+	p = p.Downscale(sub.MaxWidth)
+	if sub.Has(stream.StreamHeatmap) {
+		if err := p.Colorize(*s.opts.Colorize); err != nil {
+			return nil, err
+		}
+	}
+	if !sub.Has(stream.StreamDepth) {
+		p.DepthBuffer = nil
+	}
+
+	enc, _ := sub.Encoding() // 已在 Validate 中校验
+	quality := sub.Quality
+	if quality == 0 {
+		quality = s.opts.JPEGQuality
+	}
+	return stream.Encode(p, stream.EncodeOptions{Quality: quality, DepthEncoding: enc})
+}
+
+// rateLimiter 将发送帧率限制在 fps 以内
+// 按固定节拍推进下一次允许发送的时间，允许 1/4 间隔的抖动，
+// 避免相机帧率是目标帧率整数倍时因时间抖动丢掉本应发送的帧
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// reset 设置目标帧率，fps <= 0 表示不限制
+func (l *rateLimiter) reset(fps float64) {
+	l.interval, l.next = 0, time.Time{}
+	if fps > 0 {
+		l.interval = time.Duration(float64(time.Second) / fps)
+	}
+}
+
+// allow 判断 now 时刻到达的帧是否应发送
+func (l *rateLimiter) allow(now time.Time) bool {
+	if l.interval == 0 {
+		return true
+	}
+	if now.Before(l.next.Add(-l.interval / 4)) {
+		return false
+	}
+	// 落后超过一个间隔时（客户端慢或相机掉帧）重新对齐，不补发
+	if l.next.IsZero() || now.Sub(l.next) > l.interval {
+		l.next = now
+	}
+	l.next = l.next.Add(l.interval)
+	return true
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/websocket"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+	"github.com/tianfei212/jetson-rs-middleware/stream"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		fps      float64
+		arrivals []float64 // 帧到达时刻（毫秒）
+		want     []bool
+	}{
+		{"unlimited", 0, []float64{0, 1, 2}, []bool{true, true, true}},
+		{"every frame at target rate", 10, []float64{0, 100, 200, 300}, []bool{true, true, true, true}},
+		// 30fps 相机限制到 15fps：每两帧发送一帧
+		{"half rate", 15, []float64{0, 33.3, 66.7, 100, 133.3, 166.7}, []bool{true, false, true, false, true, false}},
+		// 到达时间提前不超过 1/4 间隔仍然发送，节拍不随抖动漂移
+		{"jitter tolerated", 10, []float64{0, 80, 190, 300}, []bool{true, true, true, true}},
+		{"too early", 10, []float64{0, 70, 100}, []bool{true, false, true}},
+		// 中断后重新对齐，不连续补发
+		{"realign after gap", 10, []float64{0, 500, 520, 600}, []bool{true, true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l rateLimiter
+			l.reset(tt.fps)
+			base := time.Unix(1700000000, 0)
+			var got []bool
+			for _, ms := range tt.arrivals {
+				got = append(got, l.allow(base.Add(time.Duration(ms*float64(time.Millisecond)))))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("allow = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// publishUntil 以固定间隔循环发布 frames，直到 stop 关闭
+// 第 n 次发布的帧号为 n，内容为 frames[(n-1) % len(frames)]
+func publishUntil(s *Server, frames []snapshot.Frame, interval time.Duration, stop <-chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+			f := frames[i%len(frames)]
+			f.FrameNumber = uint64(i + 1)
+			s.Publish(f)
+		}
+	}
+}
+
+func TestWebSocketSubscription(t *testing.T) {
+	for _, enc := range []string{"raw", "deflate"} {
+		t.Run(enc, func(t *testing.T) {
+			s, ts := newTestServer(t, Options{})
+			frames := make([]snapshot.Frame, 4)
+			for i := range frames {
+				frames[i] = testFrame(t, uint64(i+1))
+			}
+
+			c, err := stream.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", stream.Subscription{
+				Streams: []string{"color", "depth"}, MaxWidth: 8, MaxFPS: 20, Quality: 90, DepthEncoding: enc,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if !slices.Equal(c.Streams(), []string{"color", "depth", "heatmap"}) {
+				t.Errorf("hello streams %v", c.Streams())
+			}
+
+			// 以约 200fps 发布，订阅限制为 20fps
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				publishUntil(s, frames, 5*time.Millisecond, stop)
+			}()
+			defer func() {
+				close(stop)
+				<-done
+			}()
+
+			const messages = 5
+			var start time.Time
+			var last uint64
+			for i := 0; i < messages; i++ {
+				m, err := c.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					start = time.Now()
+				}
+				if sec, ok := m.Section(stream.StreamDepth); !ok || sec.Encoding.String() != enc {
+					t.Fatalf("depth section %v %v", sec.Encoding, ok)
+				}
+				p, err := m.Payload()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p.HeatmapBuffer != nil {
+					t.Error("heatmap sent without subscription")
+				}
+
+				// 解码结果与服务端发布的帧按最近邻缩放到 8x4 后一致
+				src := frames[(p.FrameNumber-1)%uint64(len(frames))]
+				want, err := stream.FromSnapshot(src)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = want.Downscale(8)
+				if p.Width != 8 || p.Height != 4 || p.DepthScale != 0.00025 || p.Timestamp != src.Timestamp {
+					t.Errorf("payload %dx%d scale %g ts %g", p.Width, p.Height, p.DepthScale, p.Timestamp)
+				}
+				if !slices.Equal(p.DepthBuffer, want.DepthBuffer) {
+					t.Errorf("frame %d: depth %v, want %v", p.FrameNumber, p.DepthBuffer, want.DepthBuffer)
+				}
+				for j := range p.ColorBuffer {
+					if d := int(p.ColorBuffer[j]) - int(want.ColorBuffer[j]); d > 16 || d < -16 {
+						t.Fatalf("frame %d: color byte %d = %d, want about %d", p.FrameNumber, j, p.ColorBuffer[j], want.ColorBuffer[j])
+					}
+				}
+				if i > 0 && p.FrameNumber <= last {
+					t.Errorf("frame %d after frame %d", p.FrameNumber, last)
+				}
+				last = p.FrameNumber
+			}
+
+			// 20fps 时相邻消息至少间隔 37.5ms（允许 1/4 抖动），5 条消息跨越约 200ms
+			if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+				t.Errorf("%d messages in %v, rate limit not applied", messages, elapsed)
+			}
+		})
+	}
+}
+
+func TestWebSocketInvalidSubscription(t *testing.T) {
+	_, ts := newTestServer(t, Options{})
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, _, err := conn.ReadMessage(); err != nil { // hello
+		t.Fatal(err)
+	}
+
+	// stream.Client 会在本地校验订阅，这里直接发送原始控制消息，由服务端拒绝
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{`{"type":"subscribe","version":1,"streams":["ir"]}`, `unknown stream "ir"`},
+		{`{"type":"subscribe","version":1,"max_width":-1}`, "invalid max width"},
+		{`not json`, "invalid control message"},
+	}
+	for _, tt := range tests {
+		if err := conn.WriteMessage(websocket.OpText, []byte(tt.msg)); err != nil {
+			t.Fatal(err)
+		}
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ctl stream.Control
+		if op != websocket.OpText || json.Unmarshal(data, &ctl) != nil || ctl.Type != stream.ControlError ||
+			!strings.Contains(ctl.Message, tt.want) {
+			t.Errorf("%s: reply %s, want error containing %q", tt.msg, data, tt.want)
+		}
+	}
+
+	// 协议版本不一致时服务端关闭连接
+	conn.WriteMessage(websocket.OpText, []byte(`{"type":"subscribe","version":99}`))
+	var ce *websocket.CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != websocket.ClosePolicy {
+		t.Errorf("error %v, want policy close", err)
+	}
+}
@@ -0,0 +1,423 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+)
+
+// DeviceInfo 是写入附属文件的设备信息
+type DeviceInfo struct {
+	Name            string `json:"name,omitempty"`
+	SerialNumber    string `json:"serial_number,omitempty"`
+	FirmwareVersion string `json:"firmware_version,omitempty"`
+	PhysicalPort    string `json:"physical_port,omitempty"`
+}
+
+// Options 抓拍参数
+type Options struct {
+	Dir         string      // 输出根目录，每次触发在其下创建一个子目录
+	ColorFormat ColorFormat // 默认 JPEG
+	JPEGQuality int         // 1-100，默认 90
+	DepthFormat DepthFormat // 默认 16 位 PNG
+	PreTrigger  int         // 额外保存的触发前帧数，0 表示只保存触发帧
+	QueueSize   int         // 等待后台保存的触发事件上限，默认 4，队列满时丢弃新事件
+
+	Device   DeviceInfo     // 设备信息，原样写入附属文件
+	Settings map[string]any // 当前流/滤波/ROI 等设置，原样写入附属文件
+}
+
+// FileEntry 是附属文件中的单帧记录，路径相对于触发目录
+// 已被之前的触发保存过的帧不再重复编码，而是硬链接到本次触发目录（不支持硬链接时复制），
+// 每个触发目录都是完整的，可以单独移动或删除
+type FileEntry struct {
+	FrameNumber uint64  `json:"frame_number"`
+	Timestamp   float64 `json:"timestamp"`
+	PreTrigger  bool    `json:"pre_trigger"`
+	Color       string  `json:"color,omitempty"`
+	Depth       string  `json:"depth,omitempty"`
+}
+
+// Sidecar 是每次触发的 JSON 附属文件 (trigger.json)
+type Sidecar struct {
+	Region      string         `json:"region"`
+	Timestamp   float64        `json:"timestamp"`
+	FrameNumber uint64         `json:"frame_number"`
+	Time        time.Time      `json:"time"`
+	Stats       roi.Stats      `json:"stats"`
+	DepthScale  float32        `json:"depth_scale"`
+	ColorFormat ColorFormat    `json:"color_format"`
+	DepthFormat DepthFormat    `json:"depth_format"`
+	Device      DeviceInfo     `json:"device"`
+	Settings    map[string]any `json:"settings,omitempty"`
+	Files       []FileEntry    `json:"files"`
+}
+
+// Result 是一次抓拍的输出
+type Result struct {
+	Dir     string // 触发目录
+	Sidecar Sidecar
+}
+
+// ErrQueueFull 表示后台保存队列已满，触发事件被丢弃
+var ErrQueueFull = errors.New("snapshot: save queue full")
+
+// Capturer 维护触发前环形缓冲，并在 ROI 触发时保存抓拍
+// 编码和写盘在后台 goroutine 中进行，Process 不会阻塞采集循环；
+// 完成的抓拍通过 Results 取走，失败通过 Err 查看，退出前需调用 Close 等待队列写完
+type Capturer struct {
+	opts Options
+	ring *RingBuffer
+	jobs chan saveJob
+	done chan struct{}
+
+	mu      sync.Mutex
+	pushed  uint64                // 已放入缓冲的帧数，最新一帧的序号为 pushed
+	saved   map[uint64]savedFrame // 已成功写入的缓冲帧，按序号索引
+	results []Result
+	err     error
+	closed  bool
+}
+
+// savedFrame 记录某一帧已写入的彩色和深度文件路径
+type savedFrame struct {
+	color string
+	depth string
+}
+
+// saveJob 是一次触发的保存任务，first 是 frames[0] 在缓冲中的序号
+type saveJob struct {
+	dir     string
+	sidecar Sidecar
+	frames  []Frame
+	first   uint64
+}
+
+// NewCapturer 创建抓拍器，输出目录不存在时自动创建
+func NewCapturer(opts Options) (*Capturer, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("snapshot: output dir is required")
+	}
+	if opts.PreTrigger < 0 {
+		return nil, fmt.Errorf("snapshot: invalid pre-trigger count %d", opts.PreTrigger)
+	}
+	if opts.ColorFormat == "" {
+		opts.ColorFormat = ColorJPEG
+	}
+	if opts.ColorFormat != ColorJPEG && opts.ColorFormat != ColorPNG {
+		return nil, fmt.Errorf("snapshot: unknown color format %q", opts.ColorFormat)
+	}
+	if opts.DepthFormat == "" {
+		opts.DepthFormat = DepthPNG16
+	}
+	if opts.DepthFormat != DepthPNG16 && opts.DepthFormat != DepthNPY {
+		return nil, fmt.Errorf("snapshot: unknown depth format %q", opts.DepthFormat)
+	}
+	if opts.JPEGQuality == 0 {
+		opts.JPEGQuality = 90
+	}
+	if opts.JPEGQuality < 1 || opts.JPEGQuality > 100 {
+		return nil, fmt.Errorf("snapshot: invalid jpeg quality %d", opts.JPEGQuality)
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = 4
+	}
+	if opts.QueueSize < 0 {
+		return nil, fmt.Errorf("snapshot: invalid queue size %d", opts.QueueSize)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	// 环形缓冲包含触发帧本身
+	ring, err := NewRingBuffer(opts.PreTrigger + 1)
+	if err != nil {
+		return nil, err
+	}
+	c := &Capturer{
+		opts:  opts,
+		ring:  ring,
+		jobs:  make(chan saveJob, opts.QueueSize),
+		done:  make(chan struct{}),
+		saved: make(map[uint64]savedFrame),
+	}
+	go c.worker()
+	return c, nil
+}
+
+// Options 返回生效的参数
+func (c *Capturer) Options() Options {
+	return c.opts
+}
+
+// Push 将一帧放入触发前缓冲，每帧调用一次
+func (c *Capturer) Push(f Frame) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ring.Push(f)
+	c.pushed++
+}
+
+// Process 放入当前帧并将每个触发事件交给后台保存，不等待文件写入
+// 队列满时丢弃剩余事件并返回 ErrQueueFull
+// 典型用法：events, _ := engine.Process(...); capturer.Process(frame, events)
+func (c *Capturer) Process(f Frame, events []roi.Event) error {
+	c.Push(f)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return fmt.Errorf("snapshot: capturer closed")
+	}
+	for _, ev := range events {
+		job, err := c.plan(ev)
+		if err != nil {
+			return err
+		}
+		select {
+		case c.jobs <- job:
+		default:
+			return ErrQueueFull
+		}
+	}
+	return nil
+}
+
+// Save 同步保存缓冲中的帧（最后一帧视为触发帧）和附属文件
+// 与后台队列共用已保存帧的记录，之前触发已保存的帧直接链接，不重复编码
+func (c *Capturer) Save(ev roi.Event) (Result, error) {
+	c.mu.Lock()
+	job, err := c.plan(ev)
+	c.mu.Unlock()
+	if err != nil {
+		return Result{}, err
+	}
+	return c.save(job)
+}
+
+// Results 取走自上次调用以来后台完成的抓拍
+func (c *Capturer) Results() []Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := c.results
+	c.results = nil
+	return out
+}
+
+// Err 返回最近一次后台保存失败的错误
+func (c *Capturer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close 停止接收新的触发事件，等待队列中的抓拍写完，返回最近一次保存错误
+func (c *Capturer) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return c.Err()
+	}
+	c.closed = true
+	close(c.jobs)
+	c.mu.Unlock()
+
+	<-c.done
+	return c.Err()
+}
+
+// worker 依次执行保存任务，前一次触发写出的帧可以被后一次触发链接
+func (c *Capturer) worker() {
+	defer close(c.done)
+	for job := range c.jobs {
+		res, err := c.save(job)
+		c.mu.Lock()
+		if err != nil {
+			c.err = err
+		} else {
+			c.results = append(c.results, res)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// plan 根据缓冲内容生成保存任务，调用方需持有 c.mu
+func (c *Capturer) plan(ev roi.Event) (saveJob, error) {
+	frames := c.ring.Frames()
+	if len(frames) == 0 {
+		return saveJob{}, fmt.Errorf("snapshot: no frames buffered")
+	}
+	trigger := frames[len(frames)-1]
+
+	name := fmt.Sprintf("%s_%s_%d", ev.Time.Format("20060102-150405.000"), sanitize(ev.Region), ev.FrameNumber)
+	job := saveJob{
+		dir:    filepath.Join(c.opts.Dir, name),
+		frames: frames,
+		first:  c.pushed - uint64(len(frames)) + 1,
+		sidecar: Sidecar{
+			Region:      ev.Region,
+			Timestamp:   ev.Timestamp,
+			FrameNumber: ev.FrameNumber,
+			Time:        ev.Time,
+			Stats:       ev.Stats,
+			DepthScale:  trigger.DepthScale,
+			ColorFormat: c.opts.ColorFormat,
+			DepthFormat: c.opts.DepthFormat,
+			Device:      c.opts.Device,
+			Settings:    c.opts.Settings,
+		},
+	}
+
+	for i, f := range frames {
+		pre := i < len(frames)-1
+		prefix := "trigger"
+		if pre {
+			prefix = fmt.Sprintf("pre_%03d", len(frames)-1-i)
+		}
+		entry := FileEntry{FrameNumber: f.FrameNumber, Timestamp: f.Timestamp, PreTrigger: pre}
+		if f.Color != nil {
+			entry.Color = prefix + "_color" + c.opts.ColorFormat.ext()
+		}
+		if f.Depth != nil {
+			entry.Depth = prefix + "_depth" + c.opts.DepthFormat.ext()
+		}
+		job.sidecar.Files = append(job.sidecar.Files, entry)
+	}
+	return job, nil
+}
+
+// save 写出任务中的帧文件和附属文件
+// 之前触发已写入的帧硬链接过来，链接和复制都失败时（例如文件已被删除）重新编码
+func (c *Capturer) save(job saveJob) (Result, error) {
+	if err := os.MkdirAll(job.dir, 0o755); err != nil {
+		return Result{}, err
+	}
+	for i, f := range job.frames {
+		entry := job.sidecar.Files[i]
+		c.mu.Lock()
+		prev, ok := c.saved[job.first+uint64(i)]
+		c.mu.Unlock()
+		if ok && linkFrame(prev, job.dir, entry) == nil {
+			continue
+		}
+		if err := c.writeFrame(job.dir, entry, f); err != nil {
+			return Result{}, err
+		}
+	}
+
+	sc := job.sidecar
+	err := writeFile(filepath.Join(job.dir, "trigger.json"), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sc)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	c.commit(job)
+	return Result{Dir: job.dir, Sidecar: sc}, nil
+}
+
+// commit 在任务保存成功后记录其中的帧，之后的触发直接链接这些文件
+// 后台任务按顺序执行，排在后面的任务不会再用到早于 job.first 的帧，这些记录随之删除
+func (c *Capturer) commit(job saveJob) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for seq := range c.saved {
+		if seq < job.first {
+			delete(c.saved, seq)
+		}
+	}
+	for i, entry := range job.sidecar.Files {
+		f := savedFrame{}
+		if entry.Color != "" {
+			f.color = filepath.Join(job.dir, entry.Color)
+		}
+		if entry.Depth != "" {
+			f.depth = filepath.Join(job.dir, entry.Depth)
+		}
+		c.saved[job.first+uint64(i)] = f
+	}
+}
+
+// linkFrame 将之前写入的单帧文件链接到 dir 中 entry 对应的文件名
+// 任何一个文件失败时删除已创建的链接，由调用方重新编码
+func linkFrame(prev savedFrame, dir string, entry FileEntry) error {
+	pairs := [][2]string{{prev.color, entry.Color}, {prev.depth, entry.Depth}}
+	var created []string
+	for _, p := range pairs {
+		if (p[0] == "") != (p[1] == "") {
+			return fmt.Errorf("snapshot: saved frame does not match %+v", entry)
+		}
+		if p[1] == "" {
+			continue
+		}
+		dst := filepath.Join(dir, p[1])
+		if err := linkFile(p[0], dst); err != nil {
+			for _, name := range created {
+				os.Remove(name)
+			}
+			return err
+		}
+		created = append(created, dst)
+	}
+	return nil
+}
+
+// linkFile 创建 src 的硬链接 dst，文件系统不支持硬链接时退回复制
+func linkFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFile(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+// writeFrame 按 entry 中的文件名保存单帧的彩色和深度文件
+func (c *Capturer) writeFrame(dir string, entry FileEntry, f Frame) error {
+	if f.Color != nil {
+		err := writeFile(filepath.Join(dir, entry.Color), func(w io.Writer) error {
+			return EncodeColor(w, f.Color, f.ColorWidth, f.ColorHeight, c.opts.ColorFormat, c.opts.JPEGQuality)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.Depth != nil {
+		err := writeFile(filepath.Join(dir, entry.Depth), func(w io.Writer) error {
+			return EncodeDepth(w, f.Depth, c.opts.DepthFormat)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitize 将区域名转换为安全的文件名
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
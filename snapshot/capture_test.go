@@ -0,0 +1,154 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/roi"
+)
+
+func testFrame(n uint64) Frame {
+	img := depth.NewImage(4, 2)
+	for i := range img.Pix {
+		img.Pix[i] = uint16(1000 + n)
+	}
+	return Frame{
+		Color: make([]byte, 4*2*3), ColorWidth: 4, ColorHeight: 2,
+		Depth: img, DepthScale: 0.001, Timestamp: float64(n) * 33, FrameNumber: n,
+	}
+}
+
+func testEvent(region string, n uint64) roi.Event {
+	return roi.Event{Region: region, FrameNumber: n, Timestamp: float64(n) * 33, Time: time.Unix(1700000000, int64(n)*int64(time.Millisecond))}
+}
+
+func TestCapturerReusesSavedFrames(t *testing.T) {
+	c, err := NewCapturer(Options{Dir: t.TempDir(), PreTrigger: 2, ColorFormat: ColorPNG})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n uint64
+	push := func(events ...roi.Event) {
+		n++
+		if err := c.Process(testFrame(n), events); err != nil {
+			t.Fatal(err)
+		}
+	}
+	push()
+	push()
+	push(testEvent("door", 3))                         // 保存 1、2、3
+	push(testEvent("door", 4))                         // 2、3 已保存，只编码 4
+	push(testEvent("door", 5), testEvent("window", 5)) // 同一帧两个区域，第二个全部链接
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	results := c.Results()
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+
+	// 每个触发目录都包含全部文件，同一帧在不同目录中是同一个文件
+	files := map[uint64][]os.FileInfo{}
+	for i, r := range results {
+		if len(r.Sidecar.Files) != 3 {
+			t.Errorf("result %d has %d files, want 3", i, len(r.Sidecar.Files))
+		}
+		for _, e := range r.Sidecar.Files {
+			for _, p := range []string{e.Color, e.Depth} {
+				if filepath.Base(p) != p {
+					t.Errorf("result %d: %q is not inside the trigger dir", i, p)
+				}
+			}
+			fi, err := os.Stat(filepath.Join(r.Dir, e.Depth))
+			if err != nil {
+				t.Fatalf("result %d: %v", i, err)
+			}
+			files[e.FrameNumber] = append(files[e.FrameNumber], fi)
+		}
+		last := r.Sidecar.Files[len(r.Sidecar.Files)-1]
+		if last.PreTrigger || last.FrameNumber != r.Sidecar.FrameNumber {
+			t.Errorf("result %d: last entry %+v is not the trigger frame", i, last)
+		}
+	}
+	for frame, infos := range files {
+		for _, fi := range infos[1:] {
+			if !os.SameFile(infos[0], fi) {
+				t.Errorf("frame %d saved more than once", frame)
+			}
+		}
+	}
+	if len(files[3]) != 4 || len(files[5]) != 2 {
+		t.Errorf("frame 3 in %d dirs, frame 5 in %d dirs", len(files[3]), len(files[5]))
+	}
+}
+
+func TestCapturerFailedSaveNotReused(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewCapturer(Options{Dir: dir, PreTrigger: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 用同名文件占住第一次触发的目录，使其保存失败
+	ev := testEvent("door", 2)
+	blocked := filepath.Join(dir, fmt.Sprintf("%s_door_2", ev.Time.Format("20060102-150405.000")))
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Push(testFrame(1))
+	if _, err := c.Save(ev); err == nil {
+		t.Fatal("save into a blocked dir should fail")
+	}
+	if err := c.Process(testFrame(2), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 失败的触发没有记录为已保存，下一次触发重新编码这些帧
+	c.Push(testFrame(3))
+	res, err := c.Save(testEvent("door", 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range res.Sidecar.Files {
+		if _, err := os.Stat(filepath.Join(res.Dir, e.Depth)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// 之前的触发目录被删除后，链接失败，同样退回重新编码
+	if err := os.RemoveAll(res.Dir); err != nil {
+		t.Fatal(err)
+	}
+	c.Push(testFrame(4))
+	res, err = c.Save(testEvent("door", 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range res.Sidecar.Files {
+		if _, err := os.Stat(filepath.Join(res.Dir, e.Depth)); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCapturerClosed(t *testing.T) {
+	c, err := NewCapturer(Options{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Process(testFrame(1), []roi.Event{testEvent("door", 1)}); err == nil {
+		t.Error("Process after Close should fail")
+	}
+}
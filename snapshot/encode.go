@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// ColorFormat 彩色图保存格式
+type ColorFormat string
+
+const (
+	ColorJPEG ColorFormat = "jpeg"
+	ColorPNG  ColorFormat = "png"
+)
+
+// DepthFormat 深度图保存格式
+type DepthFormat string
+
+const (
+	DepthPNG16 DepthFormat = "png16" // 16 位灰度 PNG，像素值为原始深度单位
+	DepthNPY   DepthFormat = "npy"   // NumPy .npy (uint16, shape=(h, w))，可直接 np.load
+)
+
+// ext 返回格式对应的文件扩展名
+func (f ColorFormat) ext() string {
+	if f == ColorPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+func (f DepthFormat) ext() string {
+	if f == DepthNPY {
+		return ".npy"
+	}
+	return ".png"
+}
+
+// rgbToImage 将 RGB8 数据转换为 image.RGBA
+func rgbToImage(rgb []byte, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		img.Pix[i*4+0] = rgb[i*3+0]
+		img.Pix[i*4+1] = rgb[i*3+1]
+		img.Pix[i*4+2] = rgb[i*3+2]
+		img.Pix[i*4+3] = 255
+	}
+	return img
+}
+
+// depthToGray16 将深度图转换为 image.Gray16（大端存储）
+func depthToGray16(d *depth.Image) *image.Gray16 {
+	img := image.NewGray16(image.Rect(0, 0, d.Width, d.Height))
+	for i, v := range d.Pix[:d.Width*d.Height] {
+		binary.BigEndian.PutUint16(img.Pix[i*2:], v)
+	}
+	return img
+}
+
+// EncodeColor 按格式编码 RGB8 彩色图
+func EncodeColor(w io.Writer, rgb []byte, width, height int, format ColorFormat, quality int) error {
+	img := rgbToImage(rgb, width, height)
+	switch format {
+	case ColorJPEG, "":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case ColorPNG:
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("snapshot: unknown color format %q", format)
+}
+
+// EncodeDepth 按格式编码深度图
+func EncodeDepth(w io.Writer, d *depth.Image, format DepthFormat) error {
+	switch format {
+	case DepthPNG16, "":
+		return png.Encode(w, depthToGray16(d))
+	case DepthNPY:
+		return WriteNPY(w, d)
+	}
+	return fmt.Errorf("snapshot: unknown depth format %q", format)
+}
+
+// WriteNPY 以 NumPy .npy v1.0 格式写出深度图 (little-endian uint16, C 顺序)
+func WriteNPY(w io.Writer, d *depth.Image) error {
+	header := fmt.Sprintf("{'descr': '<u2', 'fortran_order': False, 'shape': (%d, %d), }", d.Height, d.Width)
+	// 魔数(6) + 版本(2) + 头长度(2) + 头，总长按 64 字节对齐，头以换行结尾
+	total := 10 + len(header) + 1
+	if pad := total % 64; pad != 0 {
+		header += fmt.Sprintf("%*s", 64-pad, "")
+	}
+	header += "\n"
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(bw, binary.LittleEndian, uint16(len(header)))
+	bw.WriteString(header)
+	if err := binary.Write(bw, binary.LittleEndian, d.Pix[:d.Width*d.Height]); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeFile 创建文件并调用 encode 写入
+func writeFile(path string, encode func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encode(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("snapshot: write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,107 @@
+// Package snapshot 实现 ROI 触发后的抓拍动作
+// 保存对齐后的彩色图 (JPEG/PNG)、原始深度 (16 位 PNG 或 .npy) 以及 JSON 附属文件，
+// 并通过环形缓冲保留触发前的若干帧
+package snapshot
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+)
+
+// Frame 是一帧待保存的数据，字段均为 Go 内存（不引用 rs.Frame 的 C 内存）
+type Frame struct {
+	Color       []byte // RGB8，行优先，每像素 3 字节，可为空
+	ColorWidth  int
+	ColorHeight int
+
+	Depth      *depth.Image // Z16，已对齐到彩色，可为空
+	DepthScale float32      // 深度比例（米/单位）
+
+	Timestamp   float64 // 帧时间戳（毫秒）
+	FrameNumber uint64
+}
+
+// NewFrame 拷贝彩色与深度数据创建 Frame
+// rs.Frame.GetRawData/GetDepthData 返回的是 C 内存引用，必须拷贝后才能在帧释放后保存
+func NewFrame(color []byte, colorWidth, colorHeight int, depthData []uint16, depthWidth, depthHeight int) (Frame, error) {
+	var f Frame
+	if color != nil {
+		if len(color) < colorWidth*colorHeight*3 {
+			return Frame{}, fmt.Errorf("snapshot: color buffer too small: %d < %d", len(color), colorWidth*colorHeight*3)
+		}
+		f.Color = append([]byte(nil), color[:colorWidth*colorHeight*3]...)
+		f.ColorWidth, f.ColorHeight = colorWidth, colorHeight
+	}
+	if depthData != nil {
+		img, err := depth.FromBuffer(depthData, depthWidth, depthHeight)
+		if err != nil {
+			return Frame{}, err
+		}
+		f.Depth = img.Clone()
+	}
+	return f, nil
+}
+
+// RingBuffer 保存最近 N 帧，用于触发前回溯，可并发调用
+type RingBuffer struct {
+	mu     sync.Mutex
+	frames []Frame
+	next   int
+	count  int
+}
+
+// NewRingBuffer 创建容量为 size 的环形缓冲
+func NewRingBuffer(size int) (*RingBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("snapshot: invalid ring buffer size %d", size)
+	}
+	return &RingBuffer{frames: make([]Frame, size)}, nil
+}
+
+// Push 追加一帧，缓冲已满时覆盖最旧的一帧
+// Frame 直接保存不拷贝，调用方之后不应再修改其数据
+func (r *RingBuffer) Push(f Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames[r.next] = f
+	r.next = (r.next + 1) % len(r.frames)
+	if r.count < len(r.frames) {
+		r.count++
+	}
+}
+
+// Frames 返回缓冲中的所有帧，按时间从旧到新排列
+func (r *RingBuffer) Frames() []Frame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Frame, 0, r.count)
+	start := (r.next - r.count + len(r.frames)) % len(r.frames)
+	for i := 0; i < r.count; i++ {
+		out = append(out, r.frames[(start+i)%len(r.frames)])
+	}
+	return out
+}
+
+// Len 返回缓冲中的帧数
+func (r *RingBuffer) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// Cap 返回缓冲容量
+func (r *RingBuffer) Cap() int {
+	return len(r.frames)
+}
+
+// Reset 清空缓冲
+func (r *RingBuffer) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.frames {
+		r.frames[i] = Frame{}
+	}
+	r.next, r.count = 0, 0
+}
@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/websocket"
+)
+
+// Client 是帧流的 Go 客户端，用于测试和命令行工具
+// Next 只能在一个 goroutine 中调用，Subscribe 可以并发调用
+type Client struct {
+	conn  *websocket.Conn
+	hello Control
+}
+
+// Dial 连接服务端 (例如 ws://192.168.1.10:8080/ws)，校验协议版本并发送初始订阅
+func Dial(url string, sub Subscription) (*Client, error) {
+	conn, err := websocket.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn}
+
+	op, data, err := conn.ReadMessage()
+	if err == nil && op != websocket.OpText {
+		err = fmt.Errorf("stream: expected hello message")
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &c.hello)
+	}
+	if err == nil && c.hello.Type != ControlHello {
+		err = fmt.Errorf("stream: expected hello message, got %q", c.hello.Type)
+	}
+	if err == nil && c.hello.Version != ProtocolVersion {
+		err = fmt.Errorf("%w %d", ErrVersion, c.hello.Version)
+	}
+	if err == nil {
+		err = c.Subscribe(sub)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Streams 返回服务端可提供的流
+func (c *Client) Streams() []string {
+	return c.hello.Streams
+}
+
+// Subscribe 更新订阅，之后到达的帧按新参数编码
+func (c *Client) Subscribe(sub Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(Control{Type: ControlSubscribe, Version: ProtocolVersion, Subscription: sub})
+	if err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.OpText, data)
+}
+
+// Next 返回下一帧消息，服务端发来错误控制消息时返回该错误
+func (c *Client) Next() (*Message, error) {
+	for {
+		op, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if op == websocket.OpBinary {
+			return Decode(data)
+		}
+		var ctl Control
+		if err := json.Unmarshal(data, &ctl); err != nil {
+			return nil, fmt.Errorf("stream: invalid control message: %w", err)
+		}
+		if ctl.Type == ControlError {
+			return nil, fmt.Errorf("stream: server error: %s", ctl.Message)
+		}
+		// 其它控制消息留给后续版本，忽略
+	}
+}
+
+// NextPayload 返回下一帧并解码为 FramePayload
+func (c *Client) NextPayload() (*FramePayload, error) {
+	m, err := c.Next()
+	if err != nil {
+		return nil, err
+	}
+	return m.Payload()
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
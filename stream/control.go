@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"fmt"
+	"slices"
+)
+
+// 控制消息类型，控制消息以 JSON 文本帧传输，帧数据以二进制帧传输
+const (
+	ControlHello     = "hello"     // 服务端 -> 客户端，连接建立后发送，Streams 为可用的流
+	ControlSubscribe = "subscribe" // 客户端 -> 服务端，设置或更新订阅
+	ControlError     = "error"     // 服务端 -> 客户端，订阅无效等错误
+)
+
+// Subscription 是客户端的订阅参数，可随时重新发送以更新
+type Subscription struct {
+	Streams       []string `json:"streams,omitempty"`        // color / depth / heatmap，为空时不发送帧
+	MaxFPS        float64  `json:"max_fps,omitempty"`        // 最大帧率，0 表示跟随相机帧率
+	MaxWidth      int      `json:"max_width,omitempty"`      // 缩放到不超过该宽度，0 表示原始分辨率
+	Quality       int      `json:"quality,omitempty"`        // JPEG 质量 1-100，0 表示服务端默认
+	DepthEncoding string   `json:"depth_encoding,omitempty"` // deflate（默认）或 raw
+}
+
+// Validate 校验订阅参数
+func (s Subscription) Validate() error {
+	for _, name := range s.Streams {
+		if _, err := ParseStream(name); err != nil {
+			return err
+		}
+	}
+	if s.MaxFPS < 0 {
+		return fmt.Errorf("stream: invalid max fps %g", s.MaxFPS)
+	}
+	if s.MaxWidth < 0 {
+		return fmt.Errorf("stream: invalid max width %d", s.MaxWidth)
+	}
+	if s.Quality < 0 || s.Quality > 100 {
+		return fmt.Errorf("stream: invalid jpeg quality %d", s.Quality)
+	}
+	if _, err := s.Encoding(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Has 判断是否订阅了指定的流
+func (s Subscription) Has(st Stream) bool {
+	return slices.Contains(s.Streams, st.String())
+}
+
+// Encoding 返回深度段的编码方式
+func (s Subscription) Encoding() (Encoding, error) {
+	switch s.DepthEncoding {
+	case "", "deflate":
+		return EncodingZ16Deflate, nil
+	case "raw":
+		return EncodingZ16, nil
+	}
+	return 0, fmt.Errorf("stream: unknown depth encoding %q", s.DepthEncoding)
+}
+
+// Control 是 JSON 控制消息，例如：
+//
+//	{"type":"hello","version":1,"streams":["color","depth","heatmap"]}
+//	{"type":"subscribe","version":1,"streams":["color","depth"],"max_fps":10,"max_width":320}
+//	{"type":"error","version":1,"message":"stream: unknown stream \"ir\""}
+type Control struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`           // 协议版本，与 ProtocolVersion 一致
+	Message string `json:"message,omitempty"` // 错误信息
+	Subscription
+}
@@ -0,0 +1,143 @@
+// Package stream 定义面向上层应用 (Wails 前端等) 的帧数据 FramePayload，
+// 以及通过 WebSocket 传输它的二进制协议：每条消息为固定头部 + 若干段
+// (JPEG 彩色、压缩的原始深度、JPEG 伪彩色)，客户端用 JSON 控制消息订阅流、
+// 指定缩放宽度和最大帧率。包内的 Client 可用于测试和命令行工具
+package stream
+
+import (
+	"fmt"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// FramePayload 是一帧对齐后的数据，所有缓冲的分辨率均为 Width x Height，按行优先存储
+type FramePayload struct {
+	ColorBuffer   []byte   // RGB888 原始图像，可为空
+	DepthBuffer   []uint16 // 16 位原始深度值，0 表示无效，可为空
+	HeatmapBuffer []byte   // 伪彩色深度预览 (RGB888)，可为空
+	DepthScale    float32  // 深度比例（米/单位）
+	Width         int
+	Height        int
+	Timestamp     float64 // 硬件时间戳（毫秒）
+	FrameNumber   uint64
+}
+
+// FromSnapshot 由 snapshot.Frame 创建 FramePayload，缓冲直接引用不拷贝
+// 彩色和深度同时存在时必须已对齐（分辨率相同）
+func FromSnapshot(f snapshot.Frame) (*FramePayload, error) {
+	p := &FramePayload{
+		ColorBuffer: f.Color,
+		DepthScale:  f.DepthScale,
+		Timestamp:   f.Timestamp,
+		FrameNumber: f.FrameNumber,
+		Width:       f.ColorWidth,
+		Height:      f.ColorHeight,
+	}
+	if f.Depth != nil {
+		if f.Color != nil && (f.Depth.Width != f.ColorWidth || f.Depth.Height != f.ColorHeight) {
+			return nil, fmt.Errorf("stream: depth %dx%d is not aligned to color %dx%d",
+				f.Depth.Width, f.Depth.Height, f.ColorWidth, f.ColorHeight)
+		}
+		p.DepthBuffer = f.Depth.Pix
+		p.Width, p.Height = f.Depth.Width, f.Depth.Height
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate 检查尺寸与缓冲长度是否一致
+func (p *FramePayload) Validate() error {
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("stream: invalid payload size %dx%d", p.Width, p.Height)
+	}
+	if p.Width > 0xffff || p.Height > 0xffff {
+		return fmt.Errorf("stream: payload size %dx%d too large", p.Width, p.Height)
+	}
+	n := p.Width * p.Height
+	if p.ColorBuffer != nil && len(p.ColorBuffer) != n*3 {
+		return fmt.Errorf("stream: color buffer has %d bytes, want %d", len(p.ColorBuffer), n*3)
+	}
+	if p.DepthBuffer != nil && len(p.DepthBuffer) != n {
+		return fmt.Errorf("stream: depth buffer has %d pixels, want %d", len(p.DepthBuffer), n)
+	}
+	if p.HeatmapBuffer != nil && len(p.HeatmapBuffer) != n*3 {
+		return fmt.Errorf("stream: heatmap buffer has %d bytes, want %d", len(p.HeatmapBuffer), n*3)
+	}
+	return nil
+}
+
+// Distance 返回 (x, y) 处的距离（米），无效深度或越界返回 0
+func (p *FramePayload) Distance(x, y int) float32 {
+	if p.DepthBuffer == nil || x < 0 || y < 0 || x >= p.Width || y >= p.Height {
+		return 0
+	}
+	return float32(p.DepthBuffer[y*p.Width+x]) * p.DepthScale
+}
+
+// Colorize 由 DepthBuffer 渲染 HeatmapBuffer
+func (p *FramePayload) Colorize(opts depth.ColorizeOptions) error {
+	if p.DepthBuffer == nil {
+		return fmt.Errorf("stream: payload has no depth buffer")
+	}
+	scale := p.DepthScale
+	if scale <= 0 {
+		scale = depth.DefaultDepthScale
+	}
+	rgb, err := depth.ColorizeDepth(p.DepthBuffer, scale, opts)
+	if err != nil {
+		return err
+	}
+	p.HeatmapBuffer = rgb
+	return nil
+}
+
+// Downscale 按最近邻缩放到不超过 maxWidth 的宽度（保持宽高比），返回新的 FramePayload
+// 深度值不能插值（会在物体边缘产生不存在的距离），彩色和伪彩色同样取最近邻以保持逐像素对应
+// maxWidth <= 0 或不小于当前宽度时返回 p 本身
+func (p *FramePayload) Downscale(maxWidth int) *FramePayload {
+	if maxWidth <= 0 || maxWidth >= p.Width {
+		return p
+	}
+	w := maxWidth
+	h := max(p.Height*w/p.Width, 1)
+
+	out := *p
+	out.Width, out.Height = w, h
+	// 源坐标：目标像素中心对应的源像素
+	xs := make([]int, w)
+	for x := range xs {
+		xs[x] = (2*x + 1) * p.Width / (2 * w)
+	}
+	if p.ColorBuffer != nil {
+		out.ColorBuffer = downscaleRGB(p.ColorBuffer, p.Width, p.Height, xs, h)
+	}
+	if p.HeatmapBuffer != nil {
+		out.HeatmapBuffer = downscaleRGB(p.HeatmapBuffer, p.Width, p.Height, xs, h)
+	}
+	if p.DepthBuffer != nil {
+		out.DepthBuffer = make([]uint16, w*h)
+		for y := 0; y < h; y++ {
+			row := p.DepthBuffer[((2*y+1)*p.Height/(2*h))*p.Width:]
+			for x, sx := range xs {
+				out.DepthBuffer[y*w+x] = row[sx]
+			}
+		}
+	}
+	return &out
+}
+
+// downscaleRGB 按最近邻缩放 RGB888 数据
+func downscaleRGB(src []byte, width, height int, xs []int, h int) []byte {
+	w := len(xs)
+	dst := make([]byte, w*h*3)
+	for y := 0; y < h; y++ {
+		row := src[((2*y+1)*height/(2*h))*width*3:]
+		for x, sx := range xs {
+			copy(dst[(y*w+x)*3:(y*w+x)*3+3], row[sx*3:sx*3+3])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/tianfei212/jetson-rs-middleware/depth"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+func TestDownscaleIndices(t *testing.T) {
+	// 深度值编码源坐标：value = 100*y + x
+	src := func(w, h int) *FramePayload {
+		p := &FramePayload{DepthBuffer: make([]uint16, w*h), ColorBuffer: make([]byte, w*h*3), Width: w, Height: h}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				p.DepthBuffer[y*w+x] = uint16(100*y + x)
+				p.ColorBuffer[(y*w+x)*3] = byte(x)
+				p.ColorBuffer[(y*w+x)*3+1] = byte(y)
+			}
+		}
+		return p
+	}
+	tests := []struct {
+		name     string
+		w, h     int
+		maxWidth int
+		outW     int
+		outH     int
+		xs, ys   []int // 目标像素中心对应的源坐标
+	}{
+		{"half", 8, 4, 4, 4, 2, []int{1, 3, 5, 7}, []int{1, 3}},
+		{"third", 9, 6, 3, 3, 2, []int{1, 4, 7}, []int{1, 4}},
+		{"non-integer", 10, 5, 4, 4, 2, []int{1, 3, 6, 8}, []int{1, 3}},
+		{"single pixel", 8, 2, 1, 1, 1, []int{4}, []int{1}}, // 高度至少为 1
+		{"flat", 16, 1, 5, 5, 1, []int{1, 4, 8, 11, 14}, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := src(tt.w, tt.h).Downscale(tt.maxWidth)
+			if out.Width != tt.outW || out.Height != tt.outH {
+				t.Fatalf("size %dx%d, want %dx%d", out.Width, out.Height, tt.outW, tt.outH)
+			}
+			if err := out.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			for y, sy := range tt.ys {
+				for x, sx := range tt.xs {
+					i := y*out.Width + x
+					if got := out.DepthBuffer[i]; got != uint16(100*sy+sx) {
+						t.Errorf("depth (%d,%d) = %d, want source (%d,%d)", x, y, got, sx, sy)
+					}
+					// 彩色与深度取同一个源像素
+					if out.ColorBuffer[i*3] != byte(sx) || out.ColorBuffer[i*3+1] != byte(sy) {
+						t.Errorf("color (%d,%d) from (%d,%d)", x, y, out.ColorBuffer[i*3], out.ColorBuffer[i*3+1])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDownscaleNoop(t *testing.T) {
+	p := testPayload(8, 4)
+	for _, w := range []int{0, -1, 8, 100} {
+		if p.Downscale(w) != p {
+			t.Errorf("Downscale(%d) copied the payload", w)
+		}
+	}
+	// 缩放不修改原始帧
+	orig := slices.Clone(p.DepthBuffer)
+	p.Downscale(2)
+	if !slices.Equal(p.DepthBuffer, orig) {
+		t.Error("Downscale modified the source")
+	}
+}
+
+func TestFromSnapshot(t *testing.T) {
+	color := make([]byte, 8*4*3)
+	data := make([]uint16, 8*4)
+	data[9] = 2000
+	f, err := snapshot.NewFrame(color, 8, 4, data, 8, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.DepthScale = 0.001
+	p, err := FromSnapshot(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Width != 8 || p.Height != 4 || p.Distance(1, 1) != 2 || p.Distance(8, 0) != 0 || p.Distance(-1, 0) != 0 {
+		t.Errorf("payload %dx%d, distance %g", p.Width, p.Height, p.Distance(1, 1))
+	}
+
+	// 未对齐的彩色和深度被拒绝
+	f.Depth = depth.NewImage(4, 4)
+	if _, err := FromSnapshot(f); err == nil {
+		t.Error("unaligned frame accepted")
+	}
+}
+
+func TestColorizeDefaultScale(t *testing.T) {
+	p := testPayload(8, 4)
+	p.DepthScale = 0
+	if err := p.Colorize(depth.ColorizeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.HeatmapBuffer) != 8*4*3 {
+		t.Errorf("heatmap has %d bytes", len(p.HeatmapBuffer))
+	}
+	p.DepthBuffer = nil
+	if err := p.Colorize(depth.ColorizeOptions{}); err == nil {
+		t.Error("colorize without depth succeeded")
+	}
+}
@@ -0,0 +1,345 @@
+package stream
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"math"
+
+	"github.com/tianfei212/jetson-rs-middleware/internal/z16"
+	"github.com/tianfei212/jetson-rs-middleware/snapshot"
+)
+
+// 二进制消息格式（小端）：
+//
+//	头部 (32 字节)
+//	  0   magic        "RSWS"
+//	  4   version      uint8   协议版本，当前为 ProtocolVersion
+//	  5   sections     uint8   段数
+//	  6   reserved     uint16
+//	  8   frameNumber  uint64
+//	  16  timestamp    float64 毫秒
+//	  24  depthScale   float32 米/单位
+//	  28  width        uint16  所有段的分辨率
+//	  30  height       uint16
+//	段 (重复 sections 次)
+//	  0   stream       uint8   1=彩色 2=深度 3=伪彩色
+//	  1   encoding     uint8   1=JPEG 2=Z16 原始 3=Z16 逐行差分+deflate
+//	  2   reserved     uint16
+//	  4   length       uint32  data 字节数
+//	  8   data
+//
+// 不兼容的格式修改增加 ProtocolVersion，解码端遇到未知版本应拒绝；
+// 未知的 stream 或 encoding 的段可按 length 跳过
+const (
+	ProtocolVersion = 1
+	Magic           = "RSWS"
+
+	headerSize  = 32
+	sectionSize = 8
+)
+
+// ErrVersion 表示消息的协议版本不受支持
+var ErrVersion = errors.New("stream: unsupported protocol version")
+
+// Stream 是消息中的段类型
+type Stream uint8
+
+const (
+	StreamColor   Stream = 1 // 彩色图
+	StreamDepth   Stream = 2 // 原始深度
+	StreamHeatmap Stream = 3 // 伪彩色深度
+)
+
+// streamNames 是订阅消息中使用的流名称
+var streamNames = map[Stream]string{
+	StreamColor:   "color",
+	StreamDepth:   "depth",
+	StreamHeatmap: "heatmap",
+}
+
+// String 返回流名称
+func (s Stream) String() string {
+	if name, ok := streamNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("stream(%d)", s)
+}
+
+// ParseStream 按名称解析流类型 (color/depth/heatmap)
+func ParseStream(name string) (Stream, error) {
+	for s, n := range streamNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("stream: unknown stream %q", name)
+}
+
+// Encoding 是段的编码方式
+type Encoding uint8
+
+const (
+	EncodingJPEG       Encoding = 1 // 彩色/伪彩色
+	EncodingZ16        Encoding = 2 // 深度，uint16 小端原始数据
+	EncodingZ16Deflate Encoding = 3 // 深度，逐行差分后 deflate 压缩，与 archive 包相同
+)
+
+// String 返回编码名称
+func (e Encoding) String() string {
+	switch e {
+	case EncodingJPEG:
+		return "jpeg"
+	case EncodingZ16:
+		return "raw"
+	case EncodingZ16Deflate:
+		return "deflate"
+	}
+	return fmt.Sprintf("encoding(%d)", e)
+}
+
+// Section 是消息中的一段
+type Section struct {
+	Stream   Stream
+	Encoding Encoding
+	Data     []byte // 编码后的数据，JPEG 段可直接写入文件
+}
+
+// Message 是解码后的一条帧消息，段数据尚未解码
+type Message struct {
+	Version     int
+	FrameNumber uint64
+	Timestamp   float64
+	DepthScale  float32
+	Width       int
+	Height      int
+	Sections    []Section
+}
+
+// Section 返回指定流的段
+func (m *Message) Section(s Stream) (Section, bool) {
+	for _, sec := range m.Sections {
+		if sec.Stream == s {
+			return sec, true
+		}
+	}
+	return Section{}, false
+}
+
+// EncodeOptions 编码参数
+type EncodeOptions struct {
+	Quality       int      // JPEG 质量 1-100，默认 80
+	DepthEncoding Encoding // EncodingZ16 或 EncodingZ16Deflate（默认）
+}
+
+// Encode 将 FramePayload 中非空的缓冲编码为一条二进制消息
+func Encode(p *FramePayload, opts EncodeOptions) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Quality == 0 {
+		opts.Quality = 80
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return nil, fmt.Errorf("stream: invalid jpeg quality %d", opts.Quality)
+	}
+	if opts.DepthEncoding == 0 {
+		opts.DepthEncoding = EncodingZ16Deflate
+	}
+	if opts.DepthEncoding != EncodingZ16 && opts.DepthEncoding != EncodingZ16Deflate {
+		return nil, fmt.Errorf("stream: invalid depth encoding %s", opts.DepthEncoding)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(headerSize + p.Width*p.Height)
+	var hdr [headerSize]byte
+	copy(hdr[:], Magic)
+	hdr[4] = ProtocolVersion
+	binary.LittleEndian.PutUint64(hdr[8:], p.FrameNumber)
+	binary.LittleEndian.PutUint64(hdr[16:], math.Float64bits(p.Timestamp))
+	binary.LittleEndian.PutUint32(hdr[24:], math.Float32bits(p.DepthScale))
+	binary.LittleEndian.PutUint16(hdr[28:], uint16(p.Width))
+	binary.LittleEndian.PutUint16(hdr[30:], uint16(p.Height))
+	buf.Write(hdr[:])
+
+	count := 0
+	// section 写入段头部，数据由 encode 写出后回填长度
+	section := func(s Stream, e Encoding, encode func(w io.Writer) error) error {
+		start := buf.Len()
+		buf.Write([]byte{byte(s), byte(e), 0, 0, 0, 0, 0, 0})
+		if err := encode(&buf); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(buf.Bytes()[start+4:], uint32(buf.Len()-start-sectionSize))
+		count++
+		return nil
+	}
+
+	if p.ColorBuffer != nil {
+		err := section(StreamColor, EncodingJPEG, func(w io.Writer) error {
+			return snapshot.EncodeColor(w, p.ColorBuffer, p.Width, p.Height, snapshot.ColorJPEG, opts.Quality)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.DepthBuffer != nil {
+		err := section(StreamDepth, opts.DepthEncoding, func(w io.Writer) error {
+			return encodeDepth(w, p.DepthBuffer, p.Width, opts.DepthEncoding)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.HeatmapBuffer != nil {
+		err := section(StreamHeatmap, EncodingJPEG, func(w io.Writer) error {
+			return snapshot.EncodeColor(w, p.HeatmapBuffer, p.Width, p.Height, snapshot.ColorJPEG, opts.Quality)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := buf.Bytes()
+	out[5] = byte(count)
+	return out, nil
+}
+
+// encodeDepth 写出深度段数据
+func encodeDepth(w io.Writer, data []uint16, width int, enc Encoding) error {
+	raw := make([]byte, len(data)*2)
+	if enc == EncodingZ16 {
+		for i, v := range data {
+			binary.LittleEndian.PutUint16(raw[i*2:], v)
+		}
+		_, err := w.Write(raw)
+		return err
+	}
+	z16.DeltaEncode(raw, data, width)
+	// 实时传输优先延迟，使用最快的压缩级别
+	zw, err := flate.NewWriter(w, flate.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(raw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decode 解析一条二进制消息，段数据保持编码状态（引用 b，不拷贝）
+func Decode(b []byte) (*Message, error) {
+	if len(b) < headerSize || string(b[:4]) != Magic {
+		return nil, fmt.Errorf("stream: not a frame message")
+	}
+	if b[4] != ProtocolVersion {
+		return nil, fmt.Errorf("%w %d", ErrVersion, b[4])
+	}
+	m := &Message{
+		Version:     int(b[4]),
+		FrameNumber: binary.LittleEndian.Uint64(b[8:]),
+		Timestamp:   math.Float64frombits(binary.LittleEndian.Uint64(b[16:])),
+		DepthScale:  math.Float32frombits(binary.LittleEndian.Uint32(b[24:])),
+		Width:       int(binary.LittleEndian.Uint16(b[28:])),
+		Height:      int(binary.LittleEndian.Uint16(b[30:])),
+	}
+	count := int(b[5])
+	b = b[headerSize:]
+	for i := 0; i < count; i++ {
+		if len(b) < sectionSize {
+			return nil, fmt.Errorf("stream: truncated section header %d", i)
+		}
+		n := binary.LittleEndian.Uint32(b[4:])
+		if uint64(len(b)-sectionSize) < uint64(n) {
+			return nil, fmt.Errorf("stream: truncated section %d: %d < %d", i, len(b)-sectionSize, n)
+		}
+		m.Sections = append(m.Sections, Section{
+			Stream:   Stream(b[0]),
+			Encoding: Encoding(b[1]),
+			Data:     b[sectionSize : sectionSize+int(n)],
+		})
+		b = b[sectionSize+int(n):]
+	}
+	return m, nil
+}
+
+// Payload 解码所有已知的段，得到 FramePayload；未知的段被忽略
+func (m *Message) Payload() (*FramePayload, error) {
+	p := &FramePayload{
+		DepthScale:  m.DepthScale,
+		Width:       m.Width,
+		Height:      m.Height,
+		Timestamp:   m.Timestamp,
+		FrameNumber: m.FrameNumber,
+	}
+	for _, sec := range m.Sections {
+		var err error
+		switch sec.Stream {
+		case StreamColor:
+			p.ColorBuffer, err = decodeRGB(sec, m.Width, m.Height)
+		case StreamHeatmap:
+			p.HeatmapBuffer, err = decodeRGB(sec, m.Width, m.Height)
+		case StreamDepth:
+			p.DepthBuffer, err = decodeDepth(sec, m.Width, m.Height)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stream: %s section: %w", sec.Stream, err)
+		}
+	}
+	return p, p.Validate()
+}
+
+// decodeRGB 将 JPEG 段解码为 RGB888
+func decodeRGB(sec Section, width, height int) ([]byte, error) {
+	if sec.Encoding != EncodingJPEG {
+		return nil, fmt.Errorf("unsupported encoding %s", sec.Encoding)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(sec.Data))
+	if err != nil {
+		return nil, err
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		return nil, fmt.Errorf("image size %dx%d does not match header %dx%d", b.Dx(), b.Dy(), width, height)
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	rgb := make([]byte, width*height*3)
+	for i := 0; i < width*height; i++ {
+		copy(rgb[i*3:i*3+3], rgba.Pix[i*4:i*4+3])
+	}
+	return rgb, nil
+}
+
+// decodeDepth 解码深度段
+func decodeDepth(sec Section, width, height int) ([]uint16, error) {
+	raw := sec.Data
+	switch sec.Encoding {
+	case EncodingZ16:
+	case EncodingZ16Deflate:
+		var err error
+		raw, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(sec.Data)), int64(width*height*2)+1))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %s", sec.Encoding)
+	}
+	if len(raw) != width*height*2 {
+		return nil, fmt.Errorf("depth data has %d bytes, want %d", len(raw), width*height*2)
+	}
+	data := make([]uint16, width*height)
+	if sec.Encoding == EncodingZ16Deflate {
+		z16.DeltaDecode(data, raw, width)
+		return data, nil
+	}
+	for i := range data {
+		data[i] = binary.LittleEndian.Uint16(raw[i*2:])
+	}
+	return data, nil
+}
@@ -0,0 +1,246 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testPayload 生成 w x h 的合成帧：彩色为按列渐变，深度为带无效点的斜坡
+func testPayload(w, h int) *FramePayload {
+	p := &FramePayload{
+		ColorBuffer: make([]byte, w*h*3),
+		DepthBuffer: make([]uint16, w*h),
+		DepthScale:  0.00025,
+		Width:       w,
+		Height:      h,
+		Timestamp:   12345.678,
+		FrameNumber: 42,
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			p.ColorBuffer[i*3] = byte(x * 255 / w)
+			p.ColorBuffer[i*3+1] = 128
+			p.ColorBuffer[i*3+2] = byte(y * 255 / h)
+			if x%7 != 3 {
+				p.DepthBuffer[i] = uint16(1000 + 13*x + 101*y)
+			}
+		}
+	}
+	return p
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	for _, enc := range []Encoding{EncodingZ16, EncodingZ16Deflate} {
+		t.Run(enc.String(), func(t *testing.T) {
+			in := testPayload(32, 16)
+			in.HeatmapBuffer = in.ColorBuffer
+			b, err := Encode(in, EncodeOptions{Quality: 95, DepthEncoding: enc})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := Decode(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Version != ProtocolVersion || m.FrameNumber != 42 || m.Timestamp != 12345.678 ||
+				m.DepthScale != 0.00025 || m.Width != 32 || m.Height != 16 {
+				t.Errorf("header %+v", m)
+			}
+			var streams []Stream
+			for _, sec := range m.Sections {
+				streams = append(streams, sec.Stream)
+			}
+			if !slices.Equal(streams, []Stream{StreamColor, StreamDepth, StreamHeatmap}) {
+				t.Errorf("sections %v", streams)
+			}
+			if sec, _ := m.Section(StreamDepth); sec.Encoding != enc {
+				t.Errorf("depth encoding %s", sec.Encoding)
+			}
+
+			out, err := m.Payload()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 深度无损，彩色经过 JPEG 有损压缩
+			if !slices.Equal(out.DepthBuffer, in.DepthBuffer) {
+				t.Error("depth differs after round trip")
+			}
+			for name, buf := range map[string][]byte{"color": out.ColorBuffer, "heatmap": out.HeatmapBuffer} {
+				if len(buf) != len(in.ColorBuffer) {
+					t.Fatalf("%s has %d bytes", name, len(buf))
+				}
+				for i := range buf {
+					if d := int(buf[i]) - int(in.ColorBuffer[i]); d > 24 || d < -24 {
+						t.Fatalf("%s byte %d = %d, want about %d", name, i, buf[i], in.ColorBuffer[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDeflateSmallerThanRaw(t *testing.T) {
+	p := testPayload(64, 48)
+	p.ColorBuffer = nil
+	raw, err := Encode(p, EncodeOptions{DepthEncoding: EncodingZ16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deflated, err := Encode(p, EncodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != headerSize+sectionSize+64*48*2 || len(deflated) >= len(raw)/2 {
+		t.Errorf("raw %d bytes, deflate %d bytes", len(raw), len(deflated))
+	}
+}
+
+func TestEncodeOptionsInvalid(t *testing.T) {
+	p := testPayload(4, 4)
+	tests := []struct {
+		name string
+		p    *FramePayload
+		opts EncodeOptions
+	}{
+		{"quality", p, EncodeOptions{Quality: 101}},
+		{"depth encoding", p, EncodeOptions{DepthEncoding: EncodingJPEG}},
+		{"short depth", &FramePayload{DepthBuffer: make([]uint16, 15), Width: 4, Height: 4}, EncodeOptions{}},
+		{"zero size", &FramePayload{}, EncodeOptions{}},
+	}
+	for _, tt := range tests {
+		if _, err := Encode(tt.p, tt.opts); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	p := testPayload(8, 4)
+	good, err := Encode(p, EncodeOptions{DepthEncoding: EncodingZ16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := func(fn func(b []byte) []byte) []byte {
+		return fn(append([]byte(nil), good...))
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+		err  string
+	}{
+		{"empty", nil, "not a frame message"},
+		{"short header", good[:headerSize-1], "not a frame message"},
+		{"bad magic", modified(func(b []byte) []byte { b[0] = 'X'; return b }), "not a frame message"},
+		{"truncated section", good[:len(good)-1], "truncated section 1"},
+		{"truncated section header", good[:headerSize+4], "truncated section header 0"},
+		{"missing section", modified(func(b []byte) []byte { b[5]++; return b }), "truncated section header 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.b)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	for _, v := range []byte{0, ProtocolVersion + 1, 255} {
+		b := modified(func(b []byte) []byte { b[4] = v; return b })
+		if _, err := Decode(b); !errors.Is(err, ErrVersion) {
+			t.Errorf("version %d: error %v, want ErrVersion", v, err)
+		}
+	}
+}
+
+func TestDecodeSkipsUnknownSections(t *testing.T) {
+	p := testPayload(8, 4)
+	p.ColorBuffer = nil
+	b, err := Encode(p, EncodeOptions{DepthEncoding: EncodingZ16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 在深度段前插入一个未知类型的段
+	unknown := []byte{99, 7, 0, 0, 3, 0, 0, 0, 'a', 'b', 'c'}
+	b = slices.Concat(b[:headerSize], unknown, b[headerSize:])
+	b[5]++
+
+	m, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Sections) != 2 || !bytes.Equal(m.Sections[0].Data, []byte("abc")) {
+		t.Fatalf("sections %+v", m.Sections)
+	}
+	out, err := m.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(out.DepthBuffer, p.DepthBuffer) || out.ColorBuffer != nil {
+		t.Error("payload differs")
+	}
+}
+
+func TestPayloadSectionErrors(t *testing.T) {
+	p := testPayload(8, 4)
+	p.ColorBuffer = nil
+	tests := []struct {
+		name string
+		sec  Section
+		err  string
+	}{
+		{"raw depth too short", Section{StreamDepth, EncodingZ16, make([]byte, 63)}, "depth data has 63 bytes, want 64"},
+		{"deflate garbage", Section{StreamDepth, EncodingZ16Deflate, []byte{0xff, 0xff}}, "depth section"},
+		{"depth as jpeg", Section{StreamDepth, EncodingJPEG, nil}, "unsupported encoding jpeg"},
+		{"color as raw", Section{StreamColor, EncodingZ16, nil}, "unsupported encoding raw"},
+		{"bad jpeg", Section{StreamColor, EncodingJPEG, []byte("not a jpeg")}, "color section"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Message{Version: ProtocolVersion, Width: 8, Height: 4, Sections: []Section{tt.sec}}
+			_, err := m.Payload()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	// 深度段比头部的分辨率多出数据也视为错误
+	b, err := Encode(testPayload(8, 8), EncodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Height = 4
+	m.Sections = m.Sections[1:] // 去掉彩色段
+	if _, err := m.Payload(); err == nil || !strings.Contains(err.Error(), "want 64") {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	tests := []struct {
+		sub Subscription
+		ok  bool
+	}{
+		{Subscription{}, true},
+		{Subscription{Streams: []string{"color", "depth", "heatmap"}, MaxFPS: 10, MaxWidth: 320, Quality: 50, DepthEncoding: "raw"}, true},
+		{Subscription{Streams: []string{"ir"}}, false},
+		{Subscription{MaxFPS: -1}, false},
+		{Subscription{MaxWidth: -1}, false},
+		{Subscription{Quality: 101}, false},
+		{Subscription{DepthEncoding: "zstd"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.sub.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v: error %v", tt.sub, err)
+		}
+	}
+}